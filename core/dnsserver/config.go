@@ -2,8 +2,12 @@ package dnsserver
 
 import (
 	"crypto/tls"
+	"fmt"
+	"net"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
+
 	"github.com/mholt/caddy"
 )
 
@@ -32,6 +36,29 @@ type Config struct {
 	// TLSConfig when listening for encrypted connections (gRPC, DNS-over-TLS).
 	TLSConfig *tls.Config
 
+	// View is the name of the view this server block belongs to. Multiple server blocks
+	// may serve the same zone on the same address if they are part of different views.
+	View string
+
+	// FilterFuncs decide if a query is handled by this server block, all of them must
+	// return true. A server block without them handles every query for its zone.
+	FilterFuncs []FilterFunc
+
+	// TsigSecret holds the TSIG keys queries may be signed with, keyed by key name.
+	TsigSecret map[string]string
+
+	// ProxyProtocol holds the networks of the load balancers that may prefix connections
+	// and datagrams with a PROXY protocol header. Nil if no headers are accepted.
+	ProxyProtocol []*net.IPNet
+
+	// ReusePort is the number of UDP sockets that are opened with SO_REUSEPORT on the
+	// address, each served by its own read loop. Zero opens a single socket without it.
+	ReusePort int
+
+	// ConnLimits limits the TCP, DNS-over-TLS and gRPC connections of the server, nil
+	// if there are no limits.
+	ConnLimits *ConnLimits
+
 	// Plugin stack.
 	Plugin []plugin.Plugin
 
@@ -42,18 +69,31 @@ type Config struct {
 	// on them should register themselves here. The name should be the name as return by the
 	// Handler's Name method.
 	registry map[string]plugin.Handler
+
+	// The registered handlers in the order of the plugin chain.
+	handlers []plugin.Handler
 }
 
 // GetConfig gets the Config that corresponds to c.
 // If none exist nil is returned.
 func GetConfig(c *caddy.Controller) *Config {
 	ctx := c.Context().(*dnsContext)
-	if cfg, ok := ctx.keysToConfigs[c.Key]; ok {
+	key := keyForConfig(c.ServerBlockIndex, c.ServerBlockKeyIndex)
+	if cfg, ok := ctx.keysToConfigs[key]; ok {
 		return cfg
 	}
 	// we should only get here during tests because directive
 	// actions typically skip the server blocks where we make
 	// the configs.
-	ctx.saveConfig(c.Key, &Config{})
+	ctx.saveConfig(key, &Config{})
 	return GetConfig(c)
 }
+
+// FilterFunc returns true if the query in state should be handled by a server block.
+type FilterFunc func(state request.Request) bool
+
+// keyForConfig returns the key under which the config of a server block key is saved. The
+// server block keys themselves can't be used, as views allow the same zone in multiple blocks.
+func keyForConfig(blockIndex, blockKeyIndex int) string {
+	return fmt.Sprintf("%d:%d", blockIndex, blockKeyIndex)
+}
@@ -0,0 +1,207 @@
+package dnsserver
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coredns/coredns/plugin/metrics/vars"
+
+	"github.com/miekg/dns"
+)
+
+// ConnLimits limits the TCP, DNS-over-TLS and gRPC connections of a server. A zero value means no
+// limit, or the default of the DNS library for IdleTimeout.
+type ConnLimits struct {
+	Max          int           // maximum number of open connections
+	MaxPerClient int           // maximum number of open connections from one client address
+	IdleTimeout  time.Duration // time a connection may be idle between queries
+	MaxQueries   int           // maximum number of queries on one connection
+}
+
+// limitListener rejects connections that exceed the connection limits. Connections over the
+// maximum are closed at once. The client of a connection is only known after a PROXY protocol
+// header is read, which must not block Accept, so the limit per client is applied when the
+// connection is first read from: connections over it are closed then.
+type limitListener struct {
+	net.Listener
+	limits ConnLimits
+	server string // address of the server, for the metrics
+
+	mu      sync.Mutex
+	open    int
+	clients map[string]int
+}
+
+func newLimitListener(l net.Listener, limits ConnLimits, server string) *limitListener {
+	return &limitListener{Listener: l, limits: limits, server: server, clients: make(map[string]int)}
+}
+
+// Accept implements the net.Listener interface.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.acquire() {
+			vars.TCPConnectionsRejected.WithLabelValues(l.server, "max_connections").Inc()
+			c.Close()
+			continue
+		}
+		vars.TCPConnections.WithLabelValues(l.server).Inc()
+		return &limitConn{Conn: c, l: l}, nil
+	}
+}
+
+// acquire accounts for a new connection. It returns false if that exceeds the maximum, then
+// nothing is accounted.
+func (l *limitListener) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.Max > 0 && l.open >= l.limits.Max {
+		return false
+	}
+	l.open++
+	return true
+}
+
+// acquireClient accounts for a connection from client. It returns false if that exceeds the
+// maximum per client, then nothing is accounted.
+func (l *limitListener) acquireClient(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.MaxPerClient > 0 && l.clients[client] >= l.limits.MaxPerClient {
+		return false
+	}
+	l.clients[client]++
+	return true
+}
+
+// release accounts for a closed connection, and for its client if that was accounted for.
+func (l *limitListener) release(client string, accounted bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.open--
+	if accounted {
+		if l.clients[client]--; l.clients[client] <= 0 {
+			delete(l.clients, client)
+		}
+	}
+	vars.TCPConnections.WithLabelValues(l.server).Dec()
+}
+
+// limitConn is a connection accepted by a limitListener.
+type limitConn struct {
+	net.Conn
+	l       *limitListener
+	queries int64
+
+	first sync.Once // accounts for the client on the first Read
+	err   error     // set when the client is over its limit
+
+	mu        sync.Mutex
+	client    string
+	accounted bool // the client is accounted for
+	closed    bool
+}
+
+// Read implements the net.Conn interface.
+func (c *limitConn) Read(b []byte) (int, error) {
+	c.first.Do(c.acquireClient)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.Conn.Read(b)
+}
+
+// acquireClient accounts for the client of c, or closes c if the client is over its limit. This may
+// read the PROXY protocol header, so it is done by the reader of the connection, under its read
+// deadline.
+func (c *limitConn) acquireClient() {
+	client := clientHost(c.Conn.RemoteAddr())
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		c.err = errClosed
+		return
+	}
+	if c.l.acquireClient(client) {
+		c.client, c.accounted = client, true
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	vars.TCPConnectionsRejected.WithLabelValues(c.l.server, "max_connections_per_client").Inc()
+	c.err = errMaxPerClient
+	c.Close()
+}
+
+// Close implements the net.Conn interface.
+func (c *limitConn) Close() error {
+	c.mu.Lock()
+	if !c.closed {
+		c.closed = true
+		c.l.release(c.client, c.accounted)
+	}
+	c.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// queryLimitReader refuses to read more than max queries from a connection, which makes the
+// DNS library close it.
+type queryLimitReader struct {
+	dns.Reader
+	max int64
+}
+
+// ReadTCP implements the dns.Reader interface.
+func (r *queryLimitReader) ReadTCP(conn net.Conn, timeout time.Duration) ([]byte, error) {
+	if c, ok := conn.(*limitConn); ok && atomic.AddInt64(&c.queries, 1) > r.max {
+		return nil, errMaxQueries
+	}
+	return r.Reader.ReadTCP(conn, timeout)
+}
+
+// clientHost returns the host of addr.
+func clientHost(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+var (
+	errMaxQueries   = errors.New("maximum number of queries on connection reached")
+	errMaxPerClient = errors.New("maximum number of connections from client reached")
+	errClosed       = errors.New("use of closed connection")
+)
+
+// limitServer applies the limits that are handled by the DNS library to srv.
+func (l ConnLimits) limitServer(srv *dns.Server) {
+	if l.IdleTimeout > 0 {
+		idle := l.IdleTimeout
+		srv.IdleTimeout = func() time.Duration { return idle }
+	}
+	if l.MaxQueries > 0 {
+		max := int64(l.MaxQueries)
+		srv.DecorateReader = func(r dns.Reader) dns.Reader { return &queryLimitReader{Reader: r, max: max} }
+	}
+}
+
+// limit returns l with the connection limits of s applied.
+func (s *Server) limit(l net.Listener) net.Listener {
+	if s.limits == (ConnLimits{}) {
+		return l
+	}
+	return newLimitListener(l, s.limits, s.Addr)
+}
@@ -0,0 +1,157 @@
+package dnsserver
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
+)
+
+func TestLimitListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	l := newLimitListener(ln, ConnLimits{Max: 3, MaxPerClient: 2}, "dns://:53")
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 10)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	// dial connects and sends a byte, read returns the error of reading it from the accepted end.
+	dial := func() net.Conn {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+		c.Write([]byte{0})
+		return c
+	}
+	read := func(c net.Conn) error {
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		_, err := c.Read(make([]byte, 1))
+		return err
+	}
+	closed := func(c net.Conn) bool {
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		_, err := c.Read(make([]byte, 1))
+		return err != nil && !isTimeout(err)
+	}
+
+	c1, c2 := dial(), dial()
+	a1, a2 := <-accepted, <-accepted
+	if err := read(a1); err != nil {
+		t.Fatalf("Expected first connection to be read, got %s", err)
+	}
+	if err := read(a2); err != nil {
+		t.Fatalf("Expected second connection to be read, got %s", err)
+	}
+
+	// A third connection from the same client is rejected on its first read.
+	c3 := dial()
+	if err := read(<-accepted); err != errMaxPerClient {
+		t.Errorf("Expected %q, got %v", errMaxPerClient, err)
+	}
+	if !closed(c3) {
+		t.Errorf("Expected third connection to be closed")
+	}
+
+	// After one is closed, there is room again.
+	a1.Close()
+	c1.Close()
+	c4 := dial()
+	a4 := <-accepted
+	if err := read(a4); err != nil {
+		t.Fatalf("Expected fourth connection to be read, got %s", err)
+	}
+
+	if l.open != 2 {
+		t.Errorf("Expected 2 open connections, got %d", l.open)
+	}
+
+	// Closing twice releases once.
+	a2.Close()
+	a2.Close()
+	a4.Close()
+	c2.Close()
+	c4.Close()
+	if l.open != 0 || len(l.clients) != 0 {
+		t.Errorf("Expected no open connections, got %d (%v)", l.open, l.clients)
+	}
+}
+
+func TestLimitListenerMax(t *testing.T) {
+	l := newLimitListener(nil, ConnLimits{Max: 1, MaxPerClient: 1}, "dns://:53")
+
+	if !l.acquire() {
+		t.Errorf("Expected first connection to be accepted")
+	}
+	if l.acquire() {
+		t.Errorf("Expected second connection to be rejected")
+	}
+	if !l.acquireClient("10.0.0.1") {
+		t.Errorf("Expected first connection of client to be accepted")
+	}
+	if l.acquireClient("10.0.0.1") {
+		t.Errorf("Expected second connection of client to be rejected")
+	}
+	l.release("10.0.0.1", true)
+	if !l.acquire() {
+		t.Errorf("Expected connection to be accepted after release")
+	}
+	if !l.acquireClient("10.0.0.1") {
+		t.Errorf("Expected connection of client to be accepted after release")
+	}
+}
+
+func TestLimitListenerProxySilent(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	_, trusted, _ := net.ParseCIDR("127.0.0.0/8")
+	l := newLimitListener(proxyproto.NewListener(ln, []*net.IPNet{trusted}), ConnLimits{MaxPerClient: 1}, "dns://:53")
+	defer l.Close()
+
+	accepted := make(chan net.Conn, 10)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- c
+		}
+	}()
+
+	// A trusted connection that never sends its PROXY protocol header must not block the
+	// connections after it.
+	for i := 0; i < 2; i++ {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+		defer c.Close()
+
+		select {
+		case a := <-accepted:
+			defer a.Close()
+		case <-time.After(time.Second):
+			t.Fatalf("Expected connection %d to be accepted", i+1)
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	ne, ok := err.(net.Error)
+	return ok && ne.Timeout()
+}
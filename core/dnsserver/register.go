@@ -53,18 +53,22 @@ func (h *dnsContext) saveConfig(key string, cfg *Config) {
 // be parsed and executed.
 func (h *dnsContext) InspectServerBlocks(sourceFile string, serverBlocks []caddyfile.ServerBlock) ([]caddyfile.ServerBlock, error) {
 	// Normalize and check all the zone names and check for duplicates
+	// Server blocks that are part of a view may serve a zone that is already defined.
 	dups := map[string]string{}
-	for _, s := range serverBlocks {
-		for i, k := range s.Keys {
+	for i, s := range serverBlocks {
+		_, view := s.Tokens["view"]
+		for j, k := range s.Keys {
 			za, err := normalizeZone(k)
 			if err != nil {
 				return nil, err
 			}
-			s.Keys[i] = za.String()
-			if v, ok := dups[za.String()]; ok {
-				return nil, fmt.Errorf("cannot serve %s - zone already defined for %v", za, v)
+			s.Keys[j] = za.String()
+			if !view {
+				if v, ok := dups[za.String()]; ok {
+					return nil, fmt.Errorf("cannot serve %s - zone already defined for %v", za, v)
+				}
+				dups[za.String()] = za.String()
 			}
-			dups[za.String()] = za.String()
 
 			// Save the config to our master list, and key it for lookups
 			cfg := &Config{
@@ -72,7 +76,7 @@ func (h *dnsContext) InspectServerBlocks(sourceFile string, serverBlocks []caddy
 				Port:      za.Port,
 				Transport: za.Transport,
 			}
-			h.saveConfig(za.String(), cfg)
+			h.saveConfig(keyForConfig(i, j), cfg)
 		}
 	}
 	return serverBlocks, nil
@@ -139,6 +143,9 @@ func (c *Config) registerHandler(h plugin.Handler) {
 
 	// Just overwrite...
 	c.registry[h.Name()] = h
+
+	// Handlers are registered from the end of the chain to its start.
+	c.handlers = append([]plugin.Handler{h}, c.handlers...)
 }
 
 // Handler returns the plugin handler that has been added to the config under its name.
@@ -155,6 +162,18 @@ func (c *Config) Handler(name string) plugin.Handler {
 	return nil
 }
 
+// Handlers returns the handlers of the config in the order of the plugin chain. They are only
+// registered once the servers are made, i.e. after all plugins are set up.
+func (c *Config) Handlers() []plugin.Handler {
+	return append([]plugin.Handler(nil), c.handlers...)
+}
+
+// Configs returns the configs of all server blocks of the instance c is part of, in the order they
+// are defined in the Corefile.
+func Configs(c *caddy.Controller) []*Config {
+	return c.Context().(*dnsContext).configs
+}
+
 // groupSiteConfigsByListenAddr groups site configs by their listen
 // (bind) address, so sites that use the same listener can be served
 // on the same server instance. The return value maps the listen
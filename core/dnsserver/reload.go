@@ -0,0 +1,163 @@
+package dnsserver
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin/metrics/vars"
+
+	"github.com/mholt/caddy"
+)
+
+// The running instance, Reload replaces it.
+var running struct {
+	sync.Mutex
+	inst *caddy.Instance
+}
+
+// SetInstance tells Reload which instance is running. It is called once the instance is started.
+func SetInstance(i *caddy.Instance) {
+	running.Lock()
+	running.inst = i
+	running.Unlock()
+}
+
+// Instance returns the running instance, or nil if there is none.
+func Instance() *caddy.Instance {
+	running.Lock()
+	defer running.Unlock()
+	return running.inst
+}
+
+// Validate parses the Corefile and runs the setup of every plugin in it, without starting
+// anything. A nil error means the servers can be created, but they can still fail to start, for
+// instance when an address is already in use.
+func Validate(corefile caddy.Input) error {
+	return caddy.ValidateAndExecuteDirectives(corefile, nil, true)
+}
+
+// Reload reads the Corefile of the running instance again and validates it. Only when that
+// succeeds, the servers are replaced by ones created from it. If the new servers fail to start,
+// the old ones keep running. Reload returns the lines that were added to and removed from the
+// Corefile, prefixed with "+" and "-".
+func Reload() ([]string, error) {
+	running.Lock()
+	defer running.Unlock()
+
+	if running.inst == nil {
+		return nil, errors.New("no running instance to reload")
+	}
+
+	old := running.inst.Caddyfile()
+	corefile, err := readCorefile(old)
+	if err != nil {
+		vars.ReloadCount.WithLabelValues(reloadInvalid).Inc()
+		return nil, err
+	}
+	if err := Validate(corefile); err != nil {
+		vars.ReloadCount.WithLabelValues(reloadInvalid).Inc()
+		return nil, err
+	}
+
+	changes := diff(old.Body(), corefile.Body())
+	if len(changes) == 0 {
+		log.Printf("[INFO] Reloading unchanged %s", corefile.Path())
+	} else {
+		log.Printf("[INFO] Reloading %s, changed lines:\n%s", corefile.Path(), strings.Join(changes, "\n"))
+	}
+
+	inst, err := running.inst.Restart(corefile)
+	if err != nil {
+		vars.ReloadCount.WithLabelValues(reloadFailed).Inc()
+		return nil, err
+	}
+	running.inst = inst
+
+	vars.ReloadCount.WithLabelValues(reloadSuccess).Inc()
+	vars.ReloadLastSuccess.Set(float64(time.Now().Unix()))
+	return changes, nil
+}
+
+// readCorefile reads the file corefile was loaded from again. If it was not read from a file, it
+// is returned as is.
+func readCorefile(corefile caddy.Input) (caddy.Input, error) {
+	path := corefile.Path()
+	if path == "" || path == "stdin" {
+		return corefile, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", path, err)
+	}
+	return caddy.CaddyfileInput{Contents: contents, Filepath: path, ServerTypeName: serverType}, nil
+}
+
+// diff returns the lines that were removed from a, prefixed with "-", and those that were added
+// in b, prefixed with "+", in the order they appear. Leading and trailing white space and empty
+// lines are ignored.
+func diff(a, b []byte) []string {
+	x, y := lines(a), lines(b)
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var changes []string
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			changes = append(changes, "-"+x[i])
+			i++
+		default:
+			changes = append(changes, "+"+y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		changes = append(changes, "-"+x[i])
+	}
+	for ; j < len(y); j++ {
+		changes = append(changes, "+"+y[j])
+	}
+	return changes
+}
+
+func lines(b []byte) []string {
+	var ls []string
+	for _, l := range strings.Split(string(b), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			ls = append(ls, l)
+		}
+	}
+	return ls
+}
+
+// The results of a reload, as counted in the metrics.
+const (
+	reloadSuccess = "success"
+	reloadInvalid = "invalid" // the Corefile could not be read or is not valid
+	reloadFailed  = "failed"  // the servers could not be started, the old ones are kept
+)
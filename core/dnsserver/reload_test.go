@@ -0,0 +1,25 @@
+package dnsserver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		changes []string
+	}{
+		{".:53 {\n\twhoami\n}\n", ".:53 {\n\twhoami\n}\n", nil},
+		{".:53 {\n\twhoami\n}\n", ".:53 {\n    whoami\n\n}", nil},
+		{".:53 {\n\twhoami\n}\n", ".:53 {\n\twhoami\n\tlog\n}\n", []string{"+log"}},
+		{".:53 {\n\tproxy . 8.8.8.8\n}\n", ".:53 {\n\tproxy . 8.8.4.4\n}\n", []string{"-proxy . 8.8.8.8", "+proxy . 8.8.4.4"}},
+		{"a:53 {\n\twhoami\n}\nb:53 {\n\twhoami\n}\n", "a:53 {\n\twhoami\n}\n", []string{"-b:53 {", "-whoami", "-}"}},
+	}
+	for i, tc := range tests {
+		changes := diff([]byte(tc.a), []byte(tc.b))
+		if !reflect.DeepEqual(changes, tc.changes) {
+			t.Errorf("Test %d: expected changes %q, got %q", i, tc.changes, changes)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+//go:build linux || darwin || freebsd
+// +build linux darwin freebsd
+
+package dnsserver
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// listenPacketReusePort opens a UDP socket with SO_REUSEPORT set, so multiple sockets can be
+// bound to the same address. The kernel spreads the incoming datagrams over them.
+func listenPacketReusePort(addr string) (net.PacketConn, error) {
+	udp, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like net.ListenPacket, listen on both IPv4 and IPv6 when no address is given.
+	var sa unix.Sockaddr
+	family := unix.AF_INET6
+	if ip4 := udp.IP.To4(); ip4 != nil {
+		family = unix.AF_INET
+		sa4 := &unix.SockaddrInet4{Port: udp.Port}
+		copy(sa4.Addr[:], ip4)
+		sa = sa4
+	} else {
+		sa6 := &unix.SockaddrInet6{Port: udp.Port}
+		copy(sa6.Addr[:], udp.IP.To16())
+		if udp.Zone != "" {
+			if ifi, err := net.InterfaceByName(udp.Zone); err == nil {
+				sa6.ZoneId = uint32(ifi.Index)
+			}
+		}
+		sa = sa6
+	}
+
+	fd, err := unix.Socket(family, unix.SOCK_DGRAM, unix.IPPROTO_UDP)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	unix.CloseOnExec(fd)
+
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEPORT, 1); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("setsockopt", err)
+	}
+	if family == unix.AF_INET6 && udp.IP == nil {
+		if err := unix.SetsockoptInt(fd, unix.IPPROTO_IPV6, unix.IPV6_V6ONLY, 0); err != nil {
+			unix.Close(fd)
+			return nil, os.NewSyscallError("setsockopt", err)
+		}
+	}
+	if err := unix.Bind(fd, sa); err != nil {
+		unix.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	// FilePacketConn duplicates the descriptor, so the file is closed either way.
+	f := os.NewFile(uintptr(fd), "udp:"+addr)
+	defer f.Close()
+	return net.FilePacketConn(f)
+}
+
+// reusePort returns true if SO_REUSEPORT is set on p.
+func reusePort(p net.PacketConn) bool {
+	sc, ok := p.(syscall.Conn)
+	if !ok {
+		return false
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+	set := false
+	rc.Control(func(fd uintptr) {
+		v, err := unix.GetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT)
+		set = err == nil && v != 0
+	})
+	return set
+}
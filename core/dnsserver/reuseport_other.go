@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !freebsd
+// +build !linux,!darwin,!freebsd
+
+package dnsserver
+
+import (
+	"errors"
+	"net"
+)
+
+// listenPacketReusePort is not supported on this platform.
+func listenPacketReusePort(addr string) (net.PacketConn, error) {
+	return nil, errors.New("SO_REUSEPORT is not supported on this platform")
+}
+
+// reusePort returns false, SO_REUSEPORT is not supported on this platform.
+func reusePort(p net.PacketConn) bool { return false }
@@ -1,19 +1,25 @@
 package dnsserver
 
 import (
-	"crypto/tls"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net"
+	"sync"
 
-	"github.com/grpc-ecosystem/grpc-opentracing/go/otgrpc"
 	"github.com/miekg/dns"
 	opentracing "github.com/opentracing/opentracing-go"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 
 	"github.com/coredns/coredns/pb"
+	"github.com/coredns/coredns/plugin/pkg/trace"
 )
 
 // ServergRPC represents an instance of a DNS-over-gRPC server.
@@ -41,19 +47,15 @@ func (s *ServergRPC) Serve(l net.Listener) error {
 	s.listenAddr = l.Addr()
 	s.m.Unlock()
 
-	if s.Tracer() != nil {
-		onlyIfParent := func(parentSpanCtx opentracing.SpanContext, method string, req, resp interface{}) bool {
-			return parentSpanCtx != nil
-		}
-		intercept := otgrpc.OpenTracingServerInterceptor(s.Tracer(), otgrpc.IncludingSpans(onlyIfParent))
-		s.grpcServer = grpc.NewServer(grpc.UnaryInterceptor(intercept))
-	} else {
-		s.grpcServer = grpc.NewServer()
+	var opts []grpc.ServerOption
+	if s.limits.IdleTimeout > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionIdle: s.limits.IdleTimeout}))
 	}
+	s.grpcServer = grpc.NewServer(opts...)
 
 	pb.RegisterDnsServiceServer(s.grpcServer, s)
 
-	return s.grpcServer.Serve(l)
+	return s.grpcServer.Serve(s.limit(l))
 }
 
 // ServePacket implements caddy.UDPServer interface.
@@ -61,30 +63,7 @@ func (s *ServergRPC) ServePacket(p net.PacketConn) error { return nil }
 
 // Listen implements caddy.TCPServer interface.
 func (s *ServergRPC) Listen() (net.Listener, error) {
-
-	// The *tls* plugin must make sure that multiple conflicting
-	// TLS configuration return an error: it can only be specified once.
-	tlsConfig := new(tls.Config)
-	for _, conf := range s.zones {
-		// Should we error if some configs *don't* have TLS?
-		tlsConfig = conf.TLSConfig
-	}
-
-	var (
-		l   net.Listener
-		err error
-	)
-
-	if tlsConfig == nil {
-		l, err = net.Listen("tcp", s.Addr[len(TransportGRPC+"://"):])
-	} else {
-		l, err = tls.Listen("tcp", s.Addr[len(TransportGRPC+"://"):], tlsConfig)
-	}
-
-	if err != nil {
-		return nil, err
-	}
-	return l, nil
+	return s.listenTLS(s.Addr[len(TransportGRPC+"://"):])
 }
 
 // ListenPacket implements caddy.UDPServer interface.
@@ -97,8 +76,10 @@ func (s *ServergRPC) OnStartupComplete() {
 		return
 	}
 
-	for zone, config := range s.zones {
-		fmt.Println(TransportGRPC + "://" + zone + ":" + config.Port)
+	for zone, configs := range s.zones {
+		for _, config := range configs {
+			fmt.Println(TransportGRPC + "://" + zone + ":" + config.Port + viewSuffix(config))
+		}
 	}
 }
 
@@ -123,22 +104,16 @@ func (s *ServergRPC) Query(ctx context.Context, in *pb.DnsPacket) (*pb.DnsPacket
 		return nil, err
 	}
 
-	p, ok := peer.FromContext(ctx)
-	if !ok {
-		return nil, errors.New("no peer in gRPC context")
-	}
-
-	a, ok := p.Addr.(*net.TCPAddr)
-	if !ok {
-		return nil, fmt.Errorf("no TCP peer in gRPC context: %v", p.Addr)
+	ctx, r, err := s.requestContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	r := &net.IPAddr{IP: a.IP}
-	w := &gRPCresponse{localAddr: s.listenAddr, remoteAddr: r, Msg: msg}
+	w := s.newResponse(r, in.Msg, msg)
 
 	s.ServeDNS(ctx, w, msg)
 
-	packed, err := w.Msg.Pack()
+	packed, err := w.pack(w.Msg)
 	if err != nil {
 		return nil, err
 	}
@@ -146,6 +121,143 @@ func (s *ServergRPC) Query(ctx context.Context, in *pb.DnsPacket) (*pb.DnsPacket
 	return &pb.DnsPacket{Msg: packed}, nil
 }
 
+// QueryStream serves the queries received on stream concurrently, at most maxStreamQueries at a
+// time. The responses are sent back in the order they are ready in; clients match them to their
+// queries by message ID. A query that can't be unpacked is answered with FORMERR. With the
+// max-queries connection limit the stream is ended once that many queries were received.
+func (s *ServergRPC) QueryStream(stream pb.DnsService_QueryStreamServer) error {
+	ctx, r, err := s.requestContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	var (
+		sender  = &streamSender{stream: stream}
+		sem     = make(chan struct{}, maxStreamQueries)
+		wg      sync.WaitGroup
+		queries int
+	)
+	defer wg.Wait()
+
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			wg.Wait()
+			return sender.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if err := sender.Err(); err != nil {
+			return err
+		}
+
+		if queries++; s.limits.MaxQueries > 0 && queries > s.limits.MaxQueries {
+			wg.Wait()
+			return grpc.Errorf(codes.ResourceExhausted, "%s", errMaxQueries)
+		}
+
+		msg := new(dns.Msg)
+		if err := msg.Unpack(in.Msg); err != nil {
+			if m := formErr(in.Msg); m != nil {
+				if packed, err := m.Pack(); err == nil {
+					sender.send(packed)
+				}
+			}
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			w := s.newResponse(r, in.Msg, msg)
+			s.ServeDNS(ctx, w, msg)
+
+			packed, err := w.pack(w.Msg)
+			if err != nil {
+				log.Printf("[ERROR] Failed to pack gRPC response: %s", err)
+				return
+			}
+			sender.send(packed)
+		}()
+	}
+}
+
+// streamSender sends the responses on a QueryStream stream. Once sending fails, it stops sending
+// and keeps the error.
+type streamSender struct {
+	mu     sync.Mutex // Send must not be called concurrently
+	stream pb.DnsService_QueryStreamServer
+	err    error
+}
+
+func (s *streamSender) send(b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	if err := s.stream.Send(&pb.DnsPacket{Msg: b}); err != nil {
+		log.Printf("[ERROR] Failed to send gRPC response: %s", err)
+		s.err = err
+	}
+}
+
+// Err returns the error sending failed with, if any.
+func (s *streamSender) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// formErr returns the FORMERR response to the query b that could not be unpacked, or nil when
+// b is too short to hold the header, then the response can't be matched to the query.
+func formErr(b []byte) *dns.Msg {
+	if len(b) < headerLen {
+		return nil
+	}
+	m := new(dns.Msg)
+	m.Id = binary.BigEndian.Uint16(b)
+	m.Opcode = int(b[2]>>3) & 0xF
+	m.Response = true
+	m.Rcode = dns.RcodeFormatError
+	return m
+}
+
+const (
+	// maxStreamQueries is the number of queries of a single QueryStream that are served at the
+	// same time. Beyond that, no more queries are received until one is answered.
+	maxStreamQueries = 64
+
+	headerLen = 12 // length of the DNS message header
+)
+
+// Transfer serves a single query and sends every message written in response to it on stream.
+// This carries responses that do not fit in one message, such as zone transfers.
+func (s *ServergRPC) Transfer(in *pb.DnsPacket, stream pb.DnsService_TransferServer) error {
+	msg := new(dns.Msg)
+	err := msg.Unpack(in.Msg)
+	if err != nil {
+		return err
+	}
+
+	ctx, r, err := s.requestContext(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	w := &gRPCstream{gRPCresponse: s.newResponse(r, in.Msg, msg), stream: stream}
+
+	s.ServeDNS(ctx, w, msg)
+
+	return w.err
+}
+
 // Shutdown stops the server (non gracefully).
 func (s *ServergRPC) Shutdown() error {
 	if s.grpcServer != nil {
@@ -154,10 +266,87 @@ func (s *ServergRPC) Shutdown() error {
 	return nil
 }
 
+// requestContext returns the address of the gRPC peer in ctx, and ctx with the placeholders
+// for its TLS connection.
+func (s *ServergRPC) requestContext(ctx context.Context) (context.Context, net.Addr, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, nil, errors.New("no peer in gRPC context")
+	}
+
+	a, ok := p.Addr.(*net.TCPAddr)
+	if !ok {
+		return nil, nil, fmt.Errorf("no TCP peer in gRPC context: %v", p.Addr)
+	}
+
+	return s.traceContext(s.tlsContext(ctx, a)), &net.IPAddr{IP: a.IP}, nil
+}
+
+// traceContext adds the trace context that the client sent in the gRPC metadata to ctx, so the
+// spans of the queries become part of the client's trace.
+func (s *ServergRPC) traceContext(ctx context.Context) context.Context {
+	tracer := s.Tracer()
+	if tracer == nil {
+		return ctx
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	carrier := opentracing.TextMapCarrier{}
+	for k, v := range md {
+		if len(v) > 0 {
+			carrier[k] = v[0]
+		}
+	}
+	parent, err := tracer.Extract(opentracing.TextMap, carrier)
+	if err != nil {
+		return ctx
+	}
+	return trace.ContextWithParent(ctx, parent)
+}
+
 type gRPCresponse struct {
 	localAddr  net.Addr
 	remoteAddr net.Addr
 	Msg        *dns.Msg
+
+	tsigStatus     error
+	tsigTimersOnly bool
+	tsigSecret     string
+	tsigRequestMAC string
+}
+
+// newResponse returns the response writer for the query msg, which was unpacked from b. If the
+// query is signed, its signature is verified like the other transports do, so TsigStatus tells
+// the views whether the query was signed with one of their keys.
+func (s *ServergRPC) newResponse(remote net.Addr, b []byte, msg *dns.Msg) *gRPCresponse {
+	w := &gRPCresponse{localAddr: s.listenAddr, remoteAddr: remote, Msg: msg}
+	if t := msg.IsTsig(); t != nil && s.tsigSecret != nil {
+		secret, ok := s.tsigSecret[t.Hdr.Name]
+		if ok {
+			w.tsigStatus = dns.TsigVerify(b, secret, "", false)
+		} else {
+			w.tsigStatus = dns.ErrSecret
+		}
+		w.tsigSecret = secret
+		w.tsigRequestMAC = t.MAC
+	}
+	return w
+}
+
+// pack packs m, and signs it if it has a TSIG record and the query was signed.
+func (r *gRPCresponse) pack(m *dns.Msg) ([]byte, error) {
+	if t := m.IsTsig(); t != nil && r.tsigSecret != "" {
+		data, mac, err := dns.TsigGenerate(m, r.tsigSecret, r.tsigRequestMAC, r.tsigTimersOnly)
+		if err != nil {
+			return nil, err
+		}
+		r.tsigRequestMAC = mac
+		return data, nil
+	}
+	return m.Pack()
 }
 
 // Write is the hack that makes this work. It does not actually write the message
@@ -170,9 +359,38 @@ func (r *gRPCresponse) Write(b []byte) (int, error) {
 
 // These methods implement the dns.ResponseWriter interface from Go DNS.
 func (r *gRPCresponse) Close() error              { return nil }
-func (r *gRPCresponse) TsigStatus() error         { return nil }
-func (r *gRPCresponse) TsigTimersOnly(b bool)     { return }
+func (r *gRPCresponse) TsigStatus() error         { return r.tsigStatus }
+func (r *gRPCresponse) TsigTimersOnly(b bool)     { r.tsigTimersOnly = b }
 func (r *gRPCresponse) Hijack()                   { return }
 func (r *gRPCresponse) LocalAddr() net.Addr       { return r.localAddr }
 func (r *gRPCresponse) RemoteAddr() net.Addr      { return r.remoteAddr }
 func (r *gRPCresponse) WriteMsg(m *dns.Msg) error { r.Msg = m; return nil }
+
+// gRPCstream is a response writer that sends every message written to it on a Transfer stream.
+type gRPCstream struct {
+	*gRPCresponse
+	stream pb.DnsService_TransferServer
+	err    error // first error from sending
+}
+
+// Write sends b on the stream.
+func (r *gRPCstream) Write(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.err = r.stream.Send(&pb.DnsPacket{Msg: b})
+	if r.err != nil {
+		return 0, r.err
+	}
+	return len(b), nil
+}
+
+// WriteMsg packs m and sends it on the stream.
+func (r *gRPCstream) WriteMsg(m *dns.Msg) error {
+	packed, err := r.pack(m)
+	if err != nil {
+		return err
+	}
+	_, err = r.Write(packed)
+	return err
+}
@@ -0,0 +1,152 @@
+package dnsserver
+
+import (
+	"net"
+	"sync"
+
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
+
+	"github.com/miekg/dns"
+)
+
+// packetServer serves DNS over UDP where datagrams from load balancers may be prefixed with a
+// PROXY protocol (version 2) header. The dns.Server can't be used for this, as it takes the
+// address of the client from the socket.
+type packetServer struct {
+	conn    net.PacketConn
+	s       *Server
+	handler dns.Handler
+
+	m       sync.Mutex
+	stopped bool
+}
+
+func newPacketServer(conn net.PacketConn, s *Server, handler dns.Handler) *packetServer {
+	return &packetServer{conn: conn, s: s, handler: handler}
+}
+
+// ActivateAndServe reads datagrams until the server is shut down.
+func (p *packetServer) ActivateAndServe() error {
+	buf := make([]byte, dns.MaxMsgSize)
+	for {
+		n, addr, err := p.conn.ReadFrom(buf)
+		if err != nil {
+			p.m.Lock()
+			stopped := p.stopped
+			p.m.Unlock()
+			if stopped {
+				return nil
+			}
+			if neterr, ok := err.(net.Error); ok && neterr.Temporary() {
+				continue
+			}
+			return err
+		}
+		b := make([]byte, n)
+		copy(b, buf[:n])
+		go p.serve(addr, b)
+	}
+}
+
+// Shutdown stops the server.
+func (p *packetServer) Shutdown() error {
+	p.m.Lock()
+	p.stopped = true
+	p.m.Unlock()
+	return p.conn.Close()
+}
+
+// serve handles a single datagram b, received from addr.
+func (p *packetServer) serve(addr net.Addr, b []byte) {
+	w := &packetWriter{conn: p.conn, peer: addr, remote: addr}
+
+	if proxyproto.Trusted(p.s.proxyNets, addr) {
+		h, n, err := proxyproto.Parse(b)
+		switch err {
+		case nil:
+			b = b[n:]
+			if h.Source != nil {
+				w.remote = &net.UDPAddr{IP: h.Source, Port: h.Port}
+			}
+		case proxyproto.ErrNoHeader:
+		default:
+			return
+		}
+	}
+
+	r := new(dns.Msg)
+	if err := r.Unpack(b); err != nil {
+		x := new(dns.Msg)
+		x.SetRcodeFormatError(r)
+		w.WriteMsg(x)
+		return
+	}
+	if r.Response {
+		return
+	}
+
+	if t := r.IsTsig(); t != nil && p.s.tsigSecret != nil {
+		secret, ok := p.s.tsigSecret[t.Hdr.Name]
+		if ok {
+			w.tsigStatus = dns.TsigVerify(b, secret, "", false)
+		} else {
+			w.tsigStatus = dns.ErrSecret
+		}
+		w.tsigSecret = secret
+		w.tsigRequestMAC = t.MAC
+	}
+
+	p.handler.ServeDNS(w, r)
+}
+
+// packetWriter is the dns.ResponseWriter for the packetServer. The response is sent to the
+// peer, the load balancer, while RemoteAddr returns the client.
+type packetWriter struct {
+	conn   net.PacketConn
+	peer   net.Addr // where the datagram came from
+	remote net.Addr // the client, from the PROXY protocol header
+
+	tsigStatus     error
+	tsigTimersOnly bool
+	tsigSecret     string
+	tsigRequestMAC string
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (w *packetWriter) WriteMsg(m *dns.Msg) error {
+	var (
+		data []byte
+		err  error
+	)
+	if t := m.IsTsig(); t != nil && w.tsigSecret != "" {
+		data, w.tsigRequestMAC, err = dns.TsigGenerate(m, w.tsigSecret, w.tsigRequestMAC, w.tsigTimersOnly)
+	} else {
+		data, err = m.Pack()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Write implements the dns.ResponseWriter interface.
+func (w *packetWriter) Write(b []byte) (int, error) { return w.conn.WriteTo(b, w.peer) }
+
+// LocalAddr implements the dns.ResponseWriter interface.
+func (w *packetWriter) LocalAddr() net.Addr { return w.conn.LocalAddr() }
+
+// RemoteAddr implements the dns.ResponseWriter interface.
+func (w *packetWriter) RemoteAddr() net.Addr { return w.remote }
+
+// TsigStatus implements the dns.ResponseWriter interface.
+func (w *packetWriter) TsigStatus() error { return w.tsigStatus }
+
+// TsigTimersOnly implements the dns.ResponseWriter interface.
+func (w *packetWriter) TsigTimersOnly(b bool) { w.tsigTimersOnly = b }
+
+// Hijack implements the dns.ResponseWriter interface.
+func (w *packetWriter) Hijack() {}
+
+// Close implements the dns.ResponseWriter interface.
+func (w *packetWriter) Close() error { return nil }
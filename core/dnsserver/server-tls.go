@@ -2,7 +2,6 @@ package dnsserver
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"net"
 
@@ -26,16 +25,19 @@ func NewServerTLS(addr string, group []*Config) (*ServerTLS, error) {
 
 // Serve implements caddy.TCPServer interface.
 func (s *ServerTLS) Serve(l net.Listener) error {
+	l = s.limit(l)
+
 	s.m.Lock()
 
 	// Only fill out the TCP server for this one.
-	s.server[tcp] = &dns.Server{Listener: l, Net: "tcp-tls", Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
-		ctx := context.Background()
+	s.server = &dns.Server{Listener: l, Net: "tcp-tls", TsigSecret: s.tsigSecret, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		ctx := s.tlsContext(context.Background(), w.RemoteAddr())
 		s.ServeDNS(ctx, w, r)
 	})}
+	s.limits.limitServer(s.server)
 	s.m.Unlock()
 
-	return s.server[tcp].ActivateAndServe()
+	return s.server.ActivateAndServe()
 }
 
 // ServePacket implements caddy.UDPServer interface.
@@ -43,29 +45,7 @@ func (s *ServerTLS) ServePacket(p net.PacketConn) error { return nil }
 
 // Listen implements caddy.TCPServer interface.
 func (s *ServerTLS) Listen() (net.Listener, error) {
-	// The *tls* plugin must make sure that multiple conflicting
-	// TLS configuration return an error: it can only be specified once.
-	tlsConfig := new(tls.Config)
-	for _, conf := range s.zones {
-		// Should we error if some configs *don't* have TLS?
-		tlsConfig = conf.TLSConfig
-	}
-
-	var (
-		l   net.Listener
-		err error
-	)
-
-	if tlsConfig == nil {
-		l, err = net.Listen("tcp", s.Addr[len(TransportTLS+"://"):])
-	} else {
-		l, err = tls.Listen("tcp", s.Addr[len(TransportTLS+"://"):], tlsConfig)
-	}
-
-	if err != nil {
-		return nil, err
-	}
-	return l, nil
+	return s.listenTLS(s.Addr[len(TransportTLS+"://"):])
 }
 
 // ListenPacket implements caddy.UDPServer interface.
@@ -78,7 +58,9 @@ func (s *ServerTLS) OnStartupComplete() {
 		return
 	}
 
-	for zone, config := range s.zones {
-		fmt.Println(TransportTLS + "://" + zone + ":" + config.Port)
+	for zone, configs := range s.zones {
+		for _, config := range configs {
+			fmt.Println(TransportTLS + "://" + zone + ":" + config.Port + viewSuffix(config))
+		}
 	}
 }
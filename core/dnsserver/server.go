@@ -6,12 +6,16 @@ import (
 	"log"
 	"net"
 	"runtime"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metrics/vars"
 	"github.com/coredns/coredns/plugin/pkg/edns"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
 	"github.com/coredns/coredns/plugin/pkg/rcode"
 	"github.com/coredns/coredns/plugin/pkg/trace"
 	"github.com/coredns/coredns/request"
@@ -29,15 +33,21 @@ import (
 type Server struct {
 	Addr string // Address we listen on
 
-	server [2]*dns.Server // 0 is a net.Listener, 1 is a net.PacketConn (a *UDPConn) in our case.
-	m      sync.Mutex     // protects the servers
-
-	zones       map[string]*Config // zones keyed by their address
-	dnsWg       sync.WaitGroup     // used to wait on outstanding connections
-	connTimeout time.Duration      // the maximum duration of a graceful shutdown
-	trace       trace.Trace        // the trace plugin for the server
-	debug       bool               // disable recover()
-	classChaos  bool               // allow non-INET class queries
+	server  *dns.Server // serves the net.Listener
+	packets []udpServer // serve the net.PacketConns, there is more than one with reuseport
+	m       sync.Mutex  // protects the servers
+
+	zones       map[string][]*Config // zones keyed by their address, multiple configs when views are used
+	tsigSecret  map[string]string    // TSIG keys of all the views
+	proxyNets   []*net.IPNet         // load balancers that may send PROXY protocol headers
+	reusePort   int                  // number of UDP sockets to open with SO_REUSEPORT
+	limits      ConnLimits           // limits on the TCP connections
+	tlsConns    *tlsConns            // open TLS connections, for finding client certificates
+	dnsWg       sync.WaitGroup       // used to wait on outstanding connections
+	connTimeout time.Duration        // the maximum duration of a graceful shutdown
+	trace       trace.Trace          // the trace plugin for the server
+	debug       bool                 // disable recover()
+	classChaos  bool                 // allow non-INET class queries
 }
 
 // NewServer returns a new CoreDNS server and compiles all plugin in to it. By default CH class
@@ -46,7 +56,7 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 
 	s := &Server{
 		Addr:        addr,
-		zones:       make(map[string]*Config),
+		zones:       make(map[string][]*Config),
 		connTimeout: 5 * time.Second, // TODO(miek): was configurable
 	}
 
@@ -63,7 +73,20 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 			s.debug = true
 		}
 		// set the config per zone
-		s.zones[site.Zone] = site
+		s.zones[site.Zone] = append(s.zones[site.Zone], site)
+		for name, secret := range site.TsigSecret {
+			if s.tsigSecret == nil {
+				s.tsigSecret = make(map[string]string)
+			}
+			s.tsigSecret[name] = secret
+		}
+		s.proxyNets = append(s.proxyNets, site.ProxyProtocol...)
+		if site.ReusePort > s.reusePort {
+			s.reusePort = site.ReusePort
+		}
+		if site.ConnLimits != nil {
+			s.limits = *site.ConnLimits
+		}
 		// compile custom plugin for everything
 		var stack plugin.Handler
 		for i := len(site.Plugin) - 1; i >= 0; i-- {
@@ -86,33 +109,96 @@ func NewServer(addr string, group []*Config) (*Server, error) {
 		site.pluginChain = stack
 	}
 
+	// Server blocks that don't belong to a view handle the queries none of the views want.
+	for _, configs := range s.zones {
+		sort.SliceStable(configs, func(i, j int) bool {
+			return len(configs[i].FilterFuncs) > 0 && len(configs[j].FilterFuncs) == 0
+		})
+	}
+
 	return s, nil
 }
 
 // Serve starts the server with an existing listener. It blocks until the server stops.
 // This implements caddy.TCPServer interface.
 func (s *Server) Serve(l net.Listener) error {
+	if s.proxyNets != nil {
+		l = proxyproto.NewListener(l, s.proxyNets)
+	}
+	l = s.limit(l)
+
 	s.m.Lock()
-	s.server[tcp] = &dns.Server{Listener: l, Net: "tcp", Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+	s.server = &dns.Server{Listener: l, Net: "tcp", TsigSecret: s.tsigSecret, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
 		ctx := context.Background()
 		s.ServeDNS(ctx, w, r)
 	})}
+	s.limits.limitServer(s.server)
 	s.m.Unlock()
 
-	return s.server[tcp].ActivateAndServe()
+	return s.server.ActivateAndServe()
 }
 
 // ServePacket starts the server with an existing packetconn. It blocks until the server stops.
+// With reuseport the other sockets are opened and served as well. After a reload that added
+// reuseport, p is the socket of the old server, which was opened without SO_REUSEPORT; then no
+// other sockets can be bound to its address until the process is restarted.
 // This implements caddy.UDPServer interface.
 func (s *Server) ServePacket(p net.PacketConn) error {
+	conns := []net.PacketConn{p}
+	sockets := s.reusePort
+	if sockets > 1 && !reusePort(p) {
+		log.Printf("[WARNING] Socket of %s was opened without SO_REUSEPORT, serving 1 instead of %d sockets until restart", s.Addr, s.reusePort)
+		sockets = 1
+	}
+	for i := 1; i < sockets; i++ {
+		// Use the address of p, the port in s.Addr may be 0.
+		c, err := listenPacketReusePort(p.LocalAddr().String())
+		if err != nil {
+			for _, c := range conns[1:] {
+				c.Close()
+			}
+			return err
+		}
+		conns = append(conns, c)
+	}
+
 	s.m.Lock()
-	s.server[udp] = &dns.Server{PacketConn: p, Net: "udp", Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+	packets := make([]udpServer, len(conns))
+	for i, c := range conns {
+		packets[i] = s.newUDPServer(c, i)
+	}
+	s.packets = packets
+	s.m.Unlock()
+
+	for i, p := range packets[1:] {
+		go func(i int, p udpServer) {
+			if err := p.ActivateAndServe(); err != nil {
+				log.Printf("[ERROR] Failed to serve UDP socket %d for %s: %s", i, s.Addr, err)
+			}
+		}(i+1, p)
+	}
+	return packets[0].ActivateAndServe()
+}
+
+// udpServer serves the queries from a net.PacketConn.
+type udpServer interface {
+	ActivateAndServe() error
+	Shutdown() error
+}
+
+// newUDPServer returns the server for UDP socket number socket.
+func (s *Server) newUDPServer(p net.PacketConn, socket int) udpServer {
+	label := strconv.Itoa(socket)
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		vars.SocketRequestCount.WithLabelValues(s.Addr, label).Inc()
 		ctx := context.Background()
 		s.ServeDNS(ctx, w, r)
-	})}
-	s.m.Unlock()
+	})
 
-	return s.server[udp].ActivateAndServe()
+	if s.proxyNets != nil {
+		return newPacketServer(p, s, handler)
+	}
+	return &dns.Server{PacketConn: p, Net: "udp", TsigSecret: s.tsigSecret, Handler: handler}
 }
 
 // Listen implements caddy.TCPServer interface.
@@ -126,7 +212,15 @@ func (s *Server) Listen() (net.Listener, error) {
 
 // ListenPacket implements caddy.UDPServer interface.
 func (s *Server) ListenPacket() (net.PacketConn, error) {
-	p, err := net.ListenPacket("udp", s.Addr[len(TransportDNS+"://"):])
+	var (
+		p   net.PacketConn
+		err error
+	)
+	if s.reusePort > 0 {
+		p, err = listenPacketReusePort(s.Addr[len(TransportDNS+"://"):])
+	} else {
+		p, err = net.ListenPacket("udp", s.Addr[len(TransportDNS+"://"):])
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -161,10 +255,13 @@ func (s *Server) Stop() (err error) {
 
 	// Close the listener now; this stops the server without delay
 	s.m.Lock()
-	for _, s1 := range s.server {
-		// We might not have started and initialized the full set of servers
-		if s1 != nil {
-			err = s1.Shutdown()
+	// We might not have started and initialized the full set of servers
+	if s.server != nil {
+		err = s.server.Shutdown()
+	}
+	for _, p := range s.packets {
+		if err1 := p.Shutdown(); err1 != nil {
+			err = err1
 		}
 	}
 	s.m.Unlock()
@@ -199,6 +296,15 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		return
 	}
 
+	if t := r.IsTsig(); t != nil && s.tsigSecret != nil && w.TsigStatus() == nil {
+		w = &tsigWriter{ResponseWriter: w, tsig: t}
+	}
+
+	// Plugins pass facts about the query to each other in its metadata.
+	ctx = metadata.NewQueryContext(ctx)
+	tlsMetadata(ctx)
+	w = metadata.NewWriter(ctx, w)
+
 	q := r.Question[0].Name
 	b := make([]byte, len(q))
 	var off int
@@ -216,12 +322,9 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 			}
 		}
 
-		if h, ok := s.zones[string(b[:l])]; ok {
+		if h := s.view(string(b[:l]), w, r); h != nil {
 			if r.Question[0].Qtype != dns.TypeDS {
-				rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
-				if !plugin.ClientWrite(rcode) {
-					DefaultErrorFunc(w, r, rcode)
-				}
+				serve(ctx, h, w, r)
 				return
 			}
 			// The type is DS, keep the handler, but keep on searching as maybe we are serving
@@ -239,19 +342,13 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 
 	if dshandler != nil {
 		// DS request, and we found a zone, use the handler for the query
-		rcode, _ := dshandler.pluginChain.ServeDNS(ctx, w, r)
-		if !plugin.ClientWrite(rcode) {
-			DefaultErrorFunc(w, r, rcode)
-		}
+		serve(ctx, dshandler, w, r)
 		return
 	}
 
 	// Wildcard match, if we have found nothing try the root zone as a last resort.
-	if h, ok := s.zones["."]; ok {
-		rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
-		if !plugin.ClientWrite(rcode) {
-			DefaultErrorFunc(w, r, rcode)
-		}
+	if h := s.view(".", w, r); h != nil {
+		serve(ctx, h, w, r)
 		return
 	}
 
@@ -261,6 +358,17 @@ func (s *Server) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 	log.Printf("[INFO] \"%s %s %s\" - No such zone at %s (Remote: %s)", dns.Type(r.Question[0].Qtype), dns.Class(r.Question[0].Qclass), q, s.Addr, remoteHost)
 }
 
+// serve lets the plugin chain of h handle the query, and writes the error reply if the chain did
+// not write a response.
+func serve(ctx context.Context, h *Config, w dns.ResponseWriter, r *dns.Msg) {
+	metadata.Serving(ctx, h.pluginChain.Name())
+	rcode, _ := h.pluginChain.ServeDNS(ctx, w, r)
+	metadata.Serving(ctx, "")
+	if !plugin.ClientWrite(rcode) {
+		DefaultErrorFunc(w, r, rcode)
+	}
+}
+
 // OnStartupComplete lists the sites served by this server
 // and any relevant information, assuming Quiet is false.
 func (s *Server) OnStartupComplete() {
@@ -268,11 +376,48 @@ func (s *Server) OnStartupComplete() {
 		return
 	}
 
-	for zone, config := range s.zones {
-		fmt.Println(zone + ":" + config.Port)
+	for zone, configs := range s.zones {
+		for _, config := range configs {
+			fmt.Println(zone + ":" + config.Port + viewSuffix(config))
+		}
 	}
 }
 
+// view returns the config that should handle the query for zone: the first one whose filters
+// all accept it. It returns nil if zone isn't served or no config wants the query.
+func (s *Server) view(zone string, w dns.ResponseWriter, r *dns.Msg) *Config {
+	configs, ok := s.zones[zone]
+	if !ok {
+		return nil
+	}
+	state := request.Request{W: w, Req: r}
+
+	for _, c := range configs {
+		if c.accepts(state) {
+			return c
+		}
+	}
+	return nil
+}
+
+// accepts returns true if all filters of c accept the query in state.
+func (c *Config) accepts(state request.Request) bool {
+	for _, f := range c.FilterFuncs {
+		if !f(state) {
+			return false
+		}
+	}
+	return true
+}
+
+// viewSuffix returns the text to add to the listing of a config that is part of a view.
+func viewSuffix(c *Config) string {
+	if c.View == "" {
+		return ""
+	}
+	return " (view " + c.View + ")"
+}
+
 // Tracer ... TODO: Add comment
 func (s *Server) Tracer() ot.Tracer {
 	if s.trace == nil {
@@ -296,10 +441,19 @@ func DefaultErrorFunc(w dns.ResponseWriter, r *dns.Msg, rc int) {
 	w.WriteMsg(answer)
 }
 
-const (
-	tcp = 0
-	udp = 1
-)
+// tsigWriter signs the responses to queries that are signed with one of the TSIG keys of the views.
+type tsigWriter struct {
+	dns.ResponseWriter
+	tsig *dns.TSIG
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (t *tsigWriter) WriteMsg(m *dns.Msg) error {
+	if m.IsTsig() == nil {
+		m.SetTsig(t.tsig.Hdr.Name, t.tsig.Algorithm, t.tsig.Fudge, time.Now().Unix())
+	}
+	return t.ResponseWriter.WriteMsg(m)
+}
 
 var (
 	// Quiet mode will not show any informative output on initialization.
@@ -2,6 +2,14 @@ package dnsserver
 
 import (
 	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
 )
 
 func makeConfig(transport string) *Config {
@@ -24,3 +32,51 @@ func TestNewServer(t *testing.T) {
 		t.Errorf("Expected no error for NewServerTLS, got %s.", err)
 	}
 }
+
+func TestServeDNSView(t *testing.T) {
+	answer := func(txt string) plugin.Handler {
+		return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+			m := new(dns.Msg)
+			m.SetReply(r)
+			m.Answer = []dns.RR{&dns.TXT{Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeTXT, Class: dns.ClassINET}, Txt: []string{txt}}}
+			w.WriteMsg(m)
+			return dns.RcodeSuccess, nil
+		})
+	}
+	internal := func(state request.Request) bool { return state.IP() == "10.240.0.1" }
+
+	public := makeConfig("dns")
+	public.Zone = "example.com."
+	public.Plugin = []plugin.Plugin{func(plugin.Handler) plugin.Handler { return answer("public") }}
+	private := makeConfig("dns")
+	private.Zone = "example.com."
+	private.View = "internal"
+	private.FilterFuncs = []FilterFunc{internal}
+	private.Plugin = []plugin.Plugin{func(plugin.Handler) plugin.Handler { return answer("internal") }}
+
+	// The view is listed last, but must be tried first.
+	s, err := NewServer("127.0.0.1:53", []*Config{public, private})
+	if err != nil {
+		t.Fatalf("Expected no error for NewServer, got %s.", err)
+	}
+
+	tests := []struct {
+		w        dns.ResponseWriter
+		expected string
+	}{
+		{&test.ResponseWriter{}, "internal"}, // 10.240.0.1
+		{&test.ResponseWriter6{}, "public"},
+	}
+	for i, tc := range tests {
+		r := new(dns.Msg)
+		r.SetQuestion("www.example.com.", dns.TypeTXT)
+		rec := dnsrecorder.New(tc.w)
+		s.ServeDNS(context.TODO(), rec, r)
+		if rec.Msg == nil || len(rec.Msg.Answer) != 1 {
+			t.Fatalf("Test %d: expected 1 answer, got %v", i, rec.Msg)
+		}
+		if got := rec.Msg.Answer[0].(*dns.TXT).Txt[0]; got != tc.expected {
+			t.Errorf("Test %d: expected answer from %s, got %s", i, tc.expected, got)
+		}
+	}
+}
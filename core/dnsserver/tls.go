@@ -0,0 +1,158 @@
+package dnsserver
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/plugin/pkg/proxyproto"
+
+	"golang.org/x/net/context"
+)
+
+// listenTLS listens on addr for DNS-over-TLS and DNS-over-gRPC connections. The connections are
+// tracked, so the client certificate of a query can be found from its remote address.
+func (s *Server) listenTLS(addr string) (net.Listener, error) {
+	// The *tls* plugin must make sure that multiple conflicting
+	// TLS configuration return an error: it can only be specified once.
+	var tlsConfig *tls.Config
+	for _, configs := range s.zones {
+		for _, conf := range configs {
+			// Should we error if some configs *don't* have TLS?
+			tlsConfig = conf.TLSConfig
+		}
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The PROXY protocol header comes before the TLS handshake.
+	if s.proxyNets != nil {
+		l = proxyproto.NewListener(l, s.proxyNets)
+	}
+	if tlsConfig == nil {
+		return l, nil
+	}
+
+	conns := &tlsConns{m: make(map[string]*tls.Conn)}
+	s.m.Lock()
+	s.tlsConns = conns
+	s.m.Unlock()
+
+	return &tlsListener{Listener: l, config: tlsConfig, conns: conns}, nil
+}
+
+// clientSubjectKey is the subject of the client certificate of a query over TLS.
+var clientSubjectKey = metadata.Register("tls/client_subject")
+
+// clientSubject is the context key of the client subject that tlsContext found.
+type clientSubject struct{}
+
+// tlsContext returns ctx with the subject of the client certificate when the query from addr
+// came in over a TLS connection on which the client presented a verified certificate. The
+// subject is not metadata yet, ctx may be shared by the queries on a gRPC stream; ServeDNS
+// copies it into the metadata of each query with tlsMetadata.
+func (s *Server) tlsContext(ctx context.Context, addr net.Addr) context.Context {
+	s.m.Lock()
+	conns := s.tlsConns
+	s.m.Unlock()
+	if conns == nil {
+		return ctx
+	}
+
+	subject := conns.clientSubject(addr.String())
+	if subject == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, clientSubject{}, subject)
+}
+
+// tlsMetadata sets the tls/client_subject metadata of the query in ctx, when tlsContext found
+// a subject.
+func tlsMetadata(ctx context.Context) {
+	if subject, ok := ctx.Value(clientSubject{}).(string); ok {
+		metadata.Set(ctx, clientSubjectKey, subject)
+	}
+}
+
+// tlsConns holds the open TLS connections of a listener, keyed by remote address.
+type tlsConns struct {
+	sync.Mutex
+	m map[string]*tls.Conn
+}
+
+// clientSubject returns the subject of the certificate the client on the connection from addr
+// presented, or the empty string if there is no such connection or certificate. Certificates that
+// were not verified, with client_auth request or require, are ignored: anybody can make them up.
+func (t *tlsConns) clientSubject(addr string) string {
+	t.Lock()
+	c, ok := t.m[addr]
+	t.Unlock()
+	if !ok {
+		return ""
+	}
+
+	cs := c.ConnectionState()
+	if len(cs.VerifiedChains) == 0 {
+		return ""
+	}
+	return cs.VerifiedChains[0][0].Subject.String()
+}
+
+// tlsListener is like the listener from tls.NewListener, but it registers its connections in
+// conns while they are open.
+type tlsListener struct {
+	net.Listener
+	config *tls.Config
+	conns  *tlsConns
+}
+
+// Accept implements net.Listener.
+func (l *tlsListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &tlsConn{Conn: tls.Server(c, l.config), conns: l.conns}, nil
+}
+
+// tlsConn is a *tls.Conn that registers itself when it is first read from, and removes itself
+// when it is closed. This is not done on accept, because with the PROXY protocol the remote
+// address is only known after reading the header.
+type tlsConn struct {
+	*tls.Conn
+	conns *tlsConns
+
+	once   sync.Once
+	key    string // remote address in conns, empty when not registered (yet)
+	closed bool
+}
+
+// Read implements net.Conn.
+func (c *tlsConn) Read(b []byte) (int, error) {
+	c.once.Do(func() {
+		key := c.RemoteAddr().String()
+		c.conns.Lock()
+		if !c.closed {
+			c.conns.m[key] = c.Conn
+			c.key = key
+		}
+		c.conns.Unlock()
+	})
+	return c.Conn.Read(b)
+}
+
+// Close implements net.Conn.
+func (c *tlsConn) Close() error {
+	c.conns.Lock()
+	if c.key != "" && c.conns.m[c.key] == c.Conn {
+		delete(c.conns.m, c.key)
+	}
+	c.key = ""
+	c.closed = true
+	c.conns.Unlock()
+	return c.Conn.Close()
+}
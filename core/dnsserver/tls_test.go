@@ -0,0 +1,129 @@
+package dnsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/plugin/pkg/replacer"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+func TestTLSClientSubject(t *testing.T) {
+	cert := selfSigned(t, "client.example.org")
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cas := x509.NewCertPool()
+	cas.AddCert(leaf)
+
+	tests := []struct {
+		auth     tls.ClientAuthType
+		expected string
+	}{
+		{tls.RequireAndVerifyClientCert, "CN=client.example.org"},
+		{tls.VerifyClientCertIfGiven, "CN=client.example.org"},
+		// Not verified, so anybody could have made up the subject.
+		{tls.RequireAnyClientCert, "-"},
+		{tls.RequestClientCert, "-"},
+	}
+	for i, tc := range tests {
+		config := &tls.Config{
+			Certificates: []tls.Certificate{selfSigned(t, "server")},
+			ClientAuth:   tc.auth,
+			ClientCAs:    cas,
+		}
+		if got := clientSubjectOf(t, config, cert); got != tc.expected {
+			t.Errorf("Test %d: expected subject %q, got %q", i, tc.expected, got)
+		}
+	}
+}
+
+// clientSubjectOf connects to a server with config, with cert as the client certificate, and
+// returns the {tls/client_subject} of a query on the connection.
+func clientSubjectOf(t *testing.T, config *tls.Config, cert tls.Certificate) string {
+	s := &Server{zones: map[string][]*Config{".": {{TLSConfig: config}}}}
+
+	l, err := s.listenTLS("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	defer l.Close()
+
+	read := make(chan error)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			read <- err
+			return
+		}
+		_, err = c.Read(make([]byte, 1))
+		read <- err
+		<-read
+		c.Close()
+		read <- nil
+	}()
+
+	client, err := tls.Dial("tcp", l.Addr().String(), &tls.Config{
+		Certificates:       []tls.Certificate{cert},
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer client.Close()
+	client.Write([]byte{0})
+	if err := <-read; err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+
+	subject := func() string {
+		ctx := metadata.NewQueryContext(s.tlsContext(context.TODO(), client.LocalAddr()))
+		tlsMetadata(ctx)
+		r := new(dns.Msg)
+		r.SetQuestion("example.org.", dns.TypeA)
+		rep := replacer.New(r, dnsrecorder.New(&test.ResponseWriter{}), "-")
+		replacer.Apply(ctx, rep)
+		return rep.Replace("{tls/client_subject}")
+	}
+
+	got := subject()
+
+	// Once closed the connection is forgotten.
+	read <- nil
+	<-read
+	if closed := subject(); closed != "-" {
+		t.Errorf("Expected no subject after close, got %q", closed)
+	}
+	return got
+}
+
+func selfSigned(t *testing.T, name string) tls.Certificate {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}
+}
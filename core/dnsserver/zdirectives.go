@@ -14,16 +14,24 @@ var directives = []string{
 	"tls",
 	"root",
 	"bind",
+	"proxyprotocol",
+	"reuseport",
+	"connlimit",
+	"view",
 	"debug",
 	"trace",
 	"health",
 	"pprof",
+	"admin",
 	"prometheus",
 	"errors",
 	"log",
+	"acl",
+	"cookie",
 	"autopath",
 	"dnstap",
 	"chaos",
+	"rrl",
 	"cache",
 	"rewrite",
 	"loadbalance",
@@ -36,6 +44,8 @@ var directives = []string{
 	"auto",
 	"secondary",
 	"etcd",
+	"consul",
+	"registry",
 	"proxy",
 	"erratic",
 	"whoami",
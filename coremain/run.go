@@ -35,7 +35,7 @@ func init() {
 		flag.Var(f.Value, f.Name, f.Usage)
 	}
 
-	caddy.TrapSignals()
+	trapSignals()
 	caddy.DefaultConfigFile = "Corefile"
 	caddy.Quiet = true // don't show init stuff from caddy
 	setVersion()
@@ -43,6 +43,7 @@ func init() {
 	flag.StringVar(&conf, "conf", "", "Corefile to load (default \""+caddy.DefaultConfigFile+"\")")
 	flag.StringVar(&cpu, "cpu", "100%", "CPU cap")
 	flag.BoolVar(&plugins, "plugins", false, "List installed plugins")
+	flag.BoolVar(&validate, "validate", false, "Check the Corefile and exit, without starting any servers")
 	flag.StringVar(&caddy.PidFile, "pidfile", "", "Path to write pid file")
 	flag.BoolVar(&version, "version", false, "Show version")
 	flag.BoolVar(&dnsserver.Quiet, "quiet", false, "Quiet mode (no initialization output)")
@@ -90,11 +91,20 @@ func Run() {
 		mustLogFatal(err)
 	}
 
+	if validate {
+		if err := dnsserver.Validate(corefile); err != nil {
+			mustLogFatal(err)
+		}
+		fmt.Printf("%s is valid\n", corefileName(corefile))
+		os.Exit(0)
+	}
+
 	// Start your engines
 	instance, err := caddy.Start(corefile)
 	if err != nil {
 		mustLogFatal(err)
 	}
+	dnsserver.SetInstance(instance)
 
 	logVersion()
 	if !dnsserver.Quiet {
@@ -155,6 +165,14 @@ func defaultLoader(serverType string) (caddy.Input, error) {
 	}, nil
 }
 
+// corefileName returns the name of the file corefile was read from.
+func corefileName(corefile caddy.Input) string {
+	if corefile.Path() == "" {
+		return "Corefile"
+	}
+	return corefile.Path()
+}
+
 // logVersion logs the version that is starting.
 func logVersion() {
 	log.Print("[INFO] " + versionString())
@@ -237,11 +255,12 @@ func setCPU(cpu string) error {
 
 // Flags that control program flow or startup
 var (
-	conf    string
-	cpu     string
-	logfile bool
-	version bool
-	plugins bool
+	conf     string
+	cpu      string
+	logfile  bool
+	version  bool
+	plugins  bool
+	validate bool
 )
 
 // Build information obtained with the help of -ldflags
@@ -0,0 +1,78 @@
+package coremain
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/mholt/caddy"
+)
+
+// trapSignals handles the signals CoreDNS reacts to. It replaces caddy.TrapSignals, which stops
+// the servers on SIGHUP, where CoreDNS reloads the Corefile instead.
+func trapSignals() {
+	trapInterrupt()
+	trapSignalsPosix()
+}
+
+// trapInterrupt shuts down on SIGINT, after running the shutdown callbacks. A second SIGINT exits
+// immediately.
+func trapInterrupt() {
+	go func() {
+		sigchan := make(chan os.Signal, 1)
+		signal.Notify(sigchan, os.Interrupt)
+
+		for i := 0; ; i++ {
+			<-sigchan
+
+			if i > 0 {
+				log.Println("[INFO] SIGINT: Force quit")
+				removePidFile()
+				os.Exit(2)
+			}
+
+			log.Println("[INFO] SIGINT: Shutting down")
+			removePidFile()
+			go func() {
+				os.Exit(shutdown("SIGINT"))
+			}()
+		}
+	}()
+}
+
+// reload reloads the Corefile, as requested by signame.
+func reload(signame string) {
+	log.Printf("[INFO] %s: Reloading", signame)
+	if _, err := dnsserver.Reload(); err != nil {
+		log.Printf("[ERROR] %s: Failed to reload, keeping the running configuration: %s", signame, err)
+	}
+}
+
+// shutdown runs the shutdown callbacks of the running instance, as requested by signame. It returns
+// the exit code: 4 if a callback failed. Only the first call runs the callbacks.
+func shutdown(signame string) (code int) {
+	shutdownOnce.Do(func() {
+		caddy.EmitEvent(caddy.ShutdownEvent, signame)
+
+		inst := dnsserver.Instance()
+		if inst == nil {
+			return
+		}
+		for _, err := range inst.ShutdownCallbacks() {
+			log.Printf("[ERROR] %s shutdown: %s", signame, err)
+			code = 4
+		}
+	})
+	return code
+}
+
+var shutdownOnce sync.Once
+
+func removePidFile() {
+	if caddy.PidFile != "" {
+		os.Remove(caddy.PidFile)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build windows || plan9 || nacl
+// +build windows plan9 nacl
+
+package coremain
+
+// trapSignalsPosix does nothing, there are no POSIX signals on this platform.
+func trapSignalsPosix() {}
@@ -0,0 +1,58 @@
+//go:build !windows && !plan9 && !nacl
+// +build !windows,!plan9,!nacl
+
+package coremain
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mholt/caddy"
+)
+
+// trapSignalsPosix handles the POSIX signals:
+//
+//	SIGTERM  exit immediately
+//	SIGQUIT  run the shutdown callbacks, stop the servers and exit
+//	SIGHUP   reload the Corefile
+//	SIGUSR1  reload the Corefile, like caddy does
+//	SIGUSR2  upgrade the binary, see caddy.Upgrade
+func trapSignalsPosix() {
+	go func() {
+		sigchan := make(chan os.Signal, 1)
+		signal.Notify(sigchan, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGUSR1, syscall.SIGUSR2)
+
+		for sig := range sigchan {
+			switch sig {
+			case syscall.SIGTERM:
+				log.Println("[INFO] SIGTERM: Terminating process")
+				removePidFile()
+				os.Exit(0)
+
+			case syscall.SIGQUIT:
+				log.Println("[INFO] SIGQUIT: Shutting down")
+				code := shutdown("SIGQUIT")
+				if err := caddy.Stop(); err != nil {
+					log.Printf("[ERROR] SIGQUIT stop: %s", err)
+					code = 3
+				}
+				removePidFile()
+				os.Exit(code)
+
+			case syscall.SIGHUP:
+				reload("SIGHUP")
+
+			case syscall.SIGUSR1:
+				reload("SIGUSR1")
+
+			case syscall.SIGUSR2:
+				log.Println("[INFO] SIGUSR2: Upgrading")
+				if err := caddy.Upgrade(); err != nil {
+					log.Printf("[ERROR] SIGUSR2: upgrading: %s", err)
+				}
+			}
+		}
+	}()
+}
@@ -65,6 +65,12 @@ const _ = grpc.SupportPackageIsVersion4
 
 type DnsServiceClient interface {
 	Query(ctx context.Context, in *DnsPacket, opts ...grpc.CallOption) (*DnsPacket, error)
+	// QueryStream carries many queries over one stream. Responses are matched to
+	// queries by the message ID, and may come back in a different order.
+	QueryStream(ctx context.Context, opts ...grpc.CallOption) (DnsService_QueryStreamClient, error)
+	// Transfer returns all messages of the response to one query, such as the
+	// messages of a zone transfer (AXFR, IXFR).
+	Transfer(ctx context.Context, in *DnsPacket, opts ...grpc.CallOption) (DnsService_TransferClient, error)
 }
 
 type dnsServiceClient struct {
@@ -84,10 +90,79 @@ func (c *dnsServiceClient) Query(ctx context.Context, in *DnsPacket, opts ...grp
 	return out, nil
 }
 
+func (c *dnsServiceClient) QueryStream(ctx context.Context, opts ...grpc.CallOption) (DnsService_QueryStreamClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DnsService_serviceDesc.Streams[0], c.cc, "/coredns.dns.DnsService/QueryStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dnsServiceQueryStreamClient{stream}
+	return x, nil
+}
+
+type DnsService_QueryStreamClient interface {
+	Send(*DnsPacket) error
+	Recv() (*DnsPacket, error)
+	grpc.ClientStream
+}
+
+type dnsServiceQueryStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *dnsServiceQueryStreamClient) Send(m *DnsPacket) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *dnsServiceQueryStreamClient) Recv() (*DnsPacket, error) {
+	m := new(DnsPacket)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dnsServiceClient) Transfer(ctx context.Context, in *DnsPacket, opts ...grpc.CallOption) (DnsService_TransferClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_DnsService_serviceDesc.Streams[1], c.cc, "/coredns.dns.DnsService/Transfer", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dnsServiceTransferClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DnsService_TransferClient interface {
+	Recv() (*DnsPacket, error)
+	grpc.ClientStream
+}
+
+type dnsServiceTransferClient struct {
+	grpc.ClientStream
+}
+
+func (x *dnsServiceTransferClient) Recv() (*DnsPacket, error) {
+	m := new(DnsPacket)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Server API for DnsService service
 
 type DnsServiceServer interface {
 	Query(context.Context, *DnsPacket) (*DnsPacket, error)
+	// QueryStream carries many queries over one stream. Responses are matched to
+	// queries by the message ID, and may come back in a different order.
+	QueryStream(DnsService_QueryStreamServer) error
+	// Transfer returns all messages of the response to one query, such as the
+	// messages of a zone transfer (AXFR, IXFR).
+	Transfer(*DnsPacket, DnsService_TransferServer) error
 }
 
 func RegisterDnsServiceServer(s *grpc.Server, srv DnsServiceServer) {
@@ -112,6 +187,53 @@ func _DnsService_Query_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DnsService_QueryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DnsServiceServer).QueryStream(&dnsServiceQueryStreamServer{stream})
+}
+
+type DnsService_QueryStreamServer interface {
+	Send(*DnsPacket) error
+	Recv() (*DnsPacket, error)
+	grpc.ServerStream
+}
+
+type dnsServiceQueryStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *dnsServiceQueryStreamServer) Send(m *DnsPacket) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *dnsServiceQueryStreamServer) Recv() (*DnsPacket, error) {
+	m := new(DnsPacket)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _DnsService_Transfer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DnsPacket)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DnsServiceServer).Transfer(m, &dnsServiceTransferServer{stream})
+}
+
+type DnsService_TransferServer interface {
+	Send(*DnsPacket) error
+	grpc.ServerStream
+}
+
+type dnsServiceTransferServer struct {
+	grpc.ServerStream
+}
+
+func (x *dnsServiceTransferServer) Send(m *DnsPacket) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _DnsService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "coredns.dns.DnsService",
 	HandlerType: (*DnsServiceServer)(nil),
@@ -121,20 +243,34 @@ var _DnsService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _DnsService_Query_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "QueryStream",
+			Handler:       _DnsService_QueryStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Transfer",
+			Handler:       _DnsService_Transfer_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "dns.proto",
 }
 
 func init() { proto.RegisterFile("dns.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 120 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0xe2, 0x4c, 0xc9, 0x2b, 0xd6,
-	0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x4e, 0xce, 0x2f, 0x4a, 0x05, 0x71, 0x53, 0xf2, 0x8a,
-	0x95, 0x64, 0xb9, 0x38, 0x5d, 0xf2, 0x8a, 0x03, 0x12, 0x93, 0xb3, 0x53, 0x4b, 0x84, 0x04, 0xb8,
-	0x98, 0x73, 0x8b, 0xd3, 0x25, 0x18, 0x15, 0x18, 0x35, 0x78, 0x82, 0x40, 0x4c, 0x23, 0x57, 0x2e,
-	0x2e, 0x97, 0xbc, 0xe2, 0xe0, 0xd4, 0xa2, 0xb2, 0xcc, 0xe4, 0x54, 0x21, 0x73, 0x2e, 0xd6, 0xc0,
-	0xd2, 0xd4, 0xa2, 0x4a, 0x21, 0x31, 0x3d, 0x24, 0x33, 0xf4, 0xe0, 0x06, 0x48, 0xe1, 0x10, 0x77,
-	0x62, 0x89, 0x62, 0x2a, 0x48, 0x4a, 0x62, 0x03, 0xdb, 0x6f, 0x0c, 0x08, 0x00, 0x00, 0xff, 0xff,
-	0xf5, 0xd1, 0x3f, 0x26, 0x8c, 0x00, 0x00, 0x00,
+	// 149 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe3, 0xe2, 0x4c, 0xc9, 0x2b, 0xd6,
+	0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x4e, 0xce, 0x2f, 0x4a, 0x05, 0x71, 0x81, 0x58, 0x49,
+	0x96, 0x8b, 0xd3, 0x25, 0xaf, 0x38, 0x20, 0x31, 0x39, 0x3b, 0xb5, 0x44, 0x48, 0x80, 0x8b, 0x39,
+	0xb7, 0x38, 0x5d, 0x82, 0x51, 0x81, 0x51, 0x83, 0x27, 0x08, 0xc4, 0x34, 0x3a, 0xc6, 0xc8, 0xc5,
+	0x05, 0x94, 0x0f, 0x4e, 0x2d, 0x2a, 0xcb, 0x4c, 0x4e, 0x15, 0x32, 0xe7, 0x62, 0x0d, 0x2c, 0x4d,
+	0x2d, 0xaa, 0x14, 0x12, 0xd3, 0x43, 0x32, 0x44, 0x0f, 0x6e, 0x82, 0x14, 0x0e, 0x71, 0x21, 0x47,
+	0x2e, 0x6e, 0xb0, 0xc6, 0xe0, 0x92, 0xa2, 0xd4, 0xc4, 0x5c, 0x52, 0xb5, 0x6b, 0x30, 0x1a, 0x30,
+	0x0a, 0xd9, 0x70, 0x71, 0x84, 0x14, 0x25, 0xe6, 0x15, 0xa7, 0xa5, 0x16, 0x91, 0xaa, 0xdf, 0x80,
+	0xd1, 0x89, 0x25, 0x8a, 0xa9, 0x20, 0x29, 0x89, 0x0d, 0x1c, 0x02, 0xc6, 0x00, 0x7f, 0x8b, 0x9b,
+	0x18, 0x0e, 0x01, 0x00, 0x00,
 }
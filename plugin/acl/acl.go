@@ -0,0 +1,135 @@
+// Package acl implements a plugin that restricts who may query a zone.
+package acl
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+var (
+	actionKey = metadata.Register("acl/action")
+	ruleKey   = metadata.Register("acl/rule")
+)
+
+// ACL is a plugin that applies access control rules to queries.
+type ACL struct {
+	Next  plugin.Handler
+	Zones []string
+	Rules []Rule
+}
+
+// Rule matches queries on the client network, the query type and the query name. Empty criteria
+// match everything, so a rule without criteria matches all queries.
+type Rule struct {
+	Action Action
+	Nets   []*net.IPNet
+	Types  map[uint16]bool
+	Names  []string
+}
+
+// Action is what is done with a query that matches a rule.
+type Action int
+
+const (
+	// Allow passes the query to the next plugin.
+	Allow Action = iota
+	// Refuse answers with REFUSED.
+	Refuse
+	// NXDomain answers with NXDOMAIN.
+	NXDomain
+	// Drop does not answer at all.
+	Drop
+)
+
+// String returns the name of the action as used in the Corefile.
+func (a Action) String() string {
+	switch a {
+	case Allow:
+		return "allow"
+	case Refuse:
+		return "refuse"
+	case NXDomain:
+		return "nxdomain"
+	case Drop:
+		return "drop"
+	}
+	return ""
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (a ACL) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(a.Zones).Matches(state.Name())
+	if zone == "" {
+		return plugin.NextOrFailure(a.Name(), a.Next, ctx, w, r)
+	}
+
+	for i, rule := range a.Rules {
+		if !rule.match(state) {
+			continue
+		}
+
+		id := strconv.Itoa(i + 1)
+		RequestCount.WithLabelValues(zone, id, rule.Action.String()).Inc()
+		metadata.Set(ctx, actionKey, rule.Action.String())
+		metadata.Set(ctx, ruleKey, id)
+
+		switch rule.Action {
+		case Refuse:
+			// The server writes the response.
+			return dns.RcodeRefused, nil
+		case NXDomain:
+			m := new(dns.Msg)
+			m.SetRcode(r, dns.RcodeNameError)
+			state.SizeAndDo(m)
+			w.WriteMsg(m)
+			return dns.RcodeNameError, nil
+		case Drop:
+			// Nothing is written, but we don't want the server to write an error either.
+			return dns.RcodeSuccess, nil
+		}
+		// Allow: the first matching rule decides.
+		break
+	}
+
+	return plugin.NextOrFailure(a.Name(), a.Next, ctx, w, r)
+}
+
+// Name implements the Handler interface.
+func (a ACL) Name() string { return "acl" }
+
+// match returns true if the query in state matches all criteria of the rule.
+func (r Rule) match(state request.Request) bool {
+	if len(r.Types) > 0 && !r.Types[state.QType()] {
+		return false
+	}
+
+	if len(r.Names) > 0 {
+		if plugin.Zones(r.Names).Matches(state.Name()) == "" {
+			return false
+		}
+	}
+
+	if len(r.Nets) > 0 {
+		ip := net.ParseIP(state.IP())
+		if ip == nil {
+			return false
+		}
+		for _, n := range r.Nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,76 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/plugin/pkg/replacer"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+func TestACL(t *testing.T) {
+	c := caddy.NewTestController("dns", `acl example.org {
+		allow net 10.240.0.0/16 type AXFR
+		refuse type AXFR IXFR
+		drop net fe80::/10 name internal.example.org
+		nxdomain name secret.example.org
+		allow net 10.0.0.0/8
+		refuse
+	}`)
+	a, err := aclParse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	a.Next = test.NextHandler(dns.RcodeSuccess, nil)
+
+	tests := []struct {
+		qname     string
+		qtype     uint16
+		w         dns.ResponseWriter
+		rcode     int
+		written   bool // response written by the plugin
+		placehold string
+	}{
+		{"example.org.", dns.TypeAXFR, &test.ResponseWriter{}, dns.RcodeSuccess, false, "allow 1"},
+		{"example.org.", dns.TypeAXFR, &test.ResponseWriter6{}, dns.RcodeRefused, false, "refuse 2"},
+		{"www.internal.example.org.", dns.TypeA, &test.ResponseWriter6{}, dns.RcodeSuccess, false, "drop 3"},
+		{"www.internal.example.org.", dns.TypeA, &test.ResponseWriter{}, dns.RcodeSuccess, false, "allow 5"},
+		{"secret.example.org.", dns.TypeA, &test.ResponseWriter{}, dns.RcodeNameError, true, "nxdomain 4"},
+		{"www.example.org.", dns.TypeA, &test.ResponseWriter6{}, dns.RcodeRefused, false, "refuse 6"},
+		// Other zones are not subject to the rules.
+		{"www.example.net.", dns.TypeA, &test.ResponseWriter6{}, dns.RcodeSuccess, false, " "},
+	}
+
+	for i, tc := range tests {
+		r := new(dns.Msg)
+		r.SetQuestion(tc.qname, tc.qtype)
+		rec := dnsrecorder.New(tc.w)
+		ctx := metadata.NewContext(context.TODO())
+
+		rcode, err := a.ServeDNS(ctx, rec, r)
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+		}
+		if rcode != tc.rcode {
+			t.Errorf("Test %d: expected rcode %s, got %s", i, dns.RcodeToString[tc.rcode], dns.RcodeToString[rcode])
+		}
+		if written := rec.Msg != nil; written != tc.written {
+			t.Errorf("Test %d: expected written to be %t, got %t", i, tc.written, written)
+		}
+		if written := plugin.ClientWrite(rcode); tc.rcode == dns.RcodeRefused && written {
+			t.Errorf("Test %d: expected the server to write the response", i)
+		}
+
+		rep := replacer.New(r, rec, "")
+		replacer.Apply(ctx, rep)
+		if got := rep.Replace("{acl/action} {acl/rule}"); got != tc.placehold {
+			t.Errorf("Test %d: expected placeholders %q, got %q", i, tc.placehold, got)
+		}
+	}
+}
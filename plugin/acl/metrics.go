@@ -0,0 +1,19 @@
+package acl
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RequestCount counts the queries that matched a rule, by zone, rule number and action.
+var RequestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "acl",
+	Name:      "requests_total",
+	Help:      "Counter of queries that matched an acl rule.",
+}, []string{"zone", "rule", "action"})
+
+func init() {
+	prometheus.MustRegister(RequestCount)
+}
@@ -0,0 +1,122 @@
+package acl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+)
+
+func init() {
+	caddy.RegisterPlugin("acl", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	a, err := aclParse(c)
+	if err != nil {
+		return plugin.Error("acl", err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		a.Next = next
+		return a
+	})
+
+	return nil
+}
+
+func aclParse(c *caddy.Controller) (ACL, error) {
+	a := ACL{}
+	for c.Next() {
+		a.Zones = c.RemainingArgs()
+		if len(a.Zones) == 0 {
+			a.Zones = make([]string, len(c.ServerBlockKeys))
+			copy(a.Zones, c.ServerBlockKeys)
+		}
+		for i, str := range a.Zones {
+			a.Zones[i] = plugin.Host(str).Normalize()
+		}
+
+		for c.NextBlock() {
+			rule, err := ruleParse(c)
+			if err != nil {
+				return a, err
+			}
+			a.Rules = append(a.Rules, rule)
+		}
+	}
+	if len(a.Rules) == 0 {
+		return a, c.Err("no rules defined")
+	}
+	return a, nil
+}
+
+// ruleParse parses: ACTION [net CIDR...] [type TYPE...] [name NAME...]
+func ruleParse(c *caddy.Controller) (Rule, error) {
+	rule := Rule{}
+	switch c.Val() {
+	case "allow":
+		rule.Action = Allow
+	case "refuse", "deny":
+		rule.Action = Refuse
+	case "nxdomain":
+		rule.Action = NXDomain
+	case "drop":
+		rule.Action = Drop
+	default:
+		return rule, c.Errf("unknown action '%s'", c.Val())
+	}
+
+	args := c.RemainingArgs()
+	for len(args) > 0 {
+		what := args[0]
+		i := 1
+		for i < len(args) && !isKeyword(args[i]) {
+			i++
+		}
+		values := args[1:i]
+		args = args[i:]
+		if len(values) == 0 {
+			return rule, c.Errf("no values for '%s'", what)
+		}
+
+		switch what {
+		case "net":
+			for _, v := range values {
+				n, err := cidr.Parse(v)
+				if err != nil {
+					return rule, err
+				}
+				rule.Nets = append(rule.Nets, n)
+			}
+		case "type":
+			if rule.Types == nil {
+				rule.Types = make(map[uint16]bool)
+			}
+			for _, v := range values {
+				t, ok := dns.StringToType[strings.ToUpper(v)]
+				if !ok {
+					return rule, fmt.Errorf("invalid query type: %s", v)
+				}
+				rule.Types[t] = true
+			}
+		case "name":
+			for _, v := range values {
+				rule.Names = append(rule.Names, plugin.Host(v).Normalize())
+			}
+		default:
+			return rule, c.Errf("unknown property '%s'", what)
+		}
+	}
+	return rule, nil
+}
+
+func isKeyword(s string) bool { return s == "net" || s == "type" || s == "name" }
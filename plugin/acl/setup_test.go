@@ -0,0 +1,57 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupACL(t *testing.T) {
+	tests := []struct {
+		input         string
+		shouldErr     bool
+		expectedRules int
+	}{
+		{`acl {
+			refuse net 192.168.0.0/16 2001:db8::/32 type ANY name example.org example.net
+		}`, false, 1},
+		{`acl example.org {
+			allow net 10.0.0.1
+			deny
+		}`, false, 2},
+		{`acl`, true, 0},
+		{`acl {
+			reject
+		}`, true, 0},
+		{`acl {
+			refuse 10.0.0.0/8
+		}`, true, 0},
+		{`acl {
+			refuse net
+		}`, true, 0},
+		{`acl {
+			refuse net 10.0.0.bla
+		}`, true, 0},
+		{`acl {
+			refuse type FOO
+		}`, true, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		a, err := aclParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+		if x := len(a.Rules); x != test.expectedRules {
+			t.Errorf("Test %d: Expected %d rules, got %d", i, test.expectedRules, x)
+		}
+	}
+}
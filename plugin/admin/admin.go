@@ -0,0 +1,325 @@
+// Package admin implements a local HTTP API to inspect and change the state of the running server.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin/cache"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/proxy"
+	"github.com/coredns/coredns/plugin/secondary"
+
+	"github.com/miekg/dns"
+)
+
+// admin serves the API on an address. It is shared by all server blocks that use the address and
+// kept across reloads, the configs of the new instance replace the old ones.
+type admin struct {
+	Addr string
+
+	ln    net.Listener
+	srv   *http.Server
+	users int // server blocks using this admin, the listener is closed when it drops to 0
+
+	sync.RWMutex
+	configs []*dnsserver.Config
+}
+
+var (
+	adminsMu sync.Mutex
+	admins   = map[string]*admin{}
+)
+
+// start starts serving the API for configs on addr, or makes the already running API on addr
+// use configs.
+func start(addr string, configs []*dnsserver.Config) error {
+	adminsMu.Lock()
+	defer adminsMu.Unlock()
+
+	if a, ok := admins[addr]; ok {
+		a.Lock()
+		a.configs = configs
+		a.Unlock()
+		a.users++
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("[ERROR] Failed to start admin handler: %s", err)
+		return err
+	}
+
+	a := &admin{Addr: addr, ln: ln, configs: configs, users: 1}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/servers", a.servers)
+	mux.HandleFunc("/zones", a.zones)
+	mux.HandleFunc("/zones/reload", a.reload)
+	mux.HandleFunc("/cache", a.cache)
+	mux.HandleFunc("/upstreams", a.upstreams)
+	mux.HandleFunc("/reload", a.reloadCorefile)
+	a.srv = &http.Server{Handler: mux}
+
+	go func() {
+		a.srv.Serve(a.ln)
+	}()
+
+	admins[addr] = a
+	ListenAddr = ln.Addr().String()
+	return nil
+}
+
+// stop stops serving the API on addr once its last user is gone.
+func stop(addr string) error {
+	adminsMu.Lock()
+	defer adminsMu.Unlock()
+
+	a, ok := admins[addr]
+	if !ok {
+		return nil
+	}
+	a.users--
+	if a.users > 0 {
+		return nil
+	}
+	delete(admins, addr)
+	err := a.ln.Close()
+	// The requests in flight are finished in the background: one of them may be the POST /reload
+	// that installs a Corefile without admin, and stops the API while it waits for its reply.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		a.srv.Shutdown(ctx)
+	}()
+	return err
+}
+
+// shutdownTimeout is how long stop waits for the requests in flight, before closing their
+// connections.
+const shutdownTimeout = 5 * time.Second
+
+// server identifies a server block in the responses.
+type server struct {
+	Server string `json:"server"`
+	View   string `json:"view,omitempty"`
+}
+
+func newServer(c *dnsserver.Config) server {
+	return server{Server: c.Transport + "://" + c.Zone + ":" + c.Port, View: c.View}
+}
+
+// servers lists the server blocks and their plugin chains.
+func (a *admin) servers(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, "GET") {
+		return
+	}
+
+	type block struct {
+		server
+		Plugins []string `json:"plugins"`
+	}
+	blocks := []block{}
+	for _, c := range a.current() {
+		b := block{server: newServer(c), Plugins: []string{}}
+		for _, h := range c.Handlers() {
+			b.Plugins = append(b.Plugins, h.Name())
+		}
+		blocks = append(blocks, b)
+	}
+	reply(w, http.StatusOK, blocks)
+}
+
+// zone is a zone of the file or secondary plugin.
+type zone struct {
+	server
+	Plugin string `json:"plugin"`
+	Zone   string `json:"zone"`
+	Serial int64  `json:"serial"` // -1 when the zone is not loaded (yet)
+	Error  string `json:"error,omitempty"`
+
+	z *file.Zone
+}
+
+// fileZones returns the zones of the file and secondary plugins, restricted to name if not empty.
+func (a *admin) fileZones(name string) []zone {
+	all := name == ""
+	name = strings.ToLower(dns.Fqdn(name))
+
+	zones := []zone{}
+	for _, c := range a.current() {
+		for _, h := range c.Handlers() {
+			var (
+				f      file.File
+				plugin string
+			)
+			// Secondary embeds File, including its name.
+			switch x := h.(type) {
+			case file.File:
+				f, plugin = x, "file"
+			case secondary.Secondary:
+				f, plugin = x.File, "secondary"
+			default:
+				continue
+			}
+			for _, origin := range f.Zones.Names {
+				if !all && origin != name {
+					continue
+				}
+				z := f.Zones.Z[origin]
+				zones = append(zones, zone{server: newServer(c), Plugin: plugin, Zone: origin, Serial: z.SOASerialIfDefined(), z: z})
+			}
+		}
+	}
+	return zones
+}
+
+// zones lists the zones of the file and secondary plugins.
+func (a *admin) zones(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, "GET") {
+		return
+	}
+	reply(w, http.StatusOK, a.fileZones(""))
+}
+
+// reload reloads the zones of the file and secondary plugins, or the one given in the zone parameter.
+func (a *admin) reload(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, "POST") {
+		return
+	}
+
+	name := r.URL.Query().Get("zone")
+	zones := a.fileZones(name)
+	if name != "" && len(zones) == 0 {
+		reply(w, http.StatusNotFound, errorReply{"zone not found: " + name})
+		return
+	}
+	for i := range zones {
+		if err := zones[i].z.ReloadNow(); err != nil {
+			zones[i].Error = err.Error()
+		}
+		zones[i].Serial = zones[i].z.SOASerialIfDefined()
+	}
+	reply(w, http.StatusOK, zones)
+}
+
+// cache lists or purges the entries of the cache plugins, for the name parameter if given.
+func (a *admin) cache(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, "GET", "DELETE") {
+		return
+	}
+
+	type entry struct {
+		server
+		cache.Entry
+	}
+	name := r.URL.Query().Get("name")
+	entries := []entry{}
+	purged := 0
+	for _, c := range a.current() {
+		ca, ok := c.Handler("cache").(*cache.Cache)
+		if !ok {
+			continue
+		}
+		if r.Method == "DELETE" {
+			purged += ca.Purge(name)
+			continue
+		}
+		for _, e := range ca.Entries(name) {
+			entries = append(entries, entry{server: newServer(c), Entry: e})
+		}
+	}
+
+	if r.Method == "DELETE" {
+		reply(w, http.StatusOK, struct {
+			Purged int `json:"purged"`
+		}{purged})
+		return
+	}
+	reply(w, http.StatusOK, entries)
+}
+
+// upstreams shows the health of the upstream hosts of the proxy plugins.
+func (a *admin) upstreams(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, "GET") {
+		return
+	}
+
+	type host struct {
+		server
+		proxy.Health
+	}
+	hosts := []host{}
+	for _, c := range a.current() {
+		p, ok := c.Handler("proxy").(proxy.Proxy)
+		if !ok {
+			continue
+		}
+		for _, h := range p.Health() {
+			hosts = append(hosts, host{server: newServer(c), Health: h})
+		}
+	}
+	reply(w, http.StatusOK, hosts)
+}
+
+// reloadCorefile reloads the Corefile, see dnsserver.Reload, and replies with the lines that
+// changed.
+func (a *admin) reloadCorefile(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, "POST") {
+		return
+	}
+
+	changes, err := dnsserver.Reload()
+	if err != nil {
+		reply(w, http.StatusInternalServerError, errorReply{"reload failed, keeping the running configuration: " + err.Error()})
+		return
+	}
+	if changes == nil {
+		changes = []string{}
+	}
+	reply(w, http.StatusOK, struct {
+		Changes []string `json:"changes"`
+	}{changes})
+}
+
+// ListenAddr is assigned the address of the admin listener. Its use is mainly in tests where
+// we listen on "localhost:0" and need to retrieve the actual address.
+var ListenAddr string
+
+func (a *admin) current() []*dnsserver.Config {
+	a.RLock()
+	defer a.RUnlock()
+	return a.configs
+}
+
+type errorReply struct {
+	Error string `json:"error"`
+}
+
+// allow checks if the method of r is one of methods, if not it replies with an error.
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	reply(w, http.StatusMethodNotAllowed, errorReply{"method not allowed: " + r.Method})
+	return false
+}
+
+// reply writes v as JSON with status code.
+func reply(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("[ERROR] Failed to encode admin reply: %s", err)
+	}
+}
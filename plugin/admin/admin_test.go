@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+)
+
+func TestStartStop(t *testing.T) {
+	const addr = "127.0.0.1:0"
+
+	if err := start(addr, nil); err != nil {
+		t.Fatalf("Failed to start: %s", err)
+	}
+	url := "http://" + ListenAddr
+
+	// A reload starts the new instance before the old one is shut down.
+	configs := []*dnsserver.Config{{Zone: "example.org.", Port: "53", Transport: "dns"}}
+	if err := start(addr, configs); err != nil {
+		t.Fatalf("Failed to start: %s", err)
+	}
+	stop(addr)
+
+	resp, err := http.Get(url + "/servers")
+	if err != nil {
+		t.Fatalf("Failed to get servers: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if n := len(admins[addr].current()); n != 1 {
+		t.Errorf("Expected the configs of the new instance, got %d configs", n)
+	}
+
+	resp, err = http.Post(url+"/servers", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to post servers: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", resp.StatusCode)
+	}
+
+	stop(addr)
+	if _, ok := admins[addr]; ok {
+		t.Errorf("Expected admin to be stopped")
+	}
+	// A new connection, the idle ones are closed in the background.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	if _, err := client.Get(url + "/servers"); err == nil {
+		t.Errorf("Expected admin listener to be closed")
+	}
+}
+
+func TestStopInRequest(t *testing.T) {
+	const addr = "127.0.0.1:0"
+
+	if err := start(addr, nil); err != nil {
+		t.Fatalf("Failed to start: %s", err)
+	}
+	url := "http://" + ListenAddr
+
+	// Like a POST /reload that installs a Corefile without admin: the request stops the API
+	// before it replies.
+	admins[addr].srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stop(addr)
+		reply(w, http.StatusOK, struct{}{})
+	})
+
+	resp, err := http.Get(url + "/reload")
+	if err != nil {
+		t.Fatalf("Expected a reply, got %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/mholt/caddy"
+)
+
+func init() {
+	caddy.RegisterPlugin("admin", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	addr, err := adminParse(c)
+	if err != nil {
+		return plugin.Error("admin", err)
+	}
+
+	configs := dnsserver.Configs(c)
+
+	c.OnStartup(func() error { return start(addr, configs) })
+	c.OnShutdown(func() error { return stop(addr) })
+
+	// Don't do AddPlugin, as admin is not *really* a plugin just a separate webserver running.
+	return nil
+}
+
+func adminParse(c *caddy.Controller) (string, error) {
+	addr := ""
+	for c.Next() {
+		if addr != "" {
+			return "", c.Err("admin can only be specified once")
+		}
+		args := c.RemainingArgs()
+
+		switch len(args) {
+		case 0:
+			addr = defAddr
+		case 1:
+			addr = args[0]
+			if _, _, e := net.SplitHostPort(addr); e != nil {
+				return "", e
+			}
+		default:
+			return "", c.ArgErr()
+		}
+	}
+	return addr, nil
+}
+
+const defAddr = "localhost:8054"
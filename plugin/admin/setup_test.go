@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupAdmin(t *testing.T) {
+	tests := []struct {
+		input        string
+		shouldErr    bool
+		expectedAddr string
+	}{
+		{`admin`, false, defAddr},
+		{`admin localhost:1234`, false, "localhost:1234"},
+		{`admin :1234`, false, ":1234"},
+		{`admin localhost`, true, ""},
+		{`admin localhost:1234 localhost:1235`, true, ""},
+		{"admin\nadmin", true, ""},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		addr, err := adminParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if addr != test.expectedAddr {
+			t.Errorf("Test %d: Expected address %s, got %s", i, test.expectedAddr, addr)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/dnstap"
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/metrics"
 	"github.com/coredns/coredns/plugin/proxy"
@@ -88,8 +89,9 @@ func (a Auto) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 
 	state.SizeAndDo(m)
 	m, _ = state.Scrub(m)
+	taperr := dnstap.TapAuth(ctx, state, m)
 	w.WriteMsg(m)
-	return dns.RcodeSuccess, nil
+	return dns.RcodeSuccess, taperr
 }
 
 // Name implements the Handler interface.
@@ -10,6 +10,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/pkg/response"
 	"github.com/coredns/coredns/plugin/test"
 
@@ -235,6 +236,23 @@ func BenchmarkCacheResponse(b *testing.B) {
 	})
 }
 
+func TestCachePlaceholders(t *testing.T) {
+	c, _ := newTestCache(maxTTL)
+	c.Next = BackendHandler()
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+
+	for _, cached := range []string{"false", "true"} {
+		ctx := metadata.NewContext(context.TODO())
+		c.ServeDNS(ctx, &test.ResponseWriter{}, req)
+
+		if v, _ := metadata.Get(ctx, hitKey); v != cached {
+			t.Errorf("Expected cache/hit to be %s, got %q", cached, v)
+		}
+	}
+}
+
 func BackendHandler() plugin.Handler {
 	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 		m := new(dns.Msg)
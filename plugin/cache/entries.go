@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/rcode"
+
+	"github.com/miekg/dns"
+)
+
+// Entry describes an item in the cache.
+type Entry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Cache string `json:"cache"` // Success or Denial
+	Rcode string `json:"rcode"`
+	TTL   int    `json:"ttl"` // remaining TTL in seconds
+}
+
+// Entries returns the entries cached for name, or all entries if name is empty.
+func (c *Cache) Entries(name string) []Entry {
+	all := name == ""
+	name = strings.ToLower(dns.Fqdn(name))
+	now := time.Now().UTC()
+
+	entries := []Entry{}
+	walk := func(ca *cache.Cache, class string) {
+		ca.Walk(func(_ uint32, el interface{}) bool {
+			i := el.(*item)
+			if !all && i.Name != name {
+				return false
+			}
+			entries = append(entries, Entry{
+				Name:  i.Name,
+				Type:  dns.Type(i.Qtype).String(),
+				Cache: class,
+				Rcode: rcode.ToString(i.Rcode),
+				TTL:   i.ttl(now),
+			})
+			return false
+		})
+	}
+	walk(c.pcache, Success)
+	walk(c.ncache, Denial)
+	return entries
+}
+
+// Purge removes the entries cached for name, or all entries if name is empty. It returns the
+// number of entries removed.
+func (c *Cache) Purge(name string) int {
+	all := name == ""
+	name = strings.ToLower(dns.Fqdn(name))
+
+	n := 0
+	purge := func(_ uint32, el interface{}) bool {
+		if !all && el.(*item).Name != name {
+			return false
+		}
+		n++
+		return true
+	}
+	c.pcache.Walk(purge)
+	c.ncache.Walk(purge)
+
+	cacheSize.WithLabelValues(Success).Set(float64(c.pcache.Len()))
+	cacheSize.WithLabelValues(Denial).Set(float64(c.ncache.Len()))
+	return n
+}
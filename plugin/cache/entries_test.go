@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/response"
+
+	"github.com/miekg/dns"
+)
+
+func TestEntriesAndPurge(t *testing.T) {
+	c, crr := newTestCache(maxTTL)
+
+	for _, q := range []struct {
+		name  string
+		qtype uint16
+		rcode int
+	}{
+		{"example.org.", dns.TypeA, dns.RcodeSuccess},
+		{"Example.org.", dns.TypeAAAA, dns.RcodeSuccess},
+		{"nothere.example.org.", dns.TypeA, dns.RcodeNameError},
+	} {
+		m := new(dns.Msg)
+		m.SetQuestion(q.name, q.qtype)
+		m.Response = true
+		m.Rcode = q.rcode
+		if q.rcode == dns.RcodeSuccess {
+			rr, _ := dns.NewRR(q.name + " 3600 IN A 127.0.0.1")
+			m.Answer = []dns.RR{rr}
+		} else {
+			rr, _ := dns.NewRR("example.org. 3600 IN SOA ns.example.org. hostmaster.example.org. 1 7200 1800 86400 60")
+			m.Ns = []dns.RR{rr}
+		}
+		mt, _ := response.Typify(m, time.Now().UTC())
+		crr.set(m, key(m, mt, false), mt, time.Hour)
+	}
+
+	if n := len(c.Entries("")); n != 3 {
+		t.Errorf("Expected 3 entries, got %d", n)
+	}
+	entries := c.Entries("EXAMPLE.org")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries for example.org., got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Name != "example.org." || e.Cache != Success || e.Rcode != "NOERROR" {
+			t.Errorf("Unexpected entry %+v", e)
+		}
+	}
+	if e := c.Entries("nothere.example.org."); len(e) != 1 || e[0].Cache != Denial || e[0].Rcode != "NXDOMAIN" {
+		t.Errorf("Unexpected denial entries %+v", e)
+	}
+
+	if n := c.Purge("example.org."); n != 2 {
+		t.Errorf("Expected 2 entries purged, got %d", n)
+	}
+	if n := len(c.Entries("")); n != 1 {
+		t.Errorf("Expected 1 entry left, got %d", n)
+	}
+	if n := c.Purge(""); n != 1 {
+		t.Errorf("Expected 1 entry purged, got %d", n)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -11,6 +12,9 @@ import (
 	"golang.org/x/net/context"
 )
 
+// hitKey is "true" if the response came from the cache.
+var hitKey = metadata.Register("cache/hit")
+
 // ServeDNS implements the plugin.Handler interface.
 func (c *Cache) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	state := request.Request{W: w, Req: r}
@@ -32,6 +36,7 @@ func (c *Cache) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 
 		state.SizeAndDo(resp)
 		resp, _ = state.Scrub(resp)
+		metadata.Set(ctx, hitKey, "true")
 		w.WriteMsg(resp)
 
 		i.Freq.Update(c.duration, now)
@@ -45,8 +50,9 @@ func (c *Cache) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 			// When prefetching we loose the item i, and with it the frequency
 			// that we've gathered sofar. See we copy the frequencies info back
 			// into the new item that was stored in the cache.
+			// The prefetch happens after the response was written, it must not change its metadata.
 			prr := &ResponseWriter{ResponseWriter: w, Cache: c, prefetch: true}
-			plugin.NextOrFailure(c.Name(), c.Next, ctx, prr, r)
+			plugin.NextOrFailure(c.Name(), c.Next, metadata.Detach(ctx), prr, r)
 
 			if i1, _ := c.get(now, qname, qtype, do); i1 != nil {
 				i1.Freq.Reset(now, i.Freq.Hits())
@@ -56,6 +62,7 @@ func (c *Cache) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		return dns.RcodeSuccess, nil
 	}
 
+	metadata.Set(ctx, hitKey, "false")
 	crr := &ResponseWriter{ResponseWriter: w, Cache: c}
 	return plugin.NextOrFailure(c.Name(), c.Next, ctx, crr, r)
 }
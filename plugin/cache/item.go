@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"strings"
 	"time"
 
 	"github.com/coredns/coredns/plugin/cache/freq"
@@ -9,6 +10,8 @@ import (
 )
 
 type item struct {
+	Name               string // qname and qtype the item was cached for
+	Qtype              uint16
 	Rcode              int
 	Authoritative      bool
 	AuthenticatedData  bool
@@ -25,6 +28,8 @@ type item struct {
 
 func newItem(m *dns.Msg, d time.Duration) *item {
 	i := new(item)
+	i.Name = strings.ToLower(m.Question[0].Name)
+	i.Qtype = m.Question[0].Qtype
 	i.Rcode = m.Rcode
 	i.Authoritative = m.Authoritative
 	i.AuthenticatedData = m.AuthenticatedData
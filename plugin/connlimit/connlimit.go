@@ -0,0 +1,11 @@
+// Package connlimit limits the TCP connections of a server.
+package connlimit
+
+import "github.com/mholt/caddy"
+
+func init() {
+	caddy.RegisterPlugin("connlimit", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
@@ -0,0 +1,79 @@
+package connlimit
+
+import (
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/parse"
+
+	"github.com/mholt/caddy"
+)
+
+func setup(c *caddy.Controller) error {
+	config := dnsserver.GetConfig(c)
+
+	for c.Next() {
+		if config.ConnLimits != nil {
+			return plugin.Error("connlimit", c.Errf("connection limits already configured for this server instance"))
+		}
+		limits, err := connlimitParse(c)
+		if err != nil {
+			return plugin.Error("connlimit", err)
+		}
+		config.ConnLimits = limits
+	}
+	return nil
+}
+
+func connlimitParse(c *caddy.Controller) (*dnsserver.ConnLimits, error) {
+	limits := &dnsserver.ConnLimits{}
+
+	if len(c.RemainingArgs()) != 0 {
+		return nil, c.ArgErr()
+	}
+	for c.NextBlock() {
+		switch c.Val() {
+		case "max":
+			n, err := parse.Int(c, 1, 1<<20)
+			if err != nil {
+				return nil, err
+			}
+			limits.Max = n
+		case "max-per-client":
+			n, err := parse.Int(c, 1, 1<<20)
+			if err != nil {
+				return nil, err
+			}
+			limits.MaxPerClient = n
+		case "idle-timeout":
+			args := c.RemainingArgs()
+			if len(args) != 1 {
+				return nil, c.ArgErr()
+			}
+			d, err := time.ParseDuration(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if d <= 0 {
+				return nil, c.Errf("idle-timeout must be positive: %s", d)
+			}
+			limits.IdleTimeout = d
+		case "max-queries":
+			n, err := parse.Int(c, 1, maxQueries)
+			if err != nil {
+				return nil, err
+			}
+			limits.MaxQueries = n
+		default:
+			return nil, c.Errf("unknown property '%s'", c.Val())
+		}
+	}
+	if *limits == (dnsserver.ConnLimits{}) {
+		return nil, c.Err("no connection limits given")
+	}
+	return limits, nil
+}
+
+// maxQueries is the number of queries after which the DNS library closes a connection itself.
+const maxQueries = 128
@@ -0,0 +1,67 @@
+package connlimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupConnLimit(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		expected  dnsserver.ConnLimits
+	}{
+		{`connlimit {
+			max 1000
+			max-per-client 10
+			idle-timeout 5s
+			max-queries 100
+		}`, false, dnsserver.ConnLimits{Max: 1000, MaxPerClient: 10, IdleTimeout: 5 * time.Second, MaxQueries: 100}},
+		{`connlimit {
+			max-per-client 2
+		}`, false, dnsserver.ConnLimits{MaxPerClient: 2}},
+		{`connlimit`, true, dnsserver.ConnLimits{}},
+		{`connlimit 10`, true, dnsserver.ConnLimits{}},
+		{`connlimit {
+			max 0
+		}`, true, dnsserver.ConnLimits{}},
+		{`connlimit {
+			idle-timeout -1s
+		}`, true, dnsserver.ConnLimits{}},
+		{`connlimit {
+			max-queries 1000
+		}`, true, dnsserver.ConnLimits{}},
+		{`connlimit {
+			max-idle 10
+		}`, true, dnsserver.ConnLimits{}},
+		{`connlimit {
+			max 10
+		}
+		connlimit {
+			max 20
+		}`, true, dnsserver.ConnLimits{}},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		err := setup(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if cfg := dnsserver.GetConfig(c); *cfg.ConnLimits != test.expected {
+			t.Errorf("Test %d: Expected limits %v, got %v", i, test.expected, *cfg.ConnLimits)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// instance is a healthy instance of a service.
+type instance struct {
+	Node        string
+	NodeAddress string
+	Address     string // address of the service, defaults to the address of the node
+	Port        int
+	Tags        []string
+}
+
+// hasTag returns true if i is tagged with tag.
+func (i instance) hasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// healthEntry is an entry as returned by /v1/health/service/<service>.
+type healthEntry struct {
+	Node struct {
+		Node    string
+		Address string
+	}
+	Service struct {
+		Service string
+		Tags    []string
+		Address string
+		Port    int
+	}
+}
+
+// catalog keeps the healthy instances of all services in Consul. It uses blocking queries to
+// watch the list of services and, for every service, its healthy instances.
+type catalog struct {
+	client     *http.Client
+	endpoint   string
+	token      string
+	datacenter string
+	wait       time.Duration
+
+	sync.RWMutex
+	services map[string][]instance    // keyed by lowercased service name
+	watchers map[string]chan struct{} // closing the channel stops the watcher
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newCatalog(client *http.Client, endpoint string) *catalog {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &catalog{
+		client:   client,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		wait:     defaultWait,
+		services: make(map[string][]instance),
+		watchers: make(map[string]chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Run watches the catalog until Stop is called.
+func (c *catalog) Run() {
+	var index uint64
+	for {
+		var svcs map[string][]string
+		idx, err := c.get("/v1/catalog/services", nil, index, &svcs)
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to list consul services: %s", err)
+			if !c.sleep() {
+				return
+			}
+			continue
+		}
+		index = nextIndex(index, idx)
+
+		c.update(svcs)
+	}
+}
+
+// Stop stops all watches.
+func (c *catalog) Stop() error {
+	c.cancel()
+	return nil
+}
+
+// update starts watchers for services that are new and stops the watchers of services that
+// have gone.
+func (c *catalog) update(svcs map[string][]string) {
+	c.Lock()
+	defer c.Unlock()
+
+	seen := make(map[string]bool)
+	for name := range svcs {
+		key := strings.ToLower(name)
+		seen[key] = true
+		if _, ok := c.watchers[key]; ok {
+			continue
+		}
+		stop := make(chan struct{})
+		c.watchers[key] = stop
+		go c.watch(name, stop)
+	}
+
+	for key, stop := range c.watchers {
+		if seen[key] {
+			continue
+		}
+		close(stop)
+		delete(c.watchers, key)
+		delete(c.services, key)
+	}
+}
+
+// watch keeps the healthy instances of the service name up to date until stop is closed.
+func (c *catalog) watch(name string, stop chan struct{}) {
+	var index uint64
+	key := strings.ToLower(name)
+	for {
+		var entries []healthEntry
+		idx, err := c.get("/v1/health/service/"+url.PathEscape(name), url.Values{"passing": {""}}, index, &entries)
+		select {
+		case <-stop:
+			return
+		case <-c.ctx.Done():
+			return
+		default:
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to get healthy instances of consul service %q: %s", name, err)
+			if !c.sleep() {
+				return
+			}
+			continue
+		}
+		index = nextIndex(index, idx)
+
+		instances := make([]instance, 0, len(entries))
+		for _, e := range entries {
+			addr := e.Service.Address
+			if addr == "" {
+				addr = e.Node.Address
+			}
+			instances = append(instances, instance{Node: e.Node.Node, NodeAddress: e.Node.Address, Address: addr, Port: e.Service.Port, Tags: e.Service.Tags})
+		}
+
+		c.Lock()
+		select {
+		case <-stop:
+			// The service is gone, don't resurrect it.
+		default:
+			c.services[key] = instances
+		}
+		c.Unlock()
+	}
+}
+
+// instances returns the healthy instances of service name. The boolean is false when the service
+// is not known.
+func (c *catalog) instances(name string) ([]instance, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	i, ok := c.services[strings.ToLower(name)]
+	return i, ok
+}
+
+// node returns the address of the node with name name, if it runs a healthy instance.
+func (c *catalog) node(name string) (string, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	for _, instances := range c.services {
+		for _, i := range instances {
+			if strings.EqualFold(i.Node, name) {
+				return i.NodeAddress, true
+			}
+		}
+	}
+	return "", false
+}
+
+// get performs a blocking query on path and decodes the JSON response into v. It returns the
+// index of the response.
+func (c *catalog) get(path string, params url.Values, index uint64, v interface{}) (uint64, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	if index > 0 {
+		params.Set("index", strconv.FormatUint(index, 10))
+		params.Set("wait", fmt.Sprintf("%ds", int(c.wait.Seconds())))
+	}
+	if c.datacenter != "" {
+		params.Set("dc", c.datacenter)
+	}
+
+	req, err := http.NewRequest("GET", c.endpoint+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req.WithContext(c.ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	idx, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid X-Consul-Index: %q", resp.Header.Get("X-Consul-Index"))
+	}
+
+	return idx, json.NewDecoder(resp.Body).Decode(v)
+}
+
+// sleep waits before retrying a failed query. It returns false if the catalog was stopped in
+// the mean time.
+func (c *catalog) sleep() bool {
+	select {
+	case <-time.After(retryInterval):
+		return true
+	case <-c.ctx.Done():
+		return false
+	}
+}
+
+// nextIndex returns the index to use for the next blocking query. If the index went backwards
+// we start over, as Consul documents.
+func nextIndex(old, idx uint64) uint64 {
+	if idx < old {
+		return 0
+	}
+	return idx
+}
+
+const (
+	defaultWait   = 5 * time.Minute
+	retryInterval = 1 * time.Second
+)
@@ -0,0 +1,122 @@
+// Package consul provides the consul backend plugin.
+package consul
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/plugin/proxy"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// Consul is a plugin that serves the healthy instances of the services in the Consul catalog.
+type Consul struct {
+	Next        plugin.Handler
+	Fallthrough bool
+	Zones       []string
+	Proxy       proxy.Proxy // Proxy for looking up names during the resolution process
+
+	ttl     uint32
+	catalog *catalog
+}
+
+var errNoItems = errors.New("no items found")
+
+// Services implements the ServiceBackend interface.
+func (c *Consul) Services(state request.Request, exact bool, opt plugin.Options) ([]msg.Service, error) {
+	return c.Records(state, exact)
+}
+
+// Reverse implements the ServiceBackend interface.
+func (c *Consul) Reverse(state request.Request, exact bool, opt plugin.Options) ([]msg.Service, error) {
+	return nil, nil
+}
+
+// Lookup implements the ServiceBackend interface.
+func (c *Consul) Lookup(state request.Request, name string, typ uint16) (*dns.Msg, error) {
+	return c.Proxy.Lookup(state, name, typ)
+}
+
+// IsNameError implements the ServiceBackend interface.
+func (c *Consul) IsNameError(err error) bool { return err == errNoItems }
+
+// Records looks up the healthy instances for the name in state. The following names are
+// supported:
+//
+//   - service.zone: all healthy instances of service.
+//   - tag.service.zone or _tag._tcp.service.zone: the healthy instances of service tagged with tag.
+//   - node.node.zone: the address of node.
+//   - a-b-c-d.addr.zone: the address a.b.c.d, used as the SRV target of instances that have an
+//     address other than that of their node.
+func (c *Consul) Records(state request.Request, exact bool) ([]msg.Service, error) {
+	zone := plugin.Zones(c.Zones).Matches(state.Name())
+	if zone == "" {
+		return nil, errNoItems
+	}
+	base, _ := dnsutil.TrimZone(state.Name(), zone)
+	segs := dns.SplitDomainName(base)
+
+	switch len(segs) {
+	case 0:
+		// The apex exists, but has no address records.
+		return nil, nil
+	case 1:
+		return c.instances(state.Name(), zone, segs[0], "")
+	case 2:
+		switch strings.ToLower(segs[1]) {
+		case "node":
+			addr, ok := c.catalog.node(segs[0])
+			if !ok {
+				return nil, errNoItems
+			}
+			return []msg.Service{{Host: addr, TTL: c.ttl, Key: msg.Path(state.Name(), "coredns")}}, nil
+		case "addr":
+			addr := strings.Replace(segs[0], "-", ".", -1)
+			if net.ParseIP(addr) == nil {
+				addr = strings.Replace(segs[0], "-", ":", -1)
+				if net.ParseIP(addr) == nil {
+					return nil, errNoItems
+				}
+			}
+			return []msg.Service{{Host: addr, TTL: c.ttl, Key: msg.Path(state.Name(), "coredns")}}, nil
+		}
+		return c.instances(state.Name(), zone, segs[1], segs[0])
+	case 3:
+		proto := strings.ToLower(segs[1])
+		if !strings.HasPrefix(segs[0], "_") || (proto != "_tcp" && proto != "_udp") {
+			return nil, errNoItems
+		}
+		return c.instances(state.Name(), zone, segs[2], segs[0][1:])
+	}
+
+	return nil, errNoItems
+}
+
+// instances returns the healthy instances of service, optionally filtered on tag. A service that is
+// known but has no (matching) healthy instances returns no services and no error.
+func (c *Consul) instances(name, zone, service, tag string) ([]msg.Service, error) {
+	instances, ok := c.catalog.instances(service)
+	if !ok {
+		return nil, errNoItems
+	}
+
+	var services []msg.Service
+	for _, i := range instances {
+		if tag != "" && !i.hasTag(tag) {
+			continue
+		}
+		// The key determines the SRV target, which must resolve to the address of the instance.
+		target := strings.ToLower(i.Node) + ".node." + zone
+		if i.Address != i.NodeAddress {
+			target = strings.Replace(strings.Replace(i.Address, ".", "-", -1), ":", "-", -1) + ".addr." + zone
+		}
+		services = append(services, msg.Service{Host: i.Address, Port: i.Port, Priority: 10, Weight: 100, TTL: c.ttl, Key: msg.Path(target, "coredns")})
+	}
+	return services, nil
+}
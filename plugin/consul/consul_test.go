@@ -0,0 +1,224 @@
+package consul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// fakeConsul is an in-process stand-in for the Consul HTTP API. It supports blocking queries on
+// the catalog and health endpoints.
+type fakeConsul struct {
+	sync.Mutex
+	index   uint64
+	changed chan struct{}
+	entries map[string][]healthEntry
+}
+
+func newFakeConsul() *fakeConsul {
+	return &fakeConsul{index: 1, changed: make(chan struct{}), entries: make(map[string][]healthEntry)}
+}
+
+func (f *fakeConsul) set(service string, entries []healthEntry) {
+	f.Lock()
+	defer f.Unlock()
+	if entries == nil {
+		delete(f.entries, service)
+	} else {
+		f.entries[service] = entries
+	}
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	if idx := r.URL.Query().Get("index"); idx != "" && idx == strconv.FormatUint(f.index, 10) {
+		changed := f.changed
+		f.Unlock()
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		f.Lock()
+	}
+	defer f.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	switch {
+	case r.URL.Path == "/v1/catalog/services":
+		svcs := make(map[string][]string)
+		for s := range f.entries {
+			svcs[s] = []string{}
+		}
+		json.NewEncoder(w).Encode(svcs)
+	case strings.HasPrefix(r.URL.Path, "/v1/health/service/"):
+		if _, ok := r.URL.Query()["passing"]; !ok {
+			http.Error(w, "only passing instances expected", http.StatusBadRequest)
+			return
+		}
+		entries := f.entries[strings.TrimPrefix(r.URL.Path, "/v1/health/service/")]
+		if entries == nil {
+			entries = []healthEntry{}
+		}
+		json.NewEncoder(w).Encode(entries)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func entry(node, nodeAddr, addr string, port int, tags ...string) healthEntry {
+	e := healthEntry{}
+	e.Node.Node, e.Node.Address = node, nodeAddr
+	e.Service.Address, e.Service.Port, e.Service.Tags = addr, port, tags
+	return e
+}
+
+var dnsTestCases = []test.Case{
+	// All healthy instances
+	{
+		Qname: "web.consul.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("web.consul.	30	IN	A	10.0.0.1"),
+			test.A("web.consul.	30	IN	A	10.0.0.2"),
+		},
+	},
+	// Instances by tag
+	{
+		Qname: "v2.web.consul.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("v2.web.consul.	30	IN	A	10.0.0.2"),
+		},
+	},
+	// SRV, the targets resolve to the instances
+	{
+		Qname: "_v2._tcp.web.consul.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{
+			test.SRV("_v2._tcp.web.consul.	30	IN	SRV	10 100 8080 10-0-0-2.addr.consul."),
+		},
+		Extra: []dns.RR{
+			test.A("10-0-0-2.addr.consul.	30	IN	A	10.0.0.2"),
+		},
+	},
+	{
+		Qname: "web.consul.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{
+			test.SRV("web.consul.	30	IN	SRV	10 50 80 node1.node.consul."),
+			test.SRV("web.consul.	30	IN	SRV	10 50 8080 10-0-0-2.addr.consul."),
+		},
+		Extra: []dns.RR{
+			test.A("10-0-0-2.addr.consul.	30	IN	A	10.0.0.2"),
+			test.A("node1.node.consul.	30	IN	A	10.0.0.1"),
+		},
+	},
+	// IPv6
+	{
+		Qname: "db.consul.", Qtype: dns.TypeAAAA,
+		Answer: []dns.RR{
+			test.AAAA("db.consul.	30	IN	AAAA	2001:db8::1"),
+		},
+	},
+	// Nodes
+	{
+		Qname: "node2.node.consul.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("node2.node.consul.	30	IN	A	10.0.1.2"),
+		},
+	},
+	// Service without healthy instances
+	{
+		Qname: "cache.consul.", Qtype: dns.TypeA,
+		Ns: []dns.RR{
+			test.SOA("consul.	300	IN	SOA	ns.dns.consul. hostmaster.consul. 1502308051 7200 1800 86400 60"),
+		},
+	},
+	// Unknown tag
+	{
+		Qname: "v3.web.consul.", Qtype: dns.TypeA,
+		Ns: []dns.RR{
+			test.SOA("consul.	300	IN	SOA	ns.dns.consul. hostmaster.consul. 1502308051 7200 1800 86400 60"),
+		},
+	},
+	// Unknown service
+	{
+		Qname: "nothere.consul.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("consul.	300	IN	SOA	ns.dns.consul. hostmaster.consul. 1502308051 7200 1800 86400 60"),
+		},
+	},
+}
+
+func TestConsul(t *testing.T) {
+	fake := newFakeConsul()
+	fake.entries["web"] = []healthEntry{
+		entry("node1", "10.0.0.1", "", 80, "v1"),
+		entry("node2", "10.0.1.2", "10.0.0.2", 8080, "v2"),
+	}
+	fake.entries["db"] = []healthEntry{entry("node3", "10.0.1.3", "2001:db8::1", 5432)}
+	fake.entries["cache"] = []healthEntry{}
+
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	cs := &Consul{Zones: []string{"consul."}, ttl: defaultTTL, catalog: newCatalog(srv.Client(), srv.URL)}
+	go cs.catalog.Run()
+	defer cs.catalog.Stop()
+
+	waitFor(t, func() bool {
+		_, ok1 := cs.catalog.instances("cache")
+		_, ok2 := cs.catalog.instances("db")
+		i, _ := cs.catalog.instances("web")
+		return ok1 && ok2 && len(i) == 2
+	})
+
+	ctx := context.TODO()
+	for i, tc := range dnsTestCases {
+		m := tc.Msg()
+
+		rec := dnsrecorder.New(&test.ResponseWriter{})
+		if _, err := cs.ServeDNS(ctx, rec, m); err != nil {
+			t.Errorf("Test %d, expected no error, got %v", i, err)
+			continue
+		}
+
+		test.SortAndCheck(t, rec.Msg, tc)
+	}
+
+	// Instances that become unhealthy are removed.
+	fake.set("web", []healthEntry{entry("node1", "10.0.0.1", "", 80, "v1")})
+	waitFor(t, func() bool {
+		i, _ := cs.catalog.instances("web")
+		return len(i) == 1
+	})
+
+	// Services that are deregistered are removed.
+	fake.set("db", nil)
+	waitFor(t, func() bool {
+		_, ok := cs.catalog.instances("db")
+		return !ok
+	})
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	for i := 0; i < 100; i++ {
+		if f() {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for the catalog")
+}
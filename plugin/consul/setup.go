@@ -0,0 +1,130 @@
+package consul
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	mwtls "github.com/coredns/coredns/plugin/pkg/tls"
+	"github.com/coredns/coredns/plugin/proxy"
+
+	"github.com/mholt/caddy"
+)
+
+func init() {
+	caddy.RegisterPlugin("consul", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	cs, err := consulParse(c)
+	if err != nil {
+		return plugin.Error("consul", err)
+	}
+
+	c.OnStartup(func() error {
+		go cs.catalog.Run()
+		return nil
+	})
+
+	c.OnShutdown(func() error {
+		return cs.catalog.Stop()
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		cs.Next = next
+		return cs
+	})
+
+	return nil
+}
+
+func consulParse(c *caddy.Controller) (*Consul, error) {
+	cs := Consul{ttl: defaultTTL}
+	var (
+		tlsConfig  *tls.Config
+		err        error
+		endpoint   = defaultEndpoint
+		token      string
+		datacenter string
+	)
+	for c.Next() {
+		cs.Zones = c.RemainingArgs()
+		if len(cs.Zones) == 0 {
+			cs.Zones = make([]string, len(c.ServerBlockKeys))
+			copy(cs.Zones, c.ServerBlockKeys)
+		}
+		for i, str := range cs.Zones {
+			cs.Zones[i] = plugin.Host(str).Normalize()
+		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "endpoint":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				endpoint = c.Val()
+			case "token":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				token = c.Val()
+			case "datacenter":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				datacenter = c.Val()
+			case "tls": // cert key cacertfile
+				args := c.RemainingArgs()
+				tlsConfig, err = mwtls.NewTLSConfigFromArgs(args...)
+				if err != nil {
+					return nil, err
+				}
+			case "ttl":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				t, err := strconv.Atoi(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if t < 0 || t > 3600 {
+					return nil, c.Errf("ttl must be in range [0, 3600]: %d", t)
+				}
+				cs.ttl = uint32(t)
+			case "upstream":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				ups, err := dnsutil.ParseHostPortOrFile(args...)
+				if err != nil {
+					return nil, err
+				}
+				cs.Proxy = proxy.NewLookup(ups)
+			case "fallthrough":
+				cs.Fallthrough = true
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+
+	client := &http.Client{Transport: mwtls.NewHTTPSTransport(tlsConfig)}
+	cs.catalog = newCatalog(client, endpoint)
+	cs.catalog.token = token
+	cs.catalog.datacenter = datacenter
+
+	return &cs, nil
+}
+
+const (
+	defaultEndpoint = "http://127.0.0.1:8500"
+	defaultTTL      = 30
+)
@@ -0,0 +1,80 @@
+package consul
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupConsul(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedEndpoint   string
+		expectedTTL        uint32
+		expectedErrContent string // substring from the expected error. Empty for positive cases.
+	}{
+		// positive
+		{
+			`consul`, false, "http://127.0.0.1:8500", 30, "",
+		},
+		{
+			`consul service.consul {
+	endpoint http://consul.example.org:8500/
+	token secret
+	datacenter dc1
+	ttl 10
+	fallthrough
+}
+`, false, "http://consul.example.org:8500", 10, "",
+		},
+		// negative
+		{
+			`consul {
+	ttl -1
+}
+`, true, "", 0, "ttl must be in range",
+		},
+		{
+			`consul {
+	endpoint
+}
+`, true, "", 0, "Wrong argument count",
+		},
+		{
+			`consul {
+	endpoints localhost:8500
+}
+`, true, "", 0, "unknown property 'endpoints'",
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		cs, err := consulParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found %s for input %s", i, err, test.input)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+				continue
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+
+		if cs.catalog.endpoint != test.expectedEndpoint {
+			t.Errorf("Test %d: Expected endpoint %q, got %q", i, test.expectedEndpoint, cs.catalog.endpoint)
+		}
+		if cs.ttl != test.expectedTTL {
+			t.Errorf("Test %d: Expected ttl %d, got %d", i, test.expectedTTL, cs.ttl)
+		}
+	}
+}
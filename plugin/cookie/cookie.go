@@ -0,0 +1,112 @@
+// Package cookie implements DNS Cookies (RFC 7873).
+package cookie
+
+import (
+	"encoding/hex"
+	"net"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// Cookie adds server cookies to responses and validates the server cookies clients send back. A
+// client that returns a valid server cookie has proven it receives responses on its address.
+type Cookie struct {
+	Next plugin.Handler
+
+	secrets *secrets
+	require bool // require a valid cookie for large UDP responses
+	size    int  // responses larger than this are large
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (c *Cookie) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	o := r.IsEdns0()
+	if o == nil && !c.require {
+		return plugin.NextOrFailure(c.Name(), c.Next, ctx, w, r)
+	}
+
+	state := request.Request{W: w, Req: r}
+
+	var client, server []byte
+	if o != nil {
+		var err error
+		client, server, err = parse(o)
+		if err != nil {
+			return dns.RcodeFormatError, err
+		}
+	}
+
+	valid := false
+	if server != nil {
+		valid = c.secrets.valid(client, server, clientIP(state), time.Now())
+	}
+
+	rw := &ResponseWriter{ResponseWriter: w, cookie: c, state: state, client: client, valid: valid}
+	return plugin.NextOrFailure(c.Name(), c.Next, ctx, rw, r)
+}
+
+// Name implements the Handler interface.
+func (c *Cookie) Name() string { return "cookie" }
+
+// parse returns the client and server cookie from the COOKIE option in o. Either is nil when
+// not present. An error is returned if the option is malformed.
+func parse(o *dns.OPT) (client, server []byte, err error) {
+	for _, e := range o.Option {
+		ec, ok := e.(*dns.EDNS0_COOKIE)
+		if !ok {
+			continue
+		}
+		b, err := hex.DecodeString(ec.Cookie)
+		if err != nil {
+			return nil, nil, errMalformed
+		}
+		switch {
+		case len(b) == clientLen:
+			return b, nil, nil
+		case len(b) >= clientLen+8 && len(b) <= clientLen+32:
+			return b[:clientLen], b[clientLen:], nil
+		}
+		return nil, nil, errMalformed
+	}
+	return nil, nil, nil
+}
+
+// clientIP returns the address of the client in its shortest form.
+func clientIP(state request.Request) net.IP {
+	ip := net.ParseIP(state.IP())
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// setCookie sets the COOKIE option in m to the client cookie followed by server.
+func setCookie(m *dns.Msg, state request.Request, client, server []byte) {
+	o := m.IsEdns0()
+	if o == nil {
+		o = new(dns.OPT)
+		o.Hdr.Name = "."
+		o.Hdr.Rrtype = dns.TypeOPT
+		o.SetUDPSize(uint16(state.Size()))
+		if state.Do() {
+			o.SetDo()
+		}
+		m.Extra = append(m.Extra, o)
+	}
+
+	opts := o.Option[:0]
+	for _, e := range o.Option {
+		if e.Option() != dns.EDNS0COOKIE {
+			opts = append(opts, e)
+		}
+	}
+	cookie := &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: hex.EncodeToString(client) + hex.EncodeToString(server)}
+	o.Option = append(opts, cookie)
+}
+
+const clientLen = 8
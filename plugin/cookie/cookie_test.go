@@ -0,0 +1,158 @@
+package cookie
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// answer answers with n A records.
+func answer(n int) test.Handler {
+	return test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetReply(r)
+		for i := 0; i < n; i++ {
+			m.Answer = append(m.Answer, test.A("example.org.	300	IN	A	10.0.0.1"))
+		}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+}
+
+func newCookie(n int) *Cookie {
+	return &Cookie{
+		Next:    answer(n),
+		secrets: newSecrets([][]byte{[]byte("0123456789abcdef")}, 0),
+		size:    defaultSize,
+	}
+}
+
+func query(ck *Cookie, cookie string) (*dns.Msg, error) {
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	if cookie != "" {
+		m.SetEdns0(4096, false)
+		o := m.IsEdns0()
+		o.Option = append(o.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: cookie})
+	}
+	rec := dnsrecorder.New(&test.ResponseWriter{})
+	_, err := ck.ServeDNS(context.TODO(), rec, m)
+	return rec.Msg, err
+}
+
+// cookieOf returns the cookie from the response, or the empty string.
+func cookieOf(m *dns.Msg) string {
+	o := m.IsEdns0()
+	if o == nil {
+		return ""
+	}
+	for _, e := range o.Option {
+		if ec, ok := e.(*dns.EDNS0_COOKIE); ok {
+			return ec.Cookie
+		}
+	}
+	return ""
+}
+
+const clientCookie = "0102030405060708"
+
+func TestCookie(t *testing.T) {
+	ck := newCookie(1)
+
+	// No cookie, no cookie back.
+	m, _ := query(ck, "")
+	if c := cookieOf(m); c != "" {
+		t.Errorf("Expected no cookie, got %s", c)
+	}
+
+	// A client cookie gets a server cookie.
+	m, _ = query(ck, clientCookie)
+	c := cookieOf(m)
+	if len(c) != 2*(clientLen+serverLen) || c[:16] != clientCookie {
+		t.Fatalf("Expected client cookie with server cookie, got %s", c)
+	}
+
+	// Which is valid.
+	server, _ := hex.DecodeString(c[16:])
+	client, _ := hex.DecodeString(clientCookie)
+	ip := net.ParseIP("10.240.0.1").To4()
+	if !ck.secrets.valid(client, server, ip, time.Now()) {
+		t.Errorf("Expected server cookie to be valid")
+	}
+	// ... but not for another client address or cookie.
+	if ck.secrets.valid(client, server, net.ParseIP("10.240.0.2").To4(), time.Now()) {
+		t.Errorf("Expected server cookie to be invalid for another address")
+	}
+	if ck.secrets.valid([]byte("abcdefgh"), server, ip, time.Now()) {
+		t.Errorf("Expected server cookie to be invalid for another client cookie")
+	}
+	// ... nor too long after it was created.
+	if ck.secrets.valid(client, server, ip, time.Now().Add(2*time.Hour)) {
+		t.Errorf("Expected server cookie to be expired")
+	}
+
+	// Malformed cookies are a format error.
+	for _, c := range []string{"01020304", "0102030405060708090a", "zz02030405060708"} {
+		if _, err := query(ck, c); err == nil {
+			t.Errorf("Expected error for cookie %s", c)
+		}
+	}
+}
+
+func TestCookieRotate(t *testing.T) {
+	ck := newCookie(1)
+
+	client, _ := hex.DecodeString(clientCookie)
+	ip := net.ParseIP("10.240.0.1").To4()
+	server := ck.secrets.create(client, ip, time.Now())
+
+	ck.secrets.next()
+	if !ck.secrets.valid(client, server, ip, time.Now()) {
+		t.Errorf("Expected server cookie to be valid after one rotation")
+	}
+	ck.secrets.next()
+	if ck.secrets.valid(client, server, ip, time.Now()) {
+		t.Errorf("Expected server cookie to be invalid after two rotations")
+	}
+}
+
+func TestCookieRequire(t *testing.T) {
+	ck := newCookie(40)
+	ck.require = true
+
+	// No cookie: truncated.
+	m, _ := query(ck, "")
+	if !m.Truncated || len(m.Answer) != 0 {
+		t.Errorf("Expected truncated response without answers, got %v", m)
+	}
+
+	// Only a client cookie: BADCOOKIE with a server cookie to retry with.
+	m, _ = query(ck, clientCookie)
+	if m.Rcode != dns.RcodeBadCookie {
+		t.Fatalf("Expected BADCOOKIE, got %d", m.Rcode)
+	}
+	c := cookieOf(m)
+	if c == "" {
+		t.Fatalf("Expected cookie in BADCOOKIE response")
+	}
+
+	// With the server cookie we get the answer.
+	m, _ = query(ck, c)
+	if m.Rcode != dns.RcodeSuccess || len(m.Answer) != 40 {
+		t.Errorf("Expected full answer with a valid cookie, got %v", m)
+	}
+
+	// Small responses don't need a cookie.
+	ck.Next = answer(1)
+	m, _ = query(ck, "")
+	if m.Truncated || len(m.Answer) != 1 {
+		t.Errorf("Expected small answer without a cookie, got %v", m)
+	}
+}
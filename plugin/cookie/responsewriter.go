@@ -0,0 +1,46 @@
+package cookie
+
+import (
+	"log"
+	"time"
+
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+// ResponseWriter adds a fresh server cookie to the response, and withholds large UDP responses
+// from clients without a valid cookie if that is required.
+type ResponseWriter struct {
+	dns.ResponseWriter
+	cookie *Cookie
+	state  request.Request
+	client []byte // client cookie, nil if the client did not send one
+	valid  bool   // the client sent a valid server cookie
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) WriteMsg(res *dns.Msg) error {
+	if w.cookie.require && !w.valid && w.state.Proto() == "udp" && res.Len() > w.cookie.size {
+		m := new(dns.Msg)
+		m.SetReply(w.state.Req)
+		if w.client != nil {
+			// The client supports cookies, hand it one to retry with.
+			m.Rcode = dns.RcodeBadCookie
+		} else {
+			m.Truncated = true
+		}
+		res = m
+	}
+
+	if w.client != nil {
+		setCookie(res, w.state, w.client, w.cookie.secrets.create(w.client, clientIP(w.state), time.Now()))
+	}
+	return w.ResponseWriter.WriteMsg(res)
+}
+
+// Write implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) Write(buf []byte) (int, error) {
+	log.Printf("[WARNING] Cookie called with Write: not adding cookie to reply")
+	return w.ResponseWriter.Write(buf)
+}
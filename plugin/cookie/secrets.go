@@ -0,0 +1,131 @@
+package cookie
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// secrets holds the secrets server cookies are created with. The first secret creates cookies,
+// all of them are used to validate cookies.
+type secrets struct {
+	sync.RWMutex
+	keys [][]byte
+
+	rotate time.Duration // rotate the secret, 0 when the secrets are configured
+	stop   chan struct{}
+}
+
+func newSecrets(keys [][]byte, rotate time.Duration) *secrets {
+	return &secrets{keys: keys, rotate: rotate, stop: make(chan struct{})}
+}
+
+// Run rotates the secret until Stop is called. The previous secret is kept, so that cookies
+// created just before a rotation remain valid.
+func (s *secrets) Run() {
+	if s.rotate == 0 {
+		return
+	}
+	tick := time.NewTicker(s.rotate)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			if err := s.next(); err != nil {
+				log.Printf("[ERROR] Failed to rotate cookie secret: %s", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the rotation of the secret.
+func (s *secrets) Stop() error {
+	close(s.stop)
+	return nil
+}
+
+// next makes a new random secret the current one, and the current the previous one.
+func (s *secrets) next() error {
+	key, err := randomKey()
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	if len(s.keys) > 0 {
+		s.keys = [][]byte{key, s.keys[0]}
+	} else {
+		s.keys = [][]byte{key}
+	}
+	return nil
+}
+
+// create returns a new server cookie for the client cookie and address, in the format of RFC 9018:
+// version (1), reserved (0, 0, 0), timestamp (4 bytes) and the first 8 bytes of an HMAC-SHA256
+// of the client cookie, the preceding fields and the client address.
+func (s *secrets) create(client []byte, ip net.IP, now time.Time) []byte {
+	b := make([]byte, serverLen)
+	b[0] = version
+	binary.BigEndian.PutUint32(b[4:8], uint32(now.Unix()))
+
+	s.RLock()
+	key := s.keys[0]
+	s.RUnlock()
+
+	copy(b[8:], hash(key, client, b[:8], ip))
+	return b
+}
+
+// valid returns true if server is a server cookie we created for the client cookie and address,
+// and it is not too old or from the future.
+func (s *secrets) valid(client, server []byte, ip net.IP, now time.Time) bool {
+	if len(server) != serverLen || server[0] != version {
+		return false
+	}
+	ts := int64(binary.BigEndian.Uint32(server[4:8]))
+	if age := now.Unix() - ts; age > maxAge || age < -maxSkew {
+		return false
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+	for _, key := range s.keys {
+		if hmac.Equal(server[8:], hash(key, client, server[:8], ip)) {
+			return true
+		}
+	}
+	return false
+}
+
+func hash(key, client, header []byte, ip net.IP) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(client)
+	h.Write(header)
+	h.Write(ip)
+	return h.Sum(nil)[:8]
+}
+
+func randomKey() ([]byte, error) {
+	key := make([]byte, keyLen)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+var errMalformed = errors.New("malformed COOKIE option")
+
+const (
+	version   = 1
+	serverLen = 16
+	keyLen    = 16
+
+	maxAge  = 3600 // seconds a server cookie is valid
+	maxSkew = 300  // seconds a server cookie may be from the future
+)
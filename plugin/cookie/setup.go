@@ -0,0 +1,131 @@
+package cookie
+
+import (
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/mholt/caddy"
+)
+
+func init() {
+	caddy.RegisterPlugin("cookie", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	ck, err := cookieParse(c)
+	if err != nil {
+		return plugin.Error("cookie", err)
+	}
+
+	c.OnStartup(func() error {
+		go ck.secrets.Run()
+		return nil
+	})
+
+	c.OnShutdown(func() error {
+		return ck.secrets.Stop()
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		ck.Next = next
+		return ck
+	})
+
+	return nil
+}
+
+func cookieParse(c *caddy.Controller) (*Cookie, error) {
+	ck := &Cookie{size: defaultSize}
+
+	var keys [][]byte
+	rotate := time.Duration(0)
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, c.Err("cookie can only be specified once")
+		}
+		i++
+
+		if len(c.RemainingArgs()) != 0 {
+			return nil, c.ArgErr()
+		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "secret":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					key, err := hex.DecodeString(a)
+					if err != nil || len(key) != keyLen {
+						return nil, c.Errf("secret must be %d hex encoded bytes: %s", keyLen, a)
+					}
+					keys = append(keys, key)
+				}
+			case "rotate":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(args[0])
+				if err != nil {
+					return nil, err
+				}
+				if d < time.Minute {
+					return nil, c.Errf("rotate must be at least a minute: %s", d)
+				}
+				rotate = d
+			case "require":
+				ck.require = true
+				args := c.RemainingArgs()
+				if len(args) > 1 {
+					return nil, c.ArgErr()
+				}
+				if len(args) == 1 {
+					n, err := strconv.Atoi(args[0])
+					if err != nil {
+						return nil, err
+					}
+					if n < 0 || n > 65535 {
+						return nil, c.Errf("size must be in range [0, 65535]: %d", n)
+					}
+					ck.size = n
+				}
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+
+	if len(keys) > 0 && rotate > 0 {
+		return nil, c.Err("secret and rotate are mutually exclusive")
+	}
+	if len(keys) == 0 {
+		key, err := randomKey()
+		if err != nil {
+			return nil, err
+		}
+		keys = [][]byte{key}
+		if rotate == 0 {
+			rotate = defaultRotate
+		}
+	}
+	ck.secrets = newSecrets(keys, rotate)
+
+	return ck, nil
+}
+
+const (
+	defaultRotate = 24 * time.Hour
+	defaultSize   = 512
+)
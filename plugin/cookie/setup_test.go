@@ -0,0 +1,75 @@
+package cookie
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupCookie(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		require   bool
+		size      int
+		rotate    time.Duration
+		keys      int
+	}{
+		{`cookie`, false, false, defaultSize, defaultRotate, 1},
+		{`cookie {
+			rotate 1h
+			require
+		}`, false, true, defaultSize, time.Hour, 1},
+		{`cookie {
+			secret 000102030405060708090a0b0c0d0e0f 0f0e0d0c0b0a09080706050403020100
+			require 1232
+		}`, false, true, 1232, 0, 2},
+		{`cookie example.org`, true, false, 0, 0, 0},
+		{`cookie {
+			secret 0001
+		}`, true, false, 0, 0, 0},
+		{`cookie {
+			secret 000102030405060708090a0b0c0d0e0f
+			rotate 1h
+		}`, true, false, 0, 0, 0},
+		{`cookie {
+			rotate 1s
+		}`, true, false, 0, 0, 0},
+		{`cookie {
+			require big
+		}`, true, false, 0, 0, 0},
+		{`cookie {
+			enforce
+		}`, true, false, 0, 0, 0},
+		{`cookie
+		cookie`, true, false, 0, 0, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		ck, err := cookieParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+		if ck.require != test.require {
+			t.Errorf("Test %d: Expected require %t, got %t", i, test.require, ck.require)
+		}
+		if ck.size != test.size {
+			t.Errorf("Test %d: Expected size %d, got %d", i, test.size, ck.size)
+		}
+		if ck.secrets.rotate != test.rotate {
+			t.Errorf("Test %d: Expected rotate %s, got %s", i, test.rotate, ck.secrets.rotate)
+		}
+		if len(ck.secrets.keys) != test.keys {
+			t.Errorf("Test %d: Expected %d secrets, got %d", i, test.keys, len(ck.secrets.keys))
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/dnstap/msg"
 	"github.com/coredns/coredns/plugin/dnstap/taprw"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/request"
 
 	tap "github.com/dnstap/golang-dnstap"
 	"github.com/miekg/dns"
@@ -18,6 +20,10 @@ type Dnstap struct {
 	Next plugin.Handler
 	Out  io.Writer
 	Pack bool
+
+	// Identity and Version are set in every payload.
+	Identity []byte
+	Version  []byte
 }
 
 type (
@@ -38,8 +44,8 @@ func TapperFromContext(ctx context.Context) (t Tapper) {
 	return
 }
 
-func tapMessageTo(w io.Writer, m *tap.Message) error {
-	frame, err := msg.Marshal(m)
+func tapMessageTo(w io.Writer, p msg.Payload, m *tap.Message) error {
+	frame, err := p.Marshal(m)
 	if err != nil {
 		return fmt.Errorf("marshal: %s", err)
 	}
@@ -47,9 +53,13 @@ func tapMessageTo(w io.Writer, m *tap.Message) error {
 	return err
 }
 
+func (h Dnstap) payload() msg.Payload {
+	return msg.Payload{Identity: h.Identity, Version: h.Version}
+}
+
 // TapMessage implements Tapper.
 func (h Dnstap) TapMessage(m *tap.Message) error {
-	return tapMessageTo(h.Out, m)
+	return tapMessageTo(h.Out, h.payload(), m)
 }
 
 // TapBuilder implements Tapper.
@@ -59,7 +69,7 @@ func (h Dnstap) TapBuilder() msg.Builder {
 
 // ServeDNS logs the client query and response to dnstap and passes the dnstap Context.
 func (h Dnstap) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
-	rw := &taprw.ResponseWriter{ResponseWriter: w, Tapper: &h, Query: r}
+	rw := &taprw.ResponseWriter{ResponseWriter: w, Tapper: queryTapper{h, ctx}, Query: r}
 	rw.QueryEpoch()
 
 	code, err := plugin.NextOrFailure(h.Name(), h.Next, tapContext{ctx, h}, rw, r)
@@ -75,5 +85,58 @@ func (h Dnstap) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 	return code, nil
 }
 
+// queryTapper taps the client messages of a query, it marks the response when it was served from
+// a cache.
+type queryTapper struct {
+	Dnstap
+	ctx context.Context
+}
+
+// TapMessage implements taprw.Tapper.
+func (t queryTapper) TapMessage(m *tap.Message) error {
+	p := t.payload()
+	if m.GetType() == tap.Message_CLIENT_RESPONSE && cached(t.ctx) {
+		p.Extra = msg.Cached
+	}
+	return tapMessageTo(t.Out, p, m)
+}
+
+// cached returns true if the cache plugin answered the query.
+func cached(ctx context.Context) bool {
+	k, ok := metadata.Lookup("cache/hit")
+	if !ok {
+		return false
+	}
+	hit, _ := metadata.Get(ctx, k)
+	return hit == "true"
+}
+
+// TapAuth taps the query in state and the reply to it as AUTH_QUERY and AUTH_RESPONSE messages.
+// Plugins that are authoritative for their data call it before writing reply, it does nothing if
+// the dnstap plugin is not enabled.
+func TapAuth(ctx context.Context, state request.Request, reply *dns.Msg) error {
+	tapper := TapperFromContext(ctx)
+	if tapper == nil {
+		return nil
+	}
+
+	b := tapper.TapBuilder()
+	b.TimeSec = msg.Epoch()
+	if err := b.AddrMsg(state.W.RemoteAddr(), state.Req); err != nil {
+		return fmt.Errorf("auth query: %s", err)
+	}
+	if err := tapper.TapMessage(b.ToAuthQuery()); err != nil {
+		return fmt.Errorf("auth query: %s", err)
+	}
+
+	if err := b.Msg(reply); err != nil {
+		return fmt.Errorf("auth response: %s", err)
+	}
+	if err := tapper.TapMessage(b.ToAuthResponse()); err != nil {
+		return fmt.Errorf("auth response: %s", err)
+	}
+	return nil
+}
+
 // Name returns dnstap.
 func (h Dnstap) Name() string { return "dnstap" }
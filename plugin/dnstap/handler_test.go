@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/coredns/coredns/plugin/dnstap/msg"
 	"github.com/coredns/coredns/plugin/dnstap/test"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	mwtest "github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
 
 	tap "github.com/dnstap/golang-dnstap"
 	"github.com/golang/protobuf/proto"
@@ -63,3 +66,85 @@ func TestDnstap(t *testing.T) {
 	tapr := test.TestingData().ToClientResponse()
 	testCase(t, tapq, tapr, q, r)
 }
+
+// payloadWriter keeps the payloads that are written to it.
+type payloadWriter struct {
+	payloads []tap.Dnstap
+}
+
+func (w *payloadWriter) Write(b []byte) (int, error) {
+	e := tap.Dnstap{}
+	if err := proto.Unmarshal(b, &e); err != nil {
+		return 0, err
+	}
+	w.payloads = append(w.payloads, e)
+	return len(b), nil
+}
+
+var hitKey = metadata.Register("cache/hit")
+
+func TestDnstapPayload(t *testing.T) {
+	q := mwtest.Case{Qname: "example.org", Qtype: dns.TypeA}.Msg()
+	for _, hit := range []string{"", "false", "true"} {
+		w := &payloadWriter{}
+		h := Dnstap{
+			Next: mwtest.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+				if hit != "" {
+					metadata.Set(ctx, hitKey, hit)
+				}
+				m := new(dns.Msg)
+				m.SetReply(r)
+				return 0, w.WriteMsg(m)
+			}),
+			Out:      w,
+			Identity: []byte("ns1.example.org"),
+			Version:  []byte("CoreDNS-test"),
+		}
+		if _, err := h.ServeDNS(metadata.NewContext(context.TODO()), &mwtest.ResponseWriter{}, q); err != nil {
+			t.Fatal(err)
+		}
+		if len(w.payloads) != 2 {
+			t.Fatalf("Expected 2 payloads, got %d", len(w.payloads))
+		}
+		for _, p := range w.payloads {
+			if string(p.Identity) != "ns1.example.org" || string(p.Version) != "CoreDNS-test" {
+				t.Errorf("Expected identity ns1.example.org and version CoreDNS-test, got %q and %q", p.Identity, p.Version)
+			}
+		}
+		if len(w.payloads[0].Extra) != 0 {
+			t.Errorf("Expected no extra in the client query, got %q", w.payloads[0].Extra)
+		}
+		if cached := string(w.payloads[1].Extra) == string(msg.Cached); cached != (hit == "true") {
+			t.Errorf("Cache hit %q: expected cached %t, got extra %q", hit, hit == "true", w.payloads[1].Extra)
+		}
+	}
+}
+
+func TestTapAuth(t *testing.T) {
+	q := mwtest.Case{Qname: "example.org", Qtype: dns.TypeA}.Msg()
+	r := mwtest.Case{
+		Qname: "example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			mwtest.A("example.org. 3600	IN	A 10.0.0.1"),
+		},
+	}.Msg()
+	state := request.Request{W: &mwtest.ResponseWriter{}, Req: q}
+
+	if err := TapAuth(context.TODO(), state, r); err != nil {
+		t.Errorf("Expected no error without dnstap, got %s", err)
+	}
+
+	ctx := test.Context{Context: context.TODO()}
+	if err := TapAuth(&ctx, state, r); err != nil {
+		t.Fatal(err)
+	}
+	if len(ctx.Trap) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(ctx.Trap))
+	}
+	if want := test.TestingData().ToAuthQuery(); !test.MsgEqual(ctx.Trap[0], want) {
+		t.Errorf("want: %v\nhave: %v", want, ctx.Trap[0])
+	}
+	if want := test.TestingData().ToAuthResponse(); !test.MsgEqual(ctx.Trap[1], want) {
+		t.Errorf("want: %v\nhave: %v", want, ctx.Trap[1])
+	}
+}
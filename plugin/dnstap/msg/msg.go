@@ -141,6 +141,22 @@ func (d *Data) ToClientQuery() *tap.Message {
 	}
 }
 
+// ToAuthQuery transforms Data into a query message received by an authoritative server.
+func (d *Data) ToAuthQuery() *tap.Message {
+	m := d.ToClientQuery()
+	t := tap.Message_AUTH_QUERY
+	m.Type = &t
+	return m
+}
+
+// ToAuthResponse transforms Data into a response message sent by an authoritative server.
+func (d *Data) ToAuthResponse() *tap.Message {
+	m := d.ToClientResponse()
+	t := tap.Message_AUTH_RESPONSE
+	m.Type = &t
+	return m
+}
+
 // ToOutsideQuery transforms the data into a forwarder or resolver query message.
 func (d *Data) ToOutsideQuery(t tap.Message_Type) *tap.Message {
 	return &tap.Message{
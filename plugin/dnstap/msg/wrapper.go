@@ -7,20 +7,38 @@ import (
 	"github.com/golang/protobuf/proto"
 )
 
-func wrap(m *lib.Message) *lib.Dnstap {
+// Cached is the Extra of the payload of a client response that was served from a cache.
+var Cached = []byte("cached")
+
+// Payload holds the fields of a dnstap payload that are not part of its message.
+type Payload struct {
+	Identity []byte // the name of the server
+	Version  []byte // the version of the server
+	Extra    []byte
+}
+
+func (p Payload) wrap(m *lib.Message) *lib.Dnstap {
 	t := lib.Dnstap_MESSAGE
 	return &lib.Dnstap{
-		Type:    &t,
-		Message: m,
+		Type:     &t,
+		Identity: p.Identity,
+		Version:  p.Version,
+		Extra:    p.Extra,
+		Message:  m,
 	}
 }
 
 // Marshal encodes the message to a binary dnstap payload.
-func Marshal(m *lib.Message) (data []byte, err error) {
-	data, err = proto.Marshal(wrap(m))
+func (p Payload) Marshal(m *lib.Message) (data []byte, err error) {
+	data, err = proto.Marshal(p.wrap(m))
 	if err != nil {
 		err = fmt.Errorf("proto: %s", err)
 		return
 	}
 	return
 }
+
+// Marshal encodes the message to a binary dnstap payload without identity, version or extra.
+func Marshal(m *lib.Message) (data []byte, err error) {
+	return Payload{}.Marshal(m)
+}
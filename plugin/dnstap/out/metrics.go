@@ -0,0 +1,19 @@
+package out
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DroppedCount counts the frames that were not sent, by the address they were meant for.
+var DroppedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "dnstap",
+	Name:      "dropped_total",
+	Help:      "Counter of dnstap messages that were dropped because the queue was full or the connection failed.",
+}, []string{"address"})
+
+func init() {
+	prometheus.MustRegister(DroppedCount)
+}
@@ -0,0 +1,233 @@
+package out
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	fs "github.com/farsightsec/golang-framestream"
+)
+
+// Options configures a Stream. Zero values are replaced by the defaults.
+type Options struct {
+	TLS        *tls.Config   // when set, the connection is encrypted, only for tcp
+	Queue      int           // number of frames waiting to be sent, beyond that frames are dropped
+	Flush      time.Duration // longest time a frame stays buffered
+	Backoff    time.Duration // wait before the first redial, doubled after each failure
+	MaxBackoff time.Duration // longest wait between redials
+}
+
+// Stream is a Frame Streams encoder that sends frames over a Unix socket, TCP or TLS. Frames are
+// queued and sent in the background: Write never blocks, when the queue is full the frame is
+// dropped and counted. The connection is kept open and redialed with exponential backoff when it
+// fails, buffered frames are flushed at least every Options.Flush.
+type Stream struct {
+	network string
+	address string
+	opt     Options
+
+	queue chan []byte
+	stop  chan struct{}
+	done  chan struct{}
+	start sync.Once // starts run, or closes done when the stream is closed first
+	once  sync.Once
+
+	// Only used by the run goroutine.
+	conn    net.Conn
+	enc     *fs.Encoder
+	pending int // frames written since the last flush
+}
+
+// NewStream returns a Stream that sends to address, network is "unix" or "tcp". Nothing is sent
+// until Start is called.
+func NewStream(network, address string, opt Options) *Stream {
+	if opt.Queue <= 0 {
+		opt.Queue = DefaultQueue
+	}
+	if opt.Flush <= 0 {
+		opt.Flush = DefaultFlush
+	}
+	if opt.Backoff <= 0 {
+		opt.Backoff = defaultBackoff
+	}
+	if opt.MaxBackoff <= 0 {
+		opt.MaxBackoff = defaultMaxBackoff
+	}
+	if opt.MaxBackoff < opt.Backoff {
+		opt.MaxBackoff = opt.Backoff
+	}
+	s := &Stream{
+		network: network,
+		address: address,
+		opt:     opt,
+		queue:   make(chan []byte, opt.Queue),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	return s
+}
+
+// Start connects in the background and starts sending the queued frames.
+func (s *Stream) Start() {
+	s.start.Do(func() { go s.run() })
+}
+
+// Write queues a single Frame Streams frame. It drops the frame if the queue is full, this is not
+// an error: the loss is counted in DroppedCount.
+func (s *Stream) Write(frame []byte) (int, error) {
+	select {
+	case s.queue <- frame:
+	default:
+		DroppedCount.WithLabelValues(s.address).Inc()
+	}
+	return len(frame), nil
+}
+
+// Close sends the queued frames if the stream is connected, and closes it.
+func (s *Stream) Close() error {
+	s.start.Do(func() { close(s.done) })
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return nil
+}
+
+func (s *Stream) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.opt.Flush)
+	defer ticker.Stop()
+
+	backoff := s.opt.Backoff
+	var retry <-chan time.Time // set while waiting to redial
+
+	for {
+		if s.enc == nil && retry == nil {
+			if err := s.connect(); err != nil {
+				log.Printf("[WARNING] Can't connect to dnstap %s, retrying in %s: %s", s.address, backoff, err)
+				retry = time.After(backoff)
+				if backoff *= 2; backoff > s.opt.MaxBackoff {
+					backoff = s.opt.MaxBackoff
+				}
+			} else {
+				backoff = s.opt.Backoff
+			}
+		}
+
+		// While not connected the frames stay in the queue, and are dropped once it is full.
+		in := s.queue
+		if s.enc == nil {
+			in = nil
+		}
+
+		select {
+		case <-retry:
+			retry = nil
+		case frame := <-in:
+			s.write(frame)
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.shutdown()
+			return
+		}
+	}
+}
+
+// connect dials the address and does the Frame Streams handshake.
+func (s *Stream) connect() error {
+	conn, err := net.DialTimeout(s.network, s.address, timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if s.opt.TLS != nil {
+		c := s.opt.TLS
+		if c.ServerName == "" {
+			c = c.Clone()
+			c.ServerName, _, _ = net.SplitHostPort(s.address)
+		}
+		tc := tls.Client(conn, c)
+		if err := tc.Handshake(); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tc
+	}
+
+	enc, err := fs.NewEncoder(conn, &fs.EncoderOptions{
+		ContentType:   []byte("protobuf:dnstap.Dnstap"),
+		Bidirectional: true,
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	s.conn, s.enc, s.pending = conn, enc, 0
+	return nil
+}
+
+func (s *Stream) write(frame []byte) {
+	s.pending++
+	s.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err := s.enc.Write(frame); err != nil {
+		s.fail(err)
+	}
+}
+
+func (s *Stream) flush() {
+	if s.enc == nil || s.pending == 0 {
+		return
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if err := s.enc.Flush(); err != nil {
+		s.fail(err)
+		return
+	}
+	s.pending = 0
+}
+
+// fail closes the connection after err, the frames that were not flushed yet are lost.
+func (s *Stream) fail(err error) {
+	log.Printf("[WARNING] Lost connection to dnstap %s: %s", s.address, err)
+	DroppedCount.WithLabelValues(s.address).Add(float64(s.pending))
+	s.conn.Close()
+	s.conn, s.enc, s.pending = nil, nil, 0
+}
+
+func (s *Stream) shutdown() {
+	for s.enc != nil {
+		select {
+		case frame := <-s.queue:
+			s.write(frame)
+			continue
+		default:
+		}
+		s.flush()
+		if s.enc != nil {
+			s.conn.SetDeadline(time.Now().Add(timeout))
+			s.enc.Close()
+			s.conn.Close()
+			s.conn, s.enc = nil, nil
+		}
+	}
+	if n := len(s.queue); n > 0 {
+		DroppedCount.WithLabelValues(s.address).Add(float64(n))
+	}
+}
+
+const (
+	// DefaultQueue is the default number of frames that can wait to be sent.
+	DefaultQueue = 10000
+	// DefaultFlush is the default interval between flushes.
+	DefaultFlush = time.Second
+
+	defaultBackoff    = 100 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+
+	timeout = 5 * time.Second // for dialing and each write
+)
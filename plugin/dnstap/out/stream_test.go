@@ -0,0 +1,231 @@
+package out
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fs "github.com/farsightsec/golang-framestream"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// serve accepts one connection on l and sends the frames it decodes to frames.
+func serve(t *testing.T, l net.Listener, frames chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	dec, err := fs.NewDecoder(conn, &fs.DecoderOptions{
+		ContentType:   []byte("protobuf:dnstap.Dnstap"),
+		Bidirectional: true,
+	})
+	if err != nil {
+		t.Errorf("server decoder: %s", err)
+		return
+	}
+	for {
+		frame, err := dec.Decode()
+		if err != nil {
+			return
+		}
+		frames <- string(frame)
+	}
+}
+
+func receive(t *testing.T, frames <-chan string, want string) {
+	select {
+	case frame := <-frames:
+		if frame != want {
+			t.Errorf("Expected frame %q, got %q", want, frame)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("Expected frame %q, got nothing", want)
+	}
+}
+
+func TestStreamFlush(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	frames := make(chan string, 10)
+	go serve(t, l, frames)
+
+	s := NewStream("tcp", l.Addr().String(), Options{Flush: 10 * time.Millisecond})
+	s.Start()
+	defer s.Close()
+
+	// A single frame is sent without closing the stream.
+	s.Write([]byte("frame"))
+	receive(t, frames, "frame")
+}
+
+func TestStreamUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dnstap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sock := filepath.Join(dir, "dnstap.sock")
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	frames := make(chan string, 10)
+	go serve(t, l, frames)
+
+	s := NewStream("unix", sock, Options{Flush: 10 * time.Millisecond})
+	s.Start()
+	defer s.Close()
+
+	s.Write([]byte("first"))
+	receive(t, frames, "first")
+	s.Write([]byte("second"))
+	receive(t, frames, "second")
+}
+
+func TestStreamReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	s := NewStream("tcp", addr, Options{Flush: 10 * time.Millisecond, Backoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond})
+	s.Start()
+	defer s.Close()
+
+	// Queued while nothing listens.
+	s.Write([]byte("first"))
+
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	frames := make(chan string, 10)
+	go serve(t, l, frames)
+
+	receive(t, frames, "first")
+	s.Write([]byte("second"))
+	receive(t, frames, "second")
+}
+
+func TestStreamDrop(t *testing.T) {
+	// Nothing listens on the address, so the queue fills up.
+	s := NewStream("unix", "/nonexistent/dnstap.sock", Options{Queue: 2, Backoff: time.Hour})
+	s.Start()
+
+	before := dropped(t, "/nonexistent/dnstap.sock")
+	for i := 0; i < 5; i++ {
+		if _, err := s.Write([]byte("frame")); err != nil {
+			t.Errorf("Expected no error when dropping a frame, got %s", err)
+		}
+	}
+	s.Close()
+
+	// 3 frames don't fit in the queue, and the 2 queued ones are dropped on Close.
+	if got := dropped(t, "/nonexistent/dnstap.sock") - before; got != 5 {
+		t.Errorf("Expected 5 dropped frames, got %f", got)
+	}
+}
+
+func TestStreamNotStarted(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	accepted := make(chan bool, 1)
+	go func() {
+		if c, err := l.Accept(); err == nil {
+			c.Close()
+			accepted <- true
+		}
+	}()
+
+	// A stream that is closed before it is started never connects.
+	s := NewStream("tcp", l.Addr().String(), Options{})
+	s.Close()
+	s.Start()
+
+	select {
+	case <-accepted:
+		t.Errorf("Expected no connection")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStreamTLS(t *testing.T) {
+	cert, roots := testCert(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	frames := make(chan string, 10)
+	go serve(t, l, frames)
+
+	s := NewStream("tcp", l.Addr().String(), Options{TLS: &tls.Config{RootCAs: roots}})
+	s.Start()
+	s.Write([]byte("frame"))
+	// Close sends the queued frames.
+	s.Close()
+	receive(t, frames, "frame")
+}
+
+func dropped(t *testing.T, address string) float64 {
+	m := &dto.Metric{}
+	if err := DroppedCount.WithLabelValues(address).(prometheus.Metric).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+// testCert returns a self signed certificate for 127.0.0.1, and a pool that trusts it.
+func testCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "dnstap collector"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(c)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, roots
+}
@@ -1,15 +1,19 @@
 package dnstap
 
 import (
+	"crypto/tls"
 	"fmt"
-	"io"
-	"log"
+	"net"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/dnstap/out"
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	pkgtls "github.com/coredns/coredns/plugin/pkg/tls"
 
 	"github.com/mholt/caddy"
 	"github.com/mholt/caddy/caddyfile"
@@ -33,6 +37,12 @@ type config struct {
 	target string
 	socket bool
 	full   bool
+	tls    *tls.Config // only for tls:// targets
+	queue  int
+	flush  time.Duration
+
+	identity string // defaults to the hostname
+	version  string // defaults to CoreDNS-<version>
 }
 
 func parseConfig(d *caddyfile.Dispenser) (c config, err error) {
@@ -42,7 +52,14 @@ func parseConfig(d *caddyfile.Dispenser) (c config, err error) {
 		return c, d.ArgErr()
 	}
 
-	if strings.HasPrefix(c.target, "tcp://") {
+	isTLS := strings.HasPrefix(c.target, "tls://")
+	if isTLS {
+		// remote endpoint, the name is used to verify its certificate
+		c.target = c.target[6:]
+		if _, _, err := net.SplitHostPort(c.target); err != nil {
+			return c, d.ArgErr()
+		}
+	} else if strings.HasPrefix(c.target, "tcp://") {
 		// remote IP endpoint
 		servers, err := dnsutil.ParseHostPortOrFile(c.target[6:])
 		if err != nil {
@@ -57,9 +74,56 @@ func parseConfig(d *caddyfile.Dispenser) (c config, err error) {
 		c.socket = true
 	}
 
-	c.full = d.NextArg() && d.Val() == "full"
+	args := d.RemainingArgs()
+	c.full = len(args) > 0 && args[0] == "full"
+
+	for d.NextBlock() {
+		switch d.Val() {
+		case "tls":
+			if !isTLS {
+				return c, d.Errf("tls is only valid for tls:// targets")
+			}
+			if c.tls, err = pkgtls.NewTLSConfigFromArgs(d.RemainingArgs()...); err != nil {
+				return c, err
+			}
+		case "queue":
+			if !d.NextArg() {
+				return c, d.ArgErr()
+			}
+			if c.queue, err = strconv.Atoi(d.Val()); err != nil || c.queue <= 0 {
+				return c, d.Errf("invalid queue size: %q", d.Val())
+			}
+		case "flush":
+			if !d.NextArg() {
+				return c, d.ArgErr()
+			}
+			if c.flush, err = time.ParseDuration(d.Val()); err != nil || c.flush <= 0 {
+				return c, d.Errf("invalid flush interval: %q", d.Val())
+			}
+		case "identity":
+			if !d.NextArg() {
+				return c, d.ArgErr()
+			}
+			c.identity = d.Val()
+		case "version":
+			if !d.NextArg() {
+				return c, d.ArgErr()
+			}
+			c.version = d.Val()
+		default:
+			return c, d.Errf("unknown property '%s'", d.Val())
+		}
+		if d.NextArg() {
+			return c, d.ArgErr()
+		}
+	}
+
+	if isTLS && c.tls == nil {
+		// system CAs
+		c.tls = &tls.Config{}
+	}
 
-	return
+	return c, nil
 }
 
 func setup(c *caddy.Controller) error {
@@ -68,19 +132,27 @@ func setup(c *caddy.Controller) error {
 		return err
 	}
 
-	dnstap := Dnstap{Pack: conf.full}
+	if conf.identity == "" {
+		conf.identity, _ = os.Hostname()
+	}
+	if conf.version == "" {
+		conf.version = caddy.AppName + "-" + caddy.AppVersion
+	}
+
+	dnstap := Dnstap{Pack: conf.full, Identity: []byte(conf.identity), Version: []byte(conf.version)}
 
-	var o io.WriteCloser
+	network := "tcp"
 	if conf.socket {
-		o, err = out.NewSocket(conf.target)
-		if err != nil {
-			log.Printf("[WARN] Can't connect to %s at the moment: %s", conf.target, err)
-		}
-	} else {
-		o = out.NewTCP(conf.target)
+		network = "unix"
 	}
+	o := out.NewStream(network, conf.target, out.Options{TLS: conf.tls, Queue: conf.queue, Flush: conf.flush})
 	dnstap.Out = o
 
+	c.OnStartup(func() error {
+		o.Start()
+		return nil
+	})
+
 	c.OnShutdown(func() error {
 		if err := o.Close(); err != nil {
 			return fmt.Errorf("output: %s", err)
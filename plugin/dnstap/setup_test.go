@@ -1,8 +1,10 @@
 package dnstap
 
 import (
-	"github.com/mholt/caddy"
 	"testing"
+	"time"
+
+	"github.com/mholt/caddy"
 )
 
 func TestConfig(t *testing.T) {
@@ -32,3 +34,68 @@ func TestConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigBlock(t *testing.T) {
+	tests := []struct {
+		file  string
+		path  string
+		tls   bool
+		queue int
+		flush time.Duration
+		ident string
+		vers  string
+		fail  bool
+	}{
+		{"dnstap tls://collector.example.org:6000", "collector.example.org:6000", true, 0, 0, "", "", false},
+		{`dnstap tls://127.0.0.1:6000 full {
+			tls
+			queue 100
+			flush 250ms
+		}`, "127.0.0.1:6000", true, 100, 250 * time.Millisecond, "", "", false},
+		{`dnstap tcp://127.0.0.1:6000 {
+			queue 5
+		}`, "127.0.0.1:6000", false, 5, 0, "", "", false},
+		{`dnstap dnstap.sock {
+			identity ns1.example.org
+			version 1.0
+		}`, "dnstap.sock", false, 0, 0, "ns1.example.org", "1.0", false},
+		// fails
+		{"dnstap tls://collector.example.org", "", false, 0, 0, "", "", true},
+		{`dnstap tcp://127.0.0.1:6000 {
+			tls
+		}`, "", false, 0, 0, "", "", true},
+		{`dnstap dnstap.sock {
+			queue 0
+		}`, "", false, 0, 0, "", "", true},
+		{`dnstap dnstap.sock {
+			flush
+		}`, "", false, 0, 0, "", "", true},
+		{`dnstap dnstap.sock {
+			flush 1s 2s
+		}`, "", false, 0, 0, "", "", true},
+		{`dnstap dnstap.sock {
+			buffer 10
+		}`, "", false, 0, 0, "", "", true},
+		{`dnstap dnstap.sock {
+			identity
+		}`, "", false, 0, 0, "", "", true},
+	}
+	for i, c := range tests {
+		cad := caddy.NewTestController("dns", c.file)
+		conf, err := parseConfig(&cad.Dispenser)
+		if c.fail {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+			continue
+		}
+		if conf.target != c.path || (conf.tls != nil) != c.tls || conf.queue != c.queue || conf.flush != c.flush ||
+			conf.identity != c.ident || conf.version != c.vers {
+			t.Errorf("Test %d: expected %+v, got %+v", i, c, conf)
+		}
+	}
+}
@@ -2,13 +2,18 @@
 package errors
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"regexp"
 	"runtime"
+	"runtime/debug"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -17,9 +22,22 @@ import (
 
 // errorHandler handles DNS errors (and errors from other plugin).
 type errorHandler struct {
-	Next    plugin.Handler
-	LogFile string
-	Log     *log.Logger
+	Next     plugin.Handler
+	LogFile  string
+	Log      *log.Logger
+	Format   string // formatText or formatJSON
+	patterns []*pattern
+
+	PanicFile string      // optional sink for panics with their full stack
+	PanicLog  *log.Logger // nil when there is no PanicFile
+}
+
+// pattern consolidates the errors that match it: instead of logging each of them, the number of
+// errors that matched in period is logged at the end of it.
+type pattern struct {
+	period  time.Duration
+	pattern *regexp.Regexp
+	count   uint32
 }
 
 // ServeDNS implements the plugin.Handler interface.
@@ -29,10 +47,25 @@ func (h errorHandler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns
 	rcode, err := plugin.NextOrFailure(h.Name(), h.Next, ctx, w, r)
 
 	if err != nil {
-		state := request.Request{W: w, Req: r}
-		errMsg := fmt.Sprintf("%s [ERROR %d %s %s] %v", time.Now().Format(timeFormat), rcode, state.Name(), state.Type(), err)
+		name, ok := metadata.Get(ctx, metadata.ErrorPlugin)
+		if !ok {
+			name = "unknown"
+		}
+		ErrorCount.WithLabelValues(name).Inc()
 
-		h.Log.Println(errMsg)
+		if h.consolidated(err) {
+			return rcode, err
+		}
+
+		state := request.Request{W: w, Req: r}
+		if h.Format == formatJSON {
+			h.Log.Println(jsonLine(errorEntry{
+				Time: time.Now().UTC().Format(time.RFC3339Nano), Level: "error",
+				Rcode: rcode, Qname: state.Name(), Qtype: state.Type(), Plugin: name, Error: err.Error(),
+			}))
+		} else {
+			h.Log.Printf("%s [ERROR %d %s %s] %v", time.Now().Format(timeFormat), rcode, state.Name(), state.Type(), err)
+		}
 	}
 
 	return rcode, err
@@ -40,18 +73,72 @@ func (h errorHandler) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns
 
 func (h errorHandler) Name() string { return "errors" }
 
+// consolidated returns true if err matches one of the patterns, and counts it. The first error
+// that matches a pattern schedules the log line for its period.
+func (h errorHandler) consolidated(err error) bool {
+	for _, p := range h.patterns {
+		if !p.pattern.MatchString(err.Error()) {
+			continue
+		}
+		if atomic.AddUint32(&p.count, 1) == 1 {
+			time.AfterFunc(p.period, func() { h.logConsolidated(p) })
+		}
+		return true
+	}
+	return false
+}
+
+func (h errorHandler) logConsolidated(p *pattern) {
+	n := atomic.SwapUint32(&p.count, 0)
+	if h.Format == formatJSON {
+		h.Log.Println(jsonLine(consolidatedEntry{
+			Time: time.Now().UTC().Format(time.RFC3339Nano), Level: "warning",
+			Count: n, Pattern: p.pattern.String(), Period: p.period.String(),
+		}))
+		return
+	}
+	h.Log.Printf("%s [WARNING] %d errors like '%s' occurred in last %s", time.Now().Format(timeFormat), n, p.pattern, p.period)
+}
+
 func (h errorHandler) recovery(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) {
 	rec := recover()
 	if rec == nil {
 		return
 	}
 
+	file, line := panicSource()
+	now := time.Now()
+	qname, qtype := r.Question[0].Name, dns.Type(r.Question[0].Qtype).String()
+
+	if h.Format == formatJSON {
+		e := panicEntry{
+			Time: now.UTC().Format(time.RFC3339Nano), Level: "panic",
+			Qname: qname, Qtype: qtype, Source: fmt.Sprintf("%s:%d", file, line), Panic: fmt.Sprint(rec),
+		}
+		h.Log.Println(jsonLine(e))
+		if h.PanicLog != nil {
+			e.Stack = string(debug.Stack())
+			h.PanicLog.Println(jsonLine(e))
+		}
+		return
+	}
+
+	// Currently we don't use the function name, since file:line is more conventional
+	panicMsg := fmt.Sprintf("%s [PANIC %s %s] %s:%d - %v", now.Format(timeFormat), qname, qtype, file, line, rec)
+	h.Log.Println(panicMsg)
+	if h.PanicLog != nil {
+		h.PanicLog.Printf("%s\n%s", panicMsg, debug.Stack())
+	}
+}
+
+// panicSource returns the file and line of the function that panicked.
+func panicSource() (string, int) {
 	// Obtain source of panic
 	// From: https://gist.github.com/swdunlop/9629168
 	var name, file string // function name, file name
 	var line int
 	var pc [16]uintptr
-	n := runtime.Callers(3, pc[:])
+	n := runtime.Callers(4, pc[:])
 	for _, pc := range pc[:n] {
 		fn := runtime.FuncForPC(pc)
 		if fn == nil {
@@ -70,10 +157,52 @@ func (h errorHandler) recovery(ctx context.Context, w dns.ResponseWriter, r *dns
 	if pkgPathPos > -1 && len(file) > pkgPathPos+len(delim) {
 		file = file[pkgPathPos+len(delim):]
 	}
+	return file, line
+}
 
-	panicMsg := fmt.Sprintf("%s [PANIC %s %s] %s:%d - %v", time.Now().Format(timeFormat), r.Question[0].Name, dns.Type(r.Question[0].Qtype), file, line, rec)
-	// Currently we don't use the function name, since file:line is more conventional
-	h.Log.Printf(panicMsg)
+// The entries of the JSON format.
+type (
+	errorEntry struct {
+		Time   string `json:"time"`
+		Level  string `json:"level"`
+		Rcode  int    `json:"rcode"`
+		Qname  string `json:"qname"`
+		Qtype  string `json:"qtype"`
+		Plugin string `json:"plugin"`
+		Error  string `json:"error"`
+	}
+
+	consolidatedEntry struct {
+		Time    string `json:"time"`
+		Level   string `json:"level"`
+		Count   uint32 `json:"count"`
+		Pattern string `json:"pattern"`
+		Period  string `json:"period"`
+	}
+
+	panicEntry struct {
+		Time   string `json:"time"`
+		Level  string `json:"level"`
+		Qname  string `json:"qname"`
+		Qtype  string `json:"qtype"`
+		Source string `json:"source"`
+		Panic  string `json:"panic"`
+		Stack  string `json:"stack,omitempty"`
+	}
+)
+
+func jsonLine(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// Can't happen, the entries only have strings and numbers.
+		return ""
+	}
+	return string(b)
 }
 
-const timeFormat = "02/Jan/2006:15:04:05 -0700"
+const (
+	timeFormat = "02/Jan/2006:15:04:05 -0700"
+
+	formatText = "text"
+	formatJSON = "json"
+)
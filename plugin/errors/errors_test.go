@@ -2,17 +2,23 @@ package errors
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
+	dto "github.com/prometheus/client_model/go"
 	"golang.org/x/net/context"
 )
 
@@ -71,3 +77,109 @@ func genErrorHandler(rcode int, err error) plugin.Handler {
 		return rcode, err
 	})
 }
+
+func TestErrorsJSON(t *testing.T) {
+	buf := bytes.Buffer{}
+	em := errorHandler{Log: log.New(&buf, "", 0), Format: formatJSON}
+	em.Next = namedHandler{name: "proxy", Handler: genErrorHandler(dns.RcodeServerFailure, errors.New("unreachable backend"))}
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	ctx := metadata.NewContext(context.TODO())
+	before := errorCount(t, "proxy")
+	em.ServeDNS(ctx, dnsrecorder.New(&test.ResponseWriter{}), req)
+
+	var e errorEntry
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("Expected JSON to be logged, got %q: %s", buf.String(), err)
+	}
+	if e.Level != "error" || e.Rcode != dns.RcodeServerFailure || e.Qname != "example.org." || e.Qtype != "A" || e.Plugin != "proxy" || e.Error != "unreachable backend" {
+		t.Errorf("Unexpected log entry: %+v", e)
+	}
+	if after := errorCount(t, "proxy"); after != before+1 {
+		t.Errorf("Expected error count for proxy to be %f, got %f", before+1, after)
+	}
+}
+
+func TestErrorsConsolidate(t *testing.T) {
+	buf := &syncBuffer{}
+	em := errorHandler{
+		Log:      log.New(buf, "", 0),
+		Next:     genErrorHandler(dns.RcodeServerFailure, errors.New("unreachable backend: no upstream host")),
+		patterns: []*pattern{{period: 50 * time.Millisecond, pattern: regexp.MustCompile("^unreachable backend")}},
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	for i := 0; i < 3; i++ {
+		em.ServeDNS(context.TODO(), dnsrecorder.New(&test.ResponseWriter{}), req)
+	}
+	if log := buf.String(); log != "" {
+		t.Errorf("Expected consolidated errors not to be logged at once, got %q", log)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	expected := "[WARNING] 3 errors like '^unreachable backend' occurred in last 50ms"
+	if log := buf.String(); !strings.Contains(log, expected) {
+		t.Errorf("Expected log %q, but got %q", expected, log)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	buf, panicBuf := bytes.Buffer{}, bytes.Buffer{}
+	em := errorHandler{
+		Log:      log.New(&buf, "", 0),
+		PanicLog: log.New(&panicBuf, "", 0),
+		Next: plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+			panic("boom")
+		}),
+	}
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.org.", dns.TypeA)
+	em.ServeDNS(context.TODO(), dnsrecorder.New(&test.ResponseWriter{}), req)
+
+	if log := buf.String(); !strings.Contains(log, "[PANIC example.org. A] ") || !strings.Contains(log, "errors_test.go:") || !strings.Contains(log, " - boom") {
+		t.Errorf("Expected panic to be logged, got %q", log)
+	}
+	if strings.Contains(buf.String(), "goroutine") {
+		t.Errorf("Expected no stack in the log, got %q", buf.String())
+	}
+	if log := panicBuf.String(); !strings.Contains(log, " - boom\ngoroutine ") {
+		t.Errorf("Expected panic with stack in the panic log, got %q", log)
+	}
+}
+
+// namedHandler gives a handler a name.
+type namedHandler struct {
+	plugin.Handler
+	name string
+}
+
+func (n namedHandler) Name() string { return n.name }
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	sync.Mutex
+	b bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.b.String()
+}
+
+func errorCount(t *testing.T, plugin string) float64 {
+	m := &dto.Metric{}
+	if err := ErrorCount.WithLabelValues(plugin).Write(m); err != nil {
+		t.Fatalf("Failed to get error count: %s", err)
+	}
+	return m.GetCounter().GetValue()
+}
@@ -0,0 +1,19 @@
+package errors
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrorCount counts the errors returned by the plugins, by the plugin that returned the error.
+var ErrorCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "errors",
+	Name:      "total",
+	Help:      "Counter of errors returned by plugins.",
+}, []string{"plugin"})
+
+func init() {
+	prometheus.MustRegister(ErrorCount)
+}
@@ -2,8 +2,11 @@ package errors
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"regexp"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -25,17 +28,49 @@ func setup(c *caddy.Controller) error {
 	}
 
 	handler.Log = log.New(os.Stdout, "", 0)
+	if handler.LogFile == "stderr" {
+		handler.Log = log.New(os.Stderr, "", 0)
+	}
+
+	// Open the panic file for writing when the server starts
+	var panicFile *os.File
+	c.OnStartup(func() error {
+		if handler.PanicFile == "" {
+			return nil
+		}
+		var w io.Writer
+		switch handler.PanicFile {
+		case "stdout":
+			w = os.Stdout
+		case "stderr":
+			w = os.Stderr
+		default:
+			f, err := os.OpenFile(handler.PanicFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err != nil {
+				return plugin.Error("errors", err)
+			}
+			panicFile, w = f, f
+		}
+		handler.PanicLog = log.New(w, "", 0)
+		return nil
+	})
+	c.OnShutdown(func() error {
+		if panicFile == nil {
+			return nil
+		}
+		return panicFile.Close()
+	})
 
 	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
 		handler.Next = next
-		return handler
+		return &handler
 	})
 
 	return nil
 }
 
 func errorsParse(c *caddy.Controller) (errorHandler, error) {
-	handler := errorHandler{}
+	handler := errorHandler{Format: formatText}
 
 	for c.Next() {
 		args := c.RemainingArgs()
@@ -43,13 +78,50 @@ func errorsParse(c *caddy.Controller) (errorHandler, error) {
 		case 0:
 			handler.LogFile = "stdout"
 		case 1:
-			if args[0] != "stdout" {
+			if args[0] != "stdout" && args[0] != "stderr" {
 				return handler, fmt.Errorf("invalid log file: %s", args[0])
 			}
 			handler.LogFile = args[0]
 		default:
 			return handler, c.ArgErr()
 		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "consolidate":
+				args := c.RemainingArgs()
+				if len(args) != 2 {
+					return handler, c.ArgErr()
+				}
+				d, err := time.ParseDuration(args[0])
+				if err != nil {
+					return handler, err
+				}
+				if d <= 0 {
+					return handler, c.Errf("consolidate period must be positive: %s", d)
+				}
+				re, err := regexp.Compile(args[1])
+				if err != nil {
+					return handler, err
+				}
+				handler.patterns = append(handler.patterns, &pattern{period: d, pattern: re})
+			case "format":
+				if !c.NextArg() {
+					return handler, c.ArgErr()
+				}
+				if c.Val() != formatText && c.Val() != formatJSON {
+					return handler, c.Errf("unknown format '%s'", c.Val())
+				}
+				handler.Format = c.Val()
+			case "panic":
+				if !c.NextArg() {
+					return handler, c.ArgErr()
+				}
+				handler.PanicFile = c.Val()
+			default:
+				return handler, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
 	}
 	return handler, nil
 }
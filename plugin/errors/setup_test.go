@@ -1,7 +1,9 @@
 package errors
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/mholt/caddy"
 )
@@ -14,9 +16,11 @@ func TestErrorsParse(t *testing.T) {
 	}{
 		{`errors`, false, errorHandler{
 			LogFile: "stdout",
+			Format:  formatText,
 		}},
 		{`errors stdout`, false, errorHandler{
 			LogFile: "stdout",
+			Format:  formatText,
 		}},
 		{`errors errors.txt`, true, errorHandler{
 			LogFile: "",
@@ -27,6 +31,45 @@ func TestErrorsParse(t *testing.T) {
 		{`errors { log visible }`, true, errorHandler{
 			LogFile: "stdout",
 		}},
+		{`errors stderr {
+			format json
+			panic /var/log/coredns/panic.log
+		}`, false, errorHandler{
+			LogFile:   "stderr",
+			Format:    formatJSON,
+			PanicFile: "/var/log/coredns/panic.log",
+		}},
+		{`errors {
+			consolidate 5m ".* i/o timeout$"
+			consolidate 30s "^unreachable backend"
+		}`, false, errorHandler{
+			LogFile: "stdout",
+			Format:  formatText,
+			patterns: []*pattern{
+				{period: 5 * time.Minute, pattern: regexp.MustCompile(".* i/o timeout$")},
+				{period: 30 * time.Second, pattern: regexp.MustCompile("^unreachable backend")},
+			},
+		}},
+		{`errors {
+			consolidate 5m
+		}`, true, errorHandler{
+			LogFile: "stdout",
+		}},
+		{`errors {
+			consolidate -1m error
+		}`, true, errorHandler{
+			LogFile: "stdout",
+		}},
+		{`errors {
+			consolidate 1m "("
+		}`, true, errorHandler{
+			LogFile: "stdout",
+		}},
+		{`errors {
+			format xml
+		}`, true, errorHandler{
+			LogFile: "stdout",
+		}},
 	}
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.inputErrorsRules)
@@ -41,5 +84,27 @@ func TestErrorsParse(t *testing.T) {
 			t.Errorf("Test %d expected LogFile to be %s, but got %s",
 				i, test.expectedErrorHandler.LogFile, actualErrorsRule.LogFile)
 		}
+		if test.shouldErr {
+			continue
+		}
+		if actualErrorsRule.Format != test.expectedErrorHandler.Format {
+			t.Errorf("Test %d expected Format to be %s, but got %s",
+				i, test.expectedErrorHandler.Format, actualErrorsRule.Format)
+		}
+		if actualErrorsRule.PanicFile != test.expectedErrorHandler.PanicFile {
+			t.Errorf("Test %d expected PanicFile to be %s, but got %s",
+				i, test.expectedErrorHandler.PanicFile, actualErrorsRule.PanicFile)
+		}
+		if len(actualErrorsRule.patterns) != len(test.expectedErrorHandler.patterns) {
+			t.Fatalf("Test %d expected %d patterns, but got %d",
+				i, len(test.expectedErrorHandler.patterns), len(actualErrorsRule.patterns))
+		}
+		for j, p := range actualErrorsRule.patterns {
+			expected := test.expectedErrorHandler.patterns[j]
+			if p.period != expected.period || p.pattern.String() != expected.pattern.String() {
+				t.Errorf("Test %d expected pattern %d to be %s %s, but got %s %s",
+					i, j, expected.period, expected.pattern, p.period, p.pattern)
+			}
+		}
 	}
 }
@@ -4,12 +4,15 @@ import (
 	"github.com/coredns/coredns/plugin/kubernetes"
 
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 )
 
 type APIConnFederationTest struct{}
 
-func (APIConnFederationTest) Run()        { return }
-func (APIConnFederationTest) Stop() error { return nil }
+func (APIConnFederationTest) Run()                            { return }
+func (APIConnFederationTest) Stop() error                     { return nil }
+func (APIConnFederationTest) HasSynced() bool                 { return true }
+func (APIConnFederationTest) IngressList() []*v1beta1.Ingress { return nil }
 
 func (APIConnFederationTest) PodIndex(string) []interface{} {
 	a := make([]interface{}, 1)
@@ -0,0 +1,38 @@
+package file
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/dnstap/test"
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	mwtest "github.com/coredns/coredns/plugin/test"
+
+	tap "github.com/dnstap/golang-dnstap"
+	"golang.org/x/net/context"
+)
+
+func TestLookupDnstap(t *testing.T) {
+	zone, err := Parse(strings.NewReader(dbMiekNL), testzone, "stdin", 0)
+	if err != nil {
+		t.Fatalf("expect no error when reading zone, got %q", err)
+	}
+
+	fm := File{Next: mwtest.ErrorHandler(), Zones: Zones{Z: map[string]*Zone{testzone: zone}, Names: []string{testzone}}}
+	ctx := test.Context{Context: context.TODO()}
+
+	rec := dnsrecorder.New(&mwtest.ResponseWriter{})
+	if _, err := fm.ServeDNS(&ctx, rec, dnsTestCases[0].Msg()); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(ctx.Trap) != 2 {
+		t.Fatalf("expected 2 dnstap messages, got %d", len(ctx.Trap))
+	}
+	if typ := ctx.Trap[0].GetType(); typ != tap.Message_AUTH_QUERY {
+		t.Errorf("expected AUTH_QUERY, got %s", typ)
+	}
+	if typ := ctx.Trap[1].GetType(); typ != tap.Message_AUTH_RESPONSE {
+		t.Errorf("expected AUTH_RESPONSE, got %s", typ)
+	}
+}
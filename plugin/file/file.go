@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/dnstap"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
@@ -98,8 +99,9 @@ func (f File) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (i
 
 	state.SizeAndDo(m)
 	m, _ = state.Scrub(m)
+	taperr := dnstap.TapAuth(ctx, state, m)
 	w.WriteMsg(m)
-	return dns.RcodeSuccess, nil
+	return dns.RcodeSuccess, taperr
 }
 
 // Name implements the Handler interface.
@@ -117,9 +119,9 @@ func Parse(f io.Reader, origin, fileName string, serial int64) (*Zone, error) {
 			return nil, x.Error
 		}
 
-		if !seenSOA && serial >= 0 {
+		if !seenSOA {
 			if s, ok := x.RR.(*dns.SOA); ok {
-				if s.Serial == uint32(serial) { // same zone
+				if serial >= 0 && s.Serial == uint32(serial) { // same zone
 					return nil, fmt.Errorf("no change in serial: %d", serial)
 				}
 				seenSOA = true
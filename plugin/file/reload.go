@@ -28,28 +28,7 @@ func (z *Zone) Reload() error {
 			select {
 			case event := <-watcher.Events:
 				if path.Clean(event.Name) == z.file {
-
-					reader, err := os.Open(z.file)
-					if err != nil {
-						log.Printf("[ERROR] Failed to open `%s' for `%s': %v", z.file, z.origin, err)
-						continue
-					}
-
-					serial := z.SOASerialIfDefined()
-					zone, err := Parse(reader, z.origin, z.file, serial)
-					if err != nil {
-						log.Printf("[WARNING] Parsing zone `%s': %v", z.origin, err)
-						continue
-					}
-
-					// copy elements we need
-					z.reloadMu.Lock()
-					z.Apex = zone.Apex
-					z.Tree = zone.Tree
-					z.reloadMu.Unlock()
-
-					log.Printf("[INFO] Successfully reloaded zone `%s'", z.origin)
-					z.Notify()
+					z.reloadFile(false)
 				}
 			case <-z.ReloadShutdown:
 				watcher.Close()
@@ -60,6 +39,46 @@ func (z *Zone) Reload() error {
 	return nil
 }
 
+// ReloadNow reloads the zone at once: a secondary zone is transferred in from its masters again,
+// other zones are read from their file.
+func (z *Zone) ReloadNow() error {
+	if len(z.TransferFrom) > 0 {
+		return z.TransferIn()
+	}
+	return z.reloadFile(true)
+}
+
+// reloadFile reads the zone from its file and sets it live. The current zone data is kept if
+// that fails. Unless forced, a zone with an unchanged SOA serial is not reloaded.
+func (z *Zone) reloadFile(force bool) error {
+	reader, err := os.Open(z.file)
+	if err != nil {
+		log.Printf("[ERROR] Failed to open `%s' for `%s': %v", z.file, z.origin, err)
+		return err
+	}
+	defer reader.Close()
+
+	serial := int64(-1)
+	if !force {
+		serial = z.SOASerialIfDefined()
+	}
+	zone, err := Parse(reader, z.origin, z.file, serial)
+	if err != nil {
+		log.Printf("[WARNING] Parsing zone `%s': %v", z.origin, err)
+		return err
+	}
+
+	// copy elements we need
+	z.reloadMu.Lock()
+	z.Apex = zone.Apex
+	z.Tree = zone.Tree
+	z.reloadMu.Unlock()
+
+	log.Printf("[INFO] Successfully reloaded zone `%s'", z.origin)
+	z.Notify()
+	return nil
+}
+
 // SOASerialIfDefined returns the SOA's serial if the zone has a SOA record in the Apex, or
 // -1 otherwise.
 func (z *Zone) SOASerialIfDefined() int64 {
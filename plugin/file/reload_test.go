@@ -80,3 +80,37 @@ const reloadZone2Test = `miek.nl.		1627	IN	SOA	linode.atoom.net. miek.miek.nl. 1
 miek.nl.		1627	IN	NS	ext.ns.whyscream.net.
 miek.nl.		1627	IN	NS	omval.tednet.nl.
 `
+
+func TestZoneReloadNow(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	fileName, rm, err := test.TempFile(".", reloadZoneTest)
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm()
+	reader, err := os.Open(fileName)
+	if err != nil {
+		t.Fatalf("failed to open zone: %s", err)
+	}
+	z, err := Parse(reader, "miek.nl", fileName, 0)
+	if err != nil {
+		t.Fatalf("failed to parse zone: %s", err)
+	}
+	z.NoReload = true
+
+	if err := ioutil.WriteFile(fileName, []byte(reloadZone2Test), 0644); err != nil {
+		t.Fatalf("failed to write new zone data: %s", err)
+	}
+	if len(z.All()) != 5 {
+		t.Fatalf("expected 5 RRs, got %d", len(z.All()))
+	}
+
+	// Without a watcher the new file is only read on ReloadNow.
+	if err := z.ReloadNow(); err != nil {
+		t.Fatalf("failed to reload zone: %s", err)
+	}
+	if len(z.All()) != 3 {
+		t.Fatalf("expected 3 RRs, got %d", len(z.All()))
+	}
+}
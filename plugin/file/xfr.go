@@ -32,9 +32,15 @@ func (x Xfr) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (in
 	}
 
 	ch := make(chan *dns.Envelope)
-	defer close(ch)
+	errc := make(chan error, 1)
 	tr := new(dns.Transfer)
-	go tr.Out(w, r, ch)
+	go func() {
+		err := tr.Out(w, r, ch)
+		for range ch {
+			// Out stopped on a write error; drain ch so the loop below doesn't block.
+		}
+		errc <- err
+	}()
 
 	j, l := 0, 0
 	records = append(records, records[0]) // add closing SOA to the end
@@ -50,6 +56,13 @@ func (x Xfr) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (in
 	if j < len(records) {
 		ch <- &dns.Envelope{RR: records[j:]}
 	}
+	close(ch)
+
+	// Wait until the last message is written, writers that don't outlive this call (gRPC streams)
+	// would lose it otherwise.
+	if err := <-errc; err != nil {
+		log.Printf("[ERROR] Outgoing transfer of zone %s to %s failed: %s", x.origin, state.IP(), err)
+	}
 
 	w.Hijack()
 	// w.Close() // Client closes connection
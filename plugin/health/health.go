@@ -7,6 +7,8 @@ import (
 	"net"
 	"net/http"
 	"sync"
+
+	"github.com/coredns/coredns/core/dnsserver"
 )
 
 var once sync.Once
@@ -21,6 +23,10 @@ type health struct {
 	h []Healther
 	sync.RWMutex
 	ok bool // ok is the global boolean indicating an all healthy plugin stack
+
+	// The configs of all server blocks, their plugins that implement Readiness are asked if they
+	// are ready.
+	configs []*dnsserver.Config
 }
 
 func (h *health) Startup() error {
@@ -28,6 +34,8 @@ func (h *health) Startup() error {
 		h.Addr = defAddr
 	}
 
+	setReadyConfigs(h.configs)
+
 	once.Do(func() {
 		ln, err := net.Listen("tcp", h.Addr)
 		if err != nil {
@@ -47,6 +55,7 @@ func (h *health) Startup() error {
 			}
 			w.WriteHeader(http.StatusServiceUnavailable)
 		})
+		h.mux.HandleFunc(readyPath, ready)
 
 		go func() {
 			http.Serve(h.ln, h.mux)
@@ -63,7 +72,8 @@ func (h *health) Shutdown() error {
 }
 
 const (
-	ok      = "OK"
-	defAddr = ":8080"
-	path    = "/health"
+	ok        = "OK"
+	defAddr   = ":8080"
+	path      = "/health"
+	readyPath = "/ready"
 )
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin/secondary"
+)
+
+// Readiness is implemented by plugins that are not ready to serve queries right after startup,
+// because they first need to load their data, like kubernetes and secondary. Unlike Healther it
+// is not polled, Ready is called on every request for the readiness path, so it should return
+// quickly.
+type Readiness interface {
+	// Ready returns true when the plugin is ready to serve queries.
+	Ready() bool
+}
+
+// pluginStatus is the readiness of a plugin in a server block.
+type pluginStatus struct {
+	Server string `json:"server"`
+	Plugin string `json:"plugin"`
+	Ready  bool   `json:"ready"`
+}
+
+// readyStatus is the body of the readiness response.
+type readyStatus struct {
+	Ready   bool           `json:"ready"`
+	Plugins []pluginStatus `json:"plugins"`
+}
+
+// The configs of the running instance. The listener is shared by all instances, so it reads
+// these instead of the configs of the instance that started it; a reload replaces them.
+var (
+	readyMu      sync.RWMutex
+	readyConfigs []*dnsserver.Config
+)
+
+func setReadyConfigs(configs []*dnsserver.Config) {
+	readyMu.Lock()
+	readyConfigs = configs
+	readyMu.Unlock()
+}
+
+// readiness returns the readiness of the plugins in configs that implement Readiness.
+func readiness(configs []*dnsserver.Config) readyStatus {
+	st := readyStatus{Ready: true, Plugins: []pluginStatus{}}
+	for _, c := range configs {
+		server := c.Transport + "://" + c.Zone + ":" + c.Port
+		for _, h := range c.Handlers() {
+			r, ok := h.(Readiness)
+			if !ok {
+				continue
+			}
+			name := h.Name()
+			// Secondary embeds File, including its name.
+			if _, ok := h.(secondary.Secondary); ok {
+				name = "secondary"
+			}
+			st.add(server, name, r)
+		}
+	}
+	return st
+}
+
+func (st *readyStatus) add(server, plugin string, r Readiness) {
+	ok := r.Ready()
+	st.Plugins = append(st.Plugins, pluginStatus{Server: server, Plugin: plugin, Ready: ok})
+	st.Ready = st.Ready && ok
+}
+
+// ready responds with 200 when all plugins are ready and with 503 otherwise. The body lists the
+// readiness of each plugin.
+func ready(w http.ResponseWriter, r *http.Request) {
+	readyMu.RLock()
+	configs := readyConfigs
+	readyMu.RUnlock()
+
+	st := readiness(configs)
+
+	w.Header().Set("Content-Type", "application/json")
+	if st.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(st)
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/secondary"
+)
+
+type fakeReady bool
+
+func (r fakeReady) Ready() bool { return bool(r) }
+
+func TestReadyStatus(t *testing.T) {
+	st := readyStatus{Ready: true}
+	st.add("dns://example.org.:53", "kubernetes", fakeReady(true))
+	if !st.Ready {
+		t.Errorf("Expected to be ready with all plugins ready")
+	}
+	st.add("dns://example.org.:53", "secondary", fakeReady(false))
+	st.add("dns://example.net.:53", "kubernetes", fakeReady(true))
+	if st.Ready {
+		t.Errorf("Expected not to be ready with a plugin that is not ready")
+	}
+
+	expected := []pluginStatus{
+		{"dns://example.org.:53", "kubernetes", true},
+		{"dns://example.org.:53", "secondary", false},
+		{"dns://example.net.:53", "kubernetes", true},
+	}
+	if len(st.Plugins) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, st.Plugins)
+	}
+	for i := range expected {
+		if st.Plugins[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], st.Plugins[i])
+		}
+	}
+}
+
+func TestReadinessSecondary(t *testing.T) {
+	z := file.NewZone("example.org.", "stdin")
+	z.TransferFrom = []string{"10.0.0.1:53"}
+	sec := secondary.Secondary{File: file.File{Zones: file.Zones{Z: map[string]*file.Zone{"example.org.": z}, Names: []string{"example.org."}}}}
+
+	c := &dnsserver.Config{Zone: "example.org.", Port: "53", Transport: "dns"}
+	c.AddPlugin(func(next plugin.Handler) plugin.Handler { return sec })
+	if _, err := dnsserver.NewServer("127.0.0.1:0", []*dnsserver.Config{c}); err != nil {
+		t.Fatalf("Failed to make server: %s", err)
+	}
+
+	// Secondary has the name of file, it is reported as secondary.
+	st := readiness([]*dnsserver.Config{c})
+	expected := pluginStatus{"dns://example.org.:53", "secondary", false}
+	if st.Ready || len(st.Plugins) != 1 || st.Plugins[0] != expected {
+		t.Errorf("Expected %v, got %+v", expected, st)
+	}
+}
+
+func TestReady(t *testing.T) {
+	setReadyConfigs(nil)
+
+	rec := httptest.NewRecorder()
+	ready(rec, httptest.NewRequest("GET", readyPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected status %d without plugins, got %d", http.StatusOK, rec.Code)
+	}
+	st := readyStatus{}
+	if err := json.NewDecoder(rec.Body).Decode(&st); err != nil {
+		t.Fatalf("Could not decode body: %s", err)
+	}
+	if !st.Ready || len(st.Plugins) != 0 {
+		t.Errorf("Expected to be ready without plugins, got %+v", st)
+	}
+}
@@ -23,7 +23,7 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("health", err)
 	}
 
-	h := &health{Addr: addr}
+	h := &health{Addr: addr, configs: dnsserver.Configs(c)}
 
 	c.OnStartup(func() error {
 		for he := range healthers {
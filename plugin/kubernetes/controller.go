@@ -11,6 +11,7 @@ import (
 	"k8s.io/client-go/1.5/pkg/api"
 	unversionedapi "k8s.io/client-go/1.5/pkg/api/unversioned"
 	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 	"k8s.io/client-go/1.5/pkg/labels"
 	"k8s.io/client-go/1.5/pkg/runtime"
 	"k8s.io/client-go/1.5/pkg/watch"
@@ -40,9 +41,13 @@ type dnsController interface {
 	ServiceList() []*api.Service
 	PodIndex(string) []interface{}
 	EndpointsList() api.EndpointsList
+	IngressList() []*v1beta1.Ingress
 
 	GetNodeByName(string) (api.Node, error)
 
+	// HasSynced returns true once the initial lists of all watched objects have been received.
+	HasSynced() bool
+
 	Run()
 	Stop() error
 }
@@ -52,15 +57,19 @@ type dnsControl struct {
 
 	selector *labels.Selector
 
-	svcController *cache.Controller
-	podController *cache.Controller
-	nsController  *cache.Controller
-	epController  *cache.Controller
+	svcController  *cache.Controller
+	podController  *cache.Controller
+	nsController   *cache.Controller
+	epController   *cache.Controller
+	nodeController *cache.Controller
+	ingController  *cache.Controller
 
-	svcLister cache.StoreToServiceLister
-	podLister cache.StoreToPodLister
-	nsLister  storeToNamespaceLister
-	epLister  cache.StoreToEndpointsLister
+	svcLister  cache.StoreToServiceLister
+	podLister  cache.StoreToPodLister
+	nsLister   storeToNamespaceLister
+	epLister   cache.StoreToEndpointsLister
+	nodeLister cache.StoreToNodeLister
+	ingLister  cache.Store
 
 	// stopLock is used to enforce only a single call to Stop is active.
 	// Needed because we allow stopping through an http endpoint and
@@ -71,8 +80,10 @@ type dnsControl struct {
 }
 
 type dnsControlOpts struct {
-	initPodCache bool
-	resyncPeriod time.Duration
+	initPodCache  bool
+	initNodeCache bool
+	initIngCache  bool
+	resyncPeriod  time.Duration
 	// Label handling.
 	labelSelector *unversionedapi.LabelSelector
 	selector      *labels.Selector
@@ -126,6 +137,28 @@ func newdnsController(kubeClient *kubernetes.Clientset, opts dnsControlOpts) *dn
 		opts.resyncPeriod,
 		cache.ResourceEventHandlerFuncs{})
 
+	if opts.initNodeCache {
+		dns.nodeLister.Store, dns.nodeController = cache.NewInformer(
+			&cache.ListWatch{
+				ListFunc:  nodeListFunc(dns.client),
+				WatchFunc: nodeWatchFunc(dns.client),
+			},
+			&api.Node{},
+			opts.resyncPeriod,
+			cache.ResourceEventHandlerFuncs{})
+	}
+
+	if opts.initIngCache {
+		dns.ingLister, dns.ingController = cache.NewInformer(
+			&cache.ListWatch{
+				ListFunc:  ingressListFunc(dns.client, namespace, dns.selector),
+				WatchFunc: ingressWatchFunc(dns.client, namespace, dns.selector),
+			},
+			&v1beta1.Ingress{},
+			opts.resyncPeriod,
+			cache.ResourceEventHandlerFuncs{})
+	}
+
 	return &dns
 }
 
@@ -214,6 +247,14 @@ func v1ToAPIFilter(in watch.Event) (out watch.Event, keep bool) {
 			return in, true
 		}
 		return watch.Event{Type: in.Type, Object: &apiObj}, true
+	case *v1.Node:
+		var apiObj api.Node
+		err := v1.Convert_v1_Node_To_api_Node(v1Obj, &apiObj, nil)
+		if err != nil {
+			log.Printf("[ERROR] Could not convert v1.Node: %s", err)
+			return in, true
+		}
+		return watch.Event{Type: in.Type, Object: &apiObj}, true
 	}
 
 	log.Printf("[WARN] Unhandled v1 type in event: %v", in)
@@ -310,6 +351,54 @@ func endpointsWatchFunc(c *kubernetes.Clientset, ns string, s *labels.Selector)
 	}
 }
 
+func nodeListFunc(c *kubernetes.Clientset) func(api.ListOptions) (runtime.Object, error) {
+	return func(opts api.ListOptions) (runtime.Object, error) {
+		listV1, err := c.Core().Nodes().List(opts)
+		if err != nil {
+			return nil, err
+		}
+		var listAPI api.NodeList
+		err = v1.Convert_v1_NodeList_To_api_NodeList(listV1, &listAPI, nil)
+		if err != nil {
+			return nil, err
+		}
+		return &listAPI, err
+	}
+}
+
+func nodeWatchFunc(c *kubernetes.Clientset) func(options api.ListOptions) (watch.Interface, error) {
+	return func(options api.ListOptions) (watch.Interface, error) {
+		w, err := c.Core().Nodes().Watch(options)
+		if err != nil {
+			return nil, err
+		}
+		return watch.Filter(w, v1ToAPIFilter), nil
+	}
+}
+
+// ingressListFunc lists ingresses. These are kept as v1beta1.Ingress, because converting them to
+// the internal API needs a conversion scope.
+func ingressListFunc(c *kubernetes.Clientset, ns string, s *labels.Selector) func(api.ListOptions) (runtime.Object, error) {
+	return func(opts api.ListOptions) (runtime.Object, error) {
+		if s != nil {
+			opts.LabelSelector = *s
+		}
+		return c.Extensions().Ingresses(ns).List(opts)
+	}
+}
+
+func ingressWatchFunc(c *kubernetes.Clientset, ns string, s *labels.Selector) func(options api.ListOptions) (watch.Interface, error) {
+	return func(options api.ListOptions) (watch.Interface, error) {
+		if s != nil {
+			options.LabelSelector = *s
+		}
+		return c.Extensions().Ingresses(ns).Watch(options)
+	}
+}
+
+// HasSynced implements dnsController.
+func (dns *dnsControl) HasSynced() bool { return dns.controllersInSync() }
+
 func (dns *dnsControl) controllersInSync() bool {
 	hs := dns.svcController.HasSynced() &&
 		dns.nsController.HasSynced() &&
@@ -318,6 +407,12 @@ func (dns *dnsControl) controllersInSync() bool {
 	if dns.podController != nil {
 		hs = hs && dns.podController.HasSynced()
 	}
+	if dns.nodeController != nil {
+		hs = hs && dns.nodeController.HasSynced()
+	}
+	if dns.ingController != nil {
+		hs = hs && dns.ingController.HasSynced()
+	}
 
 	return hs
 }
@@ -346,6 +441,12 @@ func (dns *dnsControl) Run() {
 	if dns.podController != nil {
 		go dns.podController.Run(dns.stopCh)
 	}
+	if dns.nodeController != nil {
+		go dns.nodeController.Run(dns.stopCh)
+	}
+	if dns.ingController != nil {
+		go dns.ingController.Run(dns.stopCh)
+	}
 	<-dns.stopCh
 }
 
@@ -385,7 +486,38 @@ func (dns *dnsControl) EndpointsList() api.EndpointsList {
 	return epl
 }
 
+func (dns *dnsControl) IngressList() []*v1beta1.Ingress {
+	if dns.ingController == nil {
+		return nil
+	}
+
+	var ings []*v1beta1.Ingress
+	for _, o := range dns.ingLister.List() {
+		if i, ok := o.(*v1beta1.Ingress); ok {
+			ings = append(ings, i)
+		}
+	}
+	return ings
+}
+
+// GetNodeByName returns the node with the name name. When the node cache is enabled the node is
+// retrieved from the cache, otherwise the API server is queried.
 func (dns *dnsControl) GetNodeByName(name string) (api.Node, error) {
+	if dns.nodeController != nil {
+		o, exists, err := dns.nodeLister.Store.GetByKey(name)
+		if err != nil {
+			return api.Node{}, err
+		}
+		if !exists {
+			return api.Node{}, fmt.Errorf("node %q not found", name)
+		}
+		n, ok := o.(*api.Node)
+		if !ok {
+			return api.Node{}, errors.New("obj was not an *api.Node")
+		}
+		return *n, nil
+	}
+
 	v1node, err := dns.client.Core().Nodes().Get(name)
 	if err != nil {
 		return api.Node{}, err
@@ -0,0 +1,101 @@
+package kubernetes
+
+import (
+	"strings"
+
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+)
+
+// isExternal returns true if zone is one of the external zones.
+func (k *Kubernetes) isExternal(zone string) bool {
+	for _, z := range k.externalZones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+// findExternal returns the load balancer addresses for names in an external zone. Two kinds of
+// names are supported:
+//
+// * service.namespace.zone, for services of type LoadBalancer.
+// * the hosts of ingress rules that fall in the zone.
+func (k *Kubernetes) findExternal(state request.Request) (services []msg.Service, err error) {
+	base, _ := dnsutil.TrimZone(state.Name(), state.Zone)
+	segs := dns.SplitDomainName(base)
+	if len(segs) == 0 {
+		return nil, nil
+	}
+
+	key := msg.Path(state.Name(), "coredns")
+	err = errNoItems
+
+	if len(segs) == 2 {
+		for _, svc := range k.APIConn.ServiceList() {
+			if svc.Spec.Type != api.ServiceTypeLoadBalancer {
+				continue
+			}
+			if !strings.EqualFold(segs[0], svc.Name) || !strings.EqualFold(segs[1], svc.Namespace) {
+				continue
+			}
+			if !k.namespaceExposed(svc.Namespace) {
+				continue
+			}
+
+			err = nil
+
+			services = append(services, k.loadBalancerServices(key, svc.Status.LoadBalancer.Ingress)...)
+		}
+	}
+
+	for _, ing := range k.APIConn.IngressList() {
+		if !k.namespaceExposed(ing.Namespace) {
+			continue
+		}
+		for _, rule := range ing.Spec.Rules {
+			if rule.Host == "" || dns.Fqdn(strings.ToLower(rule.Host)) != state.Name() {
+				continue
+			}
+
+			err = nil
+
+			services = append(services, k.loadBalancerServices(key, ingressPoints(ing.Status.LoadBalancer.Ingress))...)
+			break
+		}
+	}
+
+	return services, err
+}
+
+// loadBalancerServices returns a service for each of the ingress points. Ingress points with a
+// hostname are returned as CNAMEs.
+func (k *Kubernetes) loadBalancerServices(key string, points []api.LoadBalancerIngress) []msg.Service {
+	var services []msg.Service
+	for _, i := range points {
+		host := i.IP
+		if host == "" {
+			host = i.Hostname
+		}
+		if host == "" {
+			continue
+		}
+		services = append(services, msg.Service{Host: host, TTL: k.ttl, Key: key})
+	}
+	return services
+}
+
+// ingressPoints converts the v1 ingress points of an Ingress to the internal API.
+func ingressPoints(points []v1.LoadBalancerIngress) []api.LoadBalancerIngress {
+	p := make([]api.LoadBalancerIngress, len(points))
+	for i := range points {
+		p[i] = api.LoadBalancerIngress{IP: points[i].IP, Hostname: points[i].Hostname}
+	}
+	return p
+}
@@ -0,0 +1,167 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+var dnsExternalCases = []test.Case{
+	// LoadBalancer service
+	{
+		Qname: "lb1.testns.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("lb1.testns.example.org.	5	IN	A	192.0.2.1"),
+		},
+	},
+	// LoadBalancer service with a hostname
+	{
+		Qname: "lb2.testns.example.org.", Qtype: dns.TypeCNAME,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.CNAME("lb2.testns.example.org.	5	IN	CNAME	lb.cloud.example.net."),
+		},
+	},
+	// ClusterIP services are not published
+	{
+		Qname: "svc1.testns.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.org.	300	IN	SOA	ns.dns.example.org. hostmaster.example.org. 1502308051 7200 1800 86400 60"),
+		},
+	},
+	// Ingress host
+	{
+		Qname: "app.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("app.example.org.	5	IN	A	192.0.2.10"),
+		},
+	},
+	// Ingress host, no AAAA
+	{
+		Qname: "app.example.org.", Qtype: dns.TypeAAAA,
+		Rcode: dns.RcodeSuccess,
+		Ns: []dns.RR{
+			test.SOA("example.org.	300	IN	SOA	ns.dns.example.org. hostmaster.example.org. 1502308051 7200 1800 86400 60"),
+		},
+	},
+	// Unknown name
+	{
+		Qname: "nothere.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.org.	300	IN	SOA	ns.dns.example.org. hostmaster.example.org. 1502308051 7200 1800 86400 60"),
+		},
+	},
+	// The cluster zone still works
+	{
+		Qname: "svc1.testns.svc.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.cluster.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+}
+
+func TestServeDNSExternal(t *testing.T) {
+	k := New([]string{"cluster.local.", "example.org."})
+	k.externalZones = []string{"example.org."}
+	k.APIConn = &APIConnExternalTest{}
+	ctx := context.TODO()
+
+	for i, tc := range dnsExternalCases {
+		r := tc.Msg()
+
+		w := dnsrecorder.New(&test.ResponseWriter{})
+
+		_, err := k.ServeDNS(ctx, w, r)
+		if err != tc.Error {
+			t.Errorf("Test %d expected no error, got %v", i, err)
+			return
+		}
+
+		resp := w.Msg
+		if resp == nil {
+			t.Fatalf("Test %d, got nil message and no error for %q", i, r.Question[0].Name)
+		}
+
+		test.SortAndCheck(t, resp, tc)
+	}
+}
+
+type APIConnExternalTest struct{}
+
+func (APIConnExternalTest) Run()                                        { return }
+func (APIConnExternalTest) Stop() error                                 { return nil }
+func (APIConnExternalTest) HasSynced() bool                             { return true }
+func (APIConnExternalTest) PodIndex(string) []interface{}               { return nil }
+func (APIConnExternalTest) EndpointsList() api.EndpointsList            { return api.EndpointsList{} }
+func (APIConnExternalTest) GetNodeByName(name string) (api.Node, error) { return api.Node{}, nil }
+
+func (APIConnExternalTest) ServiceList() []*api.Service {
+	return []*api.Service{
+		{
+			ObjectMeta: api.ObjectMeta{Name: "svc1", Namespace: "testns"},
+			Spec:       api.ServiceSpec{ClusterIP: "10.0.0.1", Ports: []api.ServicePort{{Name: "http", Protocol: "tcp", Port: 80}}},
+		},
+		{
+			ObjectMeta: api.ObjectMeta{Name: "lb1", Namespace: "testns"},
+			Spec:       api.ServiceSpec{Type: api.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.2"},
+			Status:     api.ServiceStatus{LoadBalancer: api.LoadBalancerStatus{Ingress: []api.LoadBalancerIngress{{IP: "192.0.2.1"}}}},
+		},
+		{
+			ObjectMeta: api.ObjectMeta{Name: "lb2", Namespace: "testns"},
+			Spec:       api.ServiceSpec{Type: api.ServiceTypeLoadBalancer, ClusterIP: "10.0.0.3"},
+			Status:     api.ServiceStatus{LoadBalancer: api.LoadBalancerStatus{Ingress: []api.LoadBalancerIngress{{Hostname: "lb.cloud.example.net"}}}},
+		},
+	}
+}
+
+func (APIConnExternalTest) IngressList() []*v1beta1.Ingress {
+	return []*v1beta1.Ingress{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "app", Namespace: "testns"},
+			Spec:       v1beta1.IngressSpec{Rules: []v1beta1.IngressRule{{Host: "app.example.org"}}},
+			Status:     v1beta1.IngressStatus{LoadBalancer: v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "192.0.2.10"}}}},
+		},
+	}
+}
+
+func TestKubernetesParseExternal(t *testing.T) {
+	c := caddy.NewTestController("dns", `kubernetes cluster.local {
+		external example.org Example.NET
+	}`)
+	k, _, err := kubernetesParse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %q", err)
+	}
+	if x := len(k.externalZones); x != 2 {
+		t.Fatalf("Expected 2 external zones, got %d", x)
+	}
+	if !k.isExternal("example.net.") {
+		t.Errorf("Expected example.net. to be an external zone")
+	}
+	if k.isExternal("cluster.local.") {
+		t.Errorf("Expected cluster.local. not to be an external zone")
+	}
+	if x := len(k.Zones); x != 3 {
+		t.Errorf("Expected 3 zones, got %d", x)
+	}
+
+	c = caddy.NewTestController("dns", `kubernetes cluster.local {
+		external
+	}`)
+	if _, _, err := kubernetesParse(c); err == nil {
+		t.Errorf("Expected error, got none")
+	}
+}
@@ -0,0 +1,317 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ghodss/yaml"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/api/v1"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/1.5/pkg/labels"
+)
+
+// fixtureControl implements dnsController by reading manifests from a directory instead of
+// talking to the API server. Every file ending in .yaml, .yml or .json in the directory is read,
+// these may hold multiple (YAML) documents and List objects. The directory is watched and all
+// manifests are read again when something in it changes.
+type fixtureControl struct {
+	dir      string
+	selector *labels.Selector
+
+	sync.RWMutex
+	f *fixtures
+
+	stopLock sync.Mutex
+	shutdown bool
+	stopCh   chan struct{}
+}
+
+// fixtures holds the objects read from the manifests.
+type fixtures struct {
+	svcs  []*api.Service
+	pods  []*api.Pod
+	eps   api.EndpointsList
+	ns    api.NamespaceList
+	nodes map[string]api.Node
+	ings  []*v1beta1.Ingress
+}
+
+// newFixtureControl returns a controller that serves the manifests in dir.
+func newFixtureControl(dir string, opts dnsControlOpts) (*fixtureControl, error) {
+	fix := &fixtureControl{
+		dir:      dir,
+		selector: opts.selector,
+		stopCh:   make(chan struct{}),
+	}
+	f, err := fix.load()
+	if err != nil {
+		return nil, err
+	}
+	fix.f = f
+	return fix, nil
+}
+
+// load reads all manifests from the directory.
+func (fix *fixtureControl) load() (*fixtures, error) {
+	files, err := ioutil.ReadDir(fix.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fixtures{nodes: make(map[string]api.Node)}
+	for _, fi := range files {
+		if fi.IsDir() || !isManifest(fi.Name()) {
+			continue
+		}
+		name := filepath.Join(fix.dir, fi.Name())
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range bytes.Split(b, []byte("\n---")) {
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+			j, err := yaml.YAMLToJSON(doc)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+			if err := fix.add(f, j); err != nil {
+				return nil, fmt.Errorf("%s: %s", name, err)
+			}
+		}
+	}
+	return f, nil
+}
+
+// object is used to find out the kind of an object in a manifest.
+type object struct {
+	Kind  string            `json:"kind"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// add decodes the JSON object in b and adds it to f.
+func (fix *fixtureControl) add(f *fixtures, b []byte) error {
+	var o object
+	if err := json.Unmarshal(b, &o); err != nil {
+		return err
+	}
+
+	switch o.Kind {
+	case "List", "ServiceList", "PodList", "EndpointsList", "NamespaceList", "NodeList", "IngressList":
+		for _, i := range o.Items {
+			if err := fix.add(f, i); err != nil {
+				return err
+			}
+		}
+
+	case "Service":
+		var v1Obj v1.Service
+		var apiObj api.Service
+		if err := json.Unmarshal(b, &v1Obj); err != nil {
+			return err
+		}
+		if err := v1.Convert_v1_Service_To_api_Service(&v1Obj, &apiObj, nil); err != nil {
+			return err
+		}
+		if fix.match(apiObj.Labels) {
+			defaultNamespace(&apiObj.ObjectMeta)
+			f.svcs = append(f.svcs, &apiObj)
+		}
+
+	case "Pod":
+		var v1Obj v1.Pod
+		var apiObj api.Pod
+		if err := json.Unmarshal(b, &v1Obj); err != nil {
+			return err
+		}
+		if err := v1.Convert_v1_Pod_To_api_Pod(&v1Obj, &apiObj, nil); err != nil {
+			return err
+		}
+		if fix.match(apiObj.Labels) {
+			defaultNamespace(&apiObj.ObjectMeta)
+			f.pods = append(f.pods, &apiObj)
+		}
+
+	case "Endpoints":
+		var v1Obj v1.Endpoints
+		var apiObj api.Endpoints
+		if err := json.Unmarshal(b, &v1Obj); err != nil {
+			return err
+		}
+		if err := v1.Convert_v1_Endpoints_To_api_Endpoints(&v1Obj, &apiObj, nil); err != nil {
+			return err
+		}
+		if fix.match(apiObj.Labels) {
+			defaultNamespace(&apiObj.ObjectMeta)
+			f.eps.Items = append(f.eps.Items, apiObj)
+		}
+
+	case "Namespace":
+		var v1Obj v1.Namespace
+		var apiObj api.Namespace
+		if err := json.Unmarshal(b, &v1Obj); err != nil {
+			return err
+		}
+		if err := v1.Convert_v1_Namespace_To_api_Namespace(&v1Obj, &apiObj, nil); err != nil {
+			return err
+		}
+		if fix.match(apiObj.Labels) {
+			f.ns.Items = append(f.ns.Items, apiObj)
+		}
+
+	case "Node":
+		var v1Obj v1.Node
+		var apiObj api.Node
+		if err := json.Unmarshal(b, &v1Obj); err != nil {
+			return err
+		}
+		if err := v1.Convert_v1_Node_To_api_Node(&v1Obj, &apiObj, nil); err != nil {
+			return err
+		}
+		f.nodes[apiObj.Name] = apiObj
+
+	case "Ingress":
+		var v1Obj v1beta1.Ingress
+		if err := json.Unmarshal(b, &v1Obj); err != nil {
+			return err
+		}
+		if fix.match(v1Obj.Labels) {
+			if v1Obj.Namespace == "" {
+				v1Obj.Namespace = api.NamespaceDefault
+			}
+			f.ings = append(f.ings, &v1Obj)
+		}
+
+	default:
+		return fmt.Errorf("unsupported kind %q", o.Kind)
+	}
+	return nil
+}
+
+// match returns true if the labels l match the label selector, if there is one.
+func (fix *fixtureControl) match(l map[string]string) bool {
+	if fix.selector == nil {
+		return true
+	}
+	return (*fix.selector).Matches(labels.Set(l))
+}
+
+// Run watches the directory and reloads the manifests when they change.
+func (fix *fixtureControl) Run() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[ERROR] Failed to watch fixtures in `%s': %s", fix.dir, err)
+		<-fix.stopCh
+		return
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(fix.dir); err != nil {
+		log.Printf("[ERROR] Failed to watch fixtures in `%s': %s", fix.dir, err)
+		<-fix.stopCh
+		return
+	}
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if !isManifest(event.Name) {
+				continue
+			}
+			f, err := fix.load()
+			if err != nil {
+				log.Printf("[WARNING] Failed to reload fixtures in `%s': %s", fix.dir, err)
+				continue
+			}
+			fix.Lock()
+			fix.f = f
+			fix.Unlock()
+			log.Printf("[INFO] Successfully reloaded fixtures in `%s'", fix.dir)
+
+		case err := <-watcher.Errors:
+			log.Printf("[WARNING] Failed to watch fixtures in `%s': %s", fix.dir, err)
+
+		case <-fix.stopCh:
+			return
+		}
+	}
+}
+
+// Stop stops watching the directory.
+func (fix *fixtureControl) Stop() error {
+	fix.stopLock.Lock()
+	defer fix.stopLock.Unlock()
+
+	if !fix.shutdown {
+		close(fix.stopCh)
+		fix.shutdown = true
+
+		return nil
+	}
+
+	return fmt.Errorf("shutdown already in progress")
+}
+
+// HasSynced implements dnsController. The manifests are read before the controller is returned.
+func (fix *fixtureControl) HasSynced() bool { return true }
+
+// current returns the objects read most recently.
+func (fix *fixtureControl) current() *fixtures {
+	fix.RLock()
+	defer fix.RUnlock()
+	return fix.f
+}
+
+func (fix *fixtureControl) ServiceList() []*api.Service { return fix.current().svcs }
+
+func (fix *fixtureControl) EndpointsList() api.EndpointsList { return fix.current().eps }
+
+func (fix *fixtureControl) IngressList() []*v1beta1.Ingress { return fix.current().ings }
+
+func (fix *fixtureControl) NamespaceList() *api.NamespaceList {
+	ns := fix.current().ns
+	return &ns
+}
+
+func (fix *fixtureControl) PodIndex(ip string) []interface{} {
+	var pods []interface{}
+	for _, p := range fix.current().pods {
+		if p.Status.PodIP == ip {
+			pods = append(pods, p)
+		}
+	}
+	return pods
+}
+
+func (fix *fixtureControl) GetNodeByName(name string) (api.Node, error) {
+	n, ok := fix.current().nodes[name]
+	if !ok {
+		return api.Node{}, fmt.Errorf("node %q not found", name)
+	}
+	return n, nil
+}
+
+// isManifest returns true if the file name has an extension used for manifests.
+func isManifest(name string) bool {
+	switch path.Ext(name) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// defaultNamespace sets the namespace of m to the default namespace, if it has none.
+func defaultNamespace(m *api.ObjectMeta) {
+	if m.Namespace == "" {
+		m.Namespace = api.NamespaceDefault
+	}
+}
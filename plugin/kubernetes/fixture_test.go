@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+const fixtureServices = `apiVersion: v1
+kind: Service
+metadata:
+  name: svc1
+  namespace: testns
+spec:
+  clusterIP: 10.0.0.1
+  ports:
+  - name: http
+    protocol: TCP
+    port: 80
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: hdls1
+  namespace: testns
+spec:
+  clusterIP: None
+`
+
+const fixtureEndpoints = `{
+  "apiVersion": "v1",
+  "kind": "List",
+  "items": [
+    {
+      "apiVersion": "v1",
+      "kind": "Endpoints",
+      "metadata": {"name": "hdls1", "namespace": "testns"},
+      "subsets": [{"addresses": [{"ip": "172.0.0.2"}], "ports": [{"name": "http", "port": 80, "protocol": "TCP"}]}]
+    },
+    {
+      "apiVersion": "v1",
+      "kind": "Pod",
+      "metadata": {"name": "pod1"},
+      "status": {"podIP": "10.240.0.1"}
+    }
+  ]
+}
+`
+
+var dnsFixtureCases = []test.Case{
+	{
+		Qname: "svc1.testns.svc.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("svc1.testns.svc.cluster.local.	5	IN	A	10.0.0.1"),
+		},
+	},
+	{
+		Qname: "hdls1.testns.svc.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("hdls1.testns.svc.cluster.local.	5	IN	A	172.0.0.2"),
+		},
+	},
+	{
+		Qname: "10-240-0-1.default.pod.cluster.local.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeSuccess,
+		Answer: []dns.RR{
+			test.A("10-240-0-1.default.pod.cluster.local.	0	IN	A	10.240.0.1"),
+		},
+	},
+}
+
+func TestFixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns-fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "services.yaml"), []byte(fixtureServices), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "endpoints.json"), []byte(fixtureEndpoints), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a manifest"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fix, err := newFixtureControl(dir, dnsControlOpts{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %q", err)
+	}
+
+	k := New([]string{"cluster.local."})
+	k.APIConn = fix
+	k.podMode = podModeVerified
+	ctx := context.TODO()
+
+	for i, tc := range dnsFixtureCases {
+		r := tc.Msg()
+
+		w := dnsrecorder.New(&test.ResponseWriter{})
+
+		_, err := k.ServeDNS(ctx, w, r)
+		if err != tc.Error {
+			t.Errorf("Test %d expected no error, got %v", i, err)
+			return
+		}
+
+		resp := w.Msg
+		if resp == nil {
+			t.Fatalf("Test %d, got nil message and no error for %q", i, r.Question[0].Name)
+		}
+
+		test.SortAndCheck(t, resp, tc)
+	}
+
+	// Invalid manifests are an error.
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("kind: ReplicaSet\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fix.load(); err == nil {
+		t.Errorf("Expected error for unsupported kind, got none")
+	}
+}
+
+func TestKubernetesParseFixture(t *testing.T) {
+	c := caddy.NewTestController("dns", `kubernetes cluster.local {
+		fixture /etc/coredns/fixtures
+	}`)
+	k, _, err := kubernetesParse(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %q", err)
+	}
+	if k.fixtureDir != "/etc/coredns/fixtures" {
+		t.Errorf("Expected fixture directory to be %q, got %q", "/etc/coredns/fixtures", k.fixtureDir)
+	}
+
+	c = caddy.NewTestController("dns", `kubernetes cluster.local {
+		fixture
+	}`)
+	if _, _, err := kubernetesParse(c); err == nil {
+		t.Errorf("Expected error, got none")
+	}
+}
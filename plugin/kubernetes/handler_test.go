@@ -9,6 +9,7 @@ import (
 	"github.com/miekg/dns"
 	"golang.org/x/net/context"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 )
 
 var dnsTestCases = []test.Case{
@@ -190,8 +191,10 @@ func TestServeDNS(t *testing.T) {
 
 type APIConnServeTest struct{}
 
-func (APIConnServeTest) Run()        { return }
-func (APIConnServeTest) Stop() error { return nil }
+func (APIConnServeTest) Run()                            { return }
+func (APIConnServeTest) Stop() error                     { return nil }
+func (APIConnServeTest) HasSynced() bool                 { return true }
+func (APIConnServeTest) IngressList() []*v1beta1.Ingress { return nil }
 
 func (APIConnServeTest) PodIndex(string) []interface{} {
 	a := make([]interface{}, 1)
@@ -41,6 +41,9 @@ type Kubernetes struct {
 	podMode       string
 	Fallthrough   bool
 	ttl           uint32
+	topology      string   // Ordering of endpoints based on the client's zone and region, empty when disabled.
+	externalZones []string // Zones where load balancers and ingresses are published, these are also in Zones.
+	fixtureDir    string   // Directory with manifests to use instead of the API server.
 
 	primaryZoneIndex   int
 	interfaceAddrsFunc func() net.IP
@@ -146,6 +149,10 @@ func (k *Kubernetes) IsNameError(err error) bool {
 	return err == errNoItems || err == errNsNotExposed || err == errInvalidRequest
 }
 
+// Ready implements the health.Readiness interface. It returns true once the services, endpoints
+// and other watched objects have been listed from the API.
+func (k *Kubernetes) Ready() bool { return k.APIConn.HasSynced() }
+
 func (k *Kubernetes) getClientConfig() (*rest.Config, error) {
 	loadingRules := &clientcmd.ClientConfigLoadingRules{}
 	overrides := &clientcmd.ConfigOverrides{}
@@ -236,6 +243,23 @@ func (k *Kubernetes) getClientConfig() (*rest.Config, error) {
 // initKubeCache initializes a new Kubernetes cache.
 func (k *Kubernetes) initKubeCache(opts dnsControlOpts) (err error) {
 
+	if opts.labelSelector != nil {
+		var selector labels.Selector
+		selector, err = unversionedapi.LabelSelectorAsSelector(opts.labelSelector)
+		if err != nil {
+			return fmt.Errorf("unable to create Selector for LabelSelector '%s': %q", opts.labelSelector, err)
+		}
+		opts.selector = &selector
+	}
+
+	if k.fixtureDir != "" {
+		k.APIConn, err = newFixtureControl(k.fixtureDir, opts)
+		if err != nil {
+			return fmt.Errorf("failed to load kubernetes fixtures: %q", err)
+		}
+		return nil
+	}
+
 	config, err := k.getClientConfig()
 	if err != nil {
 		return err
@@ -246,16 +270,11 @@ func (k *Kubernetes) initKubeCache(opts dnsControlOpts) (err error) {
 		return fmt.Errorf("failed to create kubernetes notification controller: %q", err)
 	}
 
-	if opts.labelSelector != nil {
-		var selector labels.Selector
-		selector, err = unversionedapi.LabelSelectorAsSelector(opts.labelSelector)
-		if err != nil {
-			return fmt.Errorf("unable to create Selector for LabelSelector '%s': %q", opts.labelSelector, err)
-		}
-		opts.selector = &selector
-	}
-
-	opts.initPodCache = k.podMode == podModeVerified
+	// Topology aware ordering needs the pods to find the client and the nodes to find the
+	// zone and region of the client and the endpoints.
+	opts.initPodCache = k.podMode == podModeVerified || k.topology != ""
+	opts.initNodeCache = k.topology != ""
+	opts.initIngCache = len(k.externalZones) > 0
 
 	k.APIConn = newdnsController(kubeClient, opts)
 
@@ -264,6 +283,10 @@ func (k *Kubernetes) initKubeCache(opts dnsControlOpts) (err error) {
 
 // Records looks up services in kubernetes.
 func (k *Kubernetes) Records(state request.Request, exact bool) ([]msg.Service, error) {
+	if k.isExternal(state.Zone) {
+		return k.findExternal(state)
+	}
+
 	r, e := parseRequest(state)
 	if e != nil {
 		return nil, e
@@ -278,7 +301,14 @@ func (k *Kubernetes) Records(state request.Request, exact bool) ([]msg.Service,
 		return pods, err
 	}
 
-	services, err := k.findServices(r, state.Zone)
+	var client *locality
+	if k.topology != "" {
+		if l, ok := k.clientLocality(state.IP()); ok {
+			client = &l
+		}
+	}
+
+	services, err := k.findServices(r, state.Zone, client)
 	return services, err
 }
 
@@ -339,8 +369,9 @@ func (k *Kubernetes) findPods(r recordRequest, zone string) (pods []msg.Service,
 	return pods, err
 }
 
-// findServices returns the services matching r from the cache.
-func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.Service, err error) {
+// findServices returns the services matching r from the cache. If client is not nil the endpoints
+// of headless services are ordered by their distance to the client.
+func (k *Kubernetes) findServices(r recordRequest, zone string, client *locality) (services []msg.Service, err error) {
 	serviceList := k.APIConn.ServiceList()
 	zonePath := msg.Path(zone, "coredns")
 	err = errNoItems // Set to errNoItems to signal really nothing found, gets reset when name is matched.
@@ -358,6 +389,10 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 
 		// Endpoint query or headless service
 		if svc.Spec.ClusterIP == api.ClusterIPNone || r.endpoint != "" {
+			var (
+				endpoints []msg.Service
+				dist      []int
+			)
 			endpointsList := k.APIConn.EndpointsList()
 			for _, ep := range endpointsList.Items {
 				if ep.ObjectMeta.Name != svc.Name || ep.ObjectMeta.Namespace != svc.Namespace {
@@ -384,11 +419,18 @@ func (k *Kubernetes) findServices(r recordRequest, zone string) (services []msg.
 
 							err = nil
 
-							services = append(services, s)
+							endpoints = append(endpoints, s)
+							if client != nil {
+								dist = append(dist, k.distance(*client, addr))
+							}
 						}
 					}
 				}
 			}
+			if client != nil {
+				endpoints = k.topologyOrder(endpoints, dist)
+			}
+			services = append(services, endpoints...)
 			continue
 		}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/miekg/dns"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 )
 
 func TestWildcard(t *testing.T) {
@@ -50,9 +51,11 @@ func TestEndpointHostname(t *testing.T) {
 
 type APIConnServiceTest struct{}
 
-func (APIConnServiceTest) Run()                          { return }
-func (APIConnServiceTest) Stop() error                   { return nil }
-func (APIConnServiceTest) PodIndex(string) []interface{} { return nil }
+func (APIConnServiceTest) Run()                            { return }
+func (APIConnServiceTest) Stop() error                     { return nil }
+func (APIConnServiceTest) HasSynced() bool                 { return true }
+func (APIConnServiceTest) IngressList() []*v1beta1.Ingress { return nil }
+func (APIConnServiceTest) PodIndex(string) []interface{}   { return nil }
 
 func (APIConnServiceTest) ServiceList() []*api.Service {
 	svcs := []*api.Service{
@@ -4,13 +4,16 @@ import (
 	"testing"
 
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 )
 
 type APIConnTest struct{}
 
-func (APIConnTest) Run()                          { return }
-func (APIConnTest) Stop() error                   { return nil }
-func (APIConnTest) PodIndex(string) []interface{} { return nil }
+func (APIConnTest) Run()                            { return }
+func (APIConnTest) Stop() error                     { return nil }
+func (APIConnTest) HasSynced() bool                 { return true }
+func (APIConnTest) IngressList() []*v1beta1.Ingress { return nil }
+func (APIConnTest) PodIndex(string) []interface{}   { return nil }
 
 func (APIConnTest) ServiceList() []*api.Service {
 	svc := api.Service{
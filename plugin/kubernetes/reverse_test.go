@@ -9,13 +9,16 @@ import (
 	"github.com/miekg/dns"
 	"golang.org/x/net/context"
 	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
 )
 
 type APIConnReverseTest struct{}
 
-func (APIConnReverseTest) Run()                          { return }
-func (APIConnReverseTest) Stop() error                   { return nil }
-func (APIConnReverseTest) PodIndex(string) []interface{} { return nil }
+func (APIConnReverseTest) Run()                            { return }
+func (APIConnReverseTest) Stop() error                     { return nil }
+func (APIConnReverseTest) HasSynced() bool                 { return true }
+func (APIConnReverseTest) IngressList() []*v1beta1.Ingress { return nil }
+func (APIConnReverseTest) PodIndex(string) []interface{}   { return nil }
 
 func (APIConnReverseTest) ServiceList() []*api.Service {
 	svcs := []*api.Service{
@@ -3,6 +3,8 @@ package kubernetes
 import (
 	"errors"
 	"fmt"
+	"log"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -44,6 +46,13 @@ func setup(c *caddy.Controller) error {
 		return nil
 	})
 
+	c.OnStartup(func() error {
+		if kubernetes.topology != "" && dnsserver.GetConfig(c).Handler("cache") != nil {
+			log.Printf("[WARNING] kubernetes: topology answers depend on the client, but cache shares them between all clients")
+		}
+		return nil
+	})
+
 	c.OnShutdown(func() error {
 		if kubernetes.APIProxy != nil {
 			kubernetes.APIProxy.Stop()
@@ -188,6 +197,37 @@ func kubernetesParse(c *caddy.Controller) (*Kubernetes, dnsControlOpts, error) {
 					return nil, opts, c.Errf("ttl must be in range [5, 3600]: %d", t)
 				}
 				k8s.ttl = uint32(t)
+			case "external":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, opts, c.ArgErr()
+				}
+				for _, a := range args {
+					z := plugin.Host(a).Normalize()
+					k8s.externalZones = append(k8s.externalZones, z)
+					k8s.Zones = append(k8s.Zones, z)
+				}
+			case "fixture":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, opts, c.ArgErr()
+				}
+				dir := args[0]
+				if !filepath.IsAbs(dir) && dnsserver.GetConfig(c).Root != "" {
+					dir = filepath.Join(dnsserver.GetConfig(c).Root, dir)
+				}
+				k8s.fixtureDir = dir
+			case "topology":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, opts, c.ArgErr()
+				}
+				switch args[0] {
+				case topologySort, topologyFilter:
+					k8s.topology = args[0]
+				default:
+					return nil, opts, fmt.Errorf("wrong value for topology: %s, must be one of: sort, filter", args[0])
+				}
 			default:
 				return nil, opts, c.Errf("unknown property '%s'", c.Val())
 			}
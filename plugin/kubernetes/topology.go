@@ -0,0 +1,98 @@
+package kubernetes
+
+import (
+	"github.com/coredns/coredns/plugin/etcd/msg"
+
+	"k8s.io/client-go/1.5/pkg/api"
+)
+
+const (
+	// topologySort returns all endpoints, but those closest to the client come first.
+	topologySort = "sort"
+	// topologyFilter only returns the endpoints closest to the client.
+	topologyFilter = "filter"
+)
+
+// Distances between a client and an endpoint, lower is closer.
+const (
+	distanceZone = iota
+	distanceRegion
+	distanceRemote
+)
+
+// locality holds the zone and region labels of a node.
+type locality struct {
+	zone   string
+	region string
+}
+
+// clientLocality returns the locality of the node the pod with IP ip is scheduled on. The
+// boolean is false when there is no such pod, or when its node can not be found.
+func (k *Kubernetes) clientLocality(ip string) (locality, bool) {
+	pod := k.podWithIP(ip)
+	if pod == nil || pod.Spec.NodeName == "" {
+		return locality{}, false
+	}
+	return k.nodeLocality(pod.Spec.NodeName)
+}
+
+// nodeLocality returns the locality of the node with name name.
+func (k *Kubernetes) nodeLocality(name string) (locality, bool) {
+	node, err := k.APIConn.GetNodeByName(name)
+	if err != nil {
+		return locality{}, false
+	}
+	return locality{zone: node.Labels[LabelZone], region: node.Labels[LabelRegion]}, true
+}
+
+// distance returns how far the endpoint addr is from the client locality c.
+func (k *Kubernetes) distance(c locality, addr api.EndpointAddress) int {
+	if addr.NodeName == nil {
+		return distanceRemote
+	}
+	l, ok := k.nodeLocality(*addr.NodeName)
+	if !ok {
+		return distanceRemote
+	}
+	if c.region != "" && c.region != l.region {
+		return distanceRemote
+	}
+	if c.zone != "" && c.zone == l.zone {
+		return distanceZone
+	}
+	if c.region != "" {
+		return distanceRegion
+	}
+	return distanceRemote
+}
+
+// topologyOrder orders the services in svcs by their distance to the client, dist holds the
+// distance for each service. With topologySort the distance is also used as the priority of
+// the service, so SRV answers reflect it. With topologyFilter only the closest services are
+// returned; if nothing is close, everything is.
+func (k *Kubernetes) topologyOrder(svcs []msg.Service, dist []int) []msg.Service {
+	min := distanceRemote
+	for _, d := range dist {
+		if d < min {
+			min = d
+		}
+	}
+
+	ordered := make([]msg.Service, 0, len(svcs))
+	for d := min; d <= distanceRemote; d++ {
+		for i := range svcs {
+			if dist[i] != d {
+				continue
+			}
+			s := svcs[i]
+			if k.topology == topologySort {
+				s.Priority = d
+			}
+			ordered = append(ordered, s)
+		}
+		if k.topology == topologyFilter {
+			break
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,176 @@
+package kubernetes
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+	"k8s.io/client-go/1.5/pkg/api"
+	"k8s.io/client-go/1.5/pkg/apis/extensions/v1beta1"
+)
+
+type APIConnTopologyTest struct{}
+
+func (APIConnTopologyTest) Run()                            { return }
+func (APIConnTopologyTest) Stop() error                     { return nil }
+func (APIConnTopologyTest) HasSynced() bool                 { return true }
+func (APIConnTopologyTest) IngressList() []*v1beta1.Ingress { return nil }
+
+func (APIConnTopologyTest) PodIndex(ip string) []interface{} {
+	if ip != "10.240.0.1" { // Remote IP set in test.ResponseWriter
+		return nil
+	}
+	return []interface{}{
+		&api.Pod{
+			ObjectMeta: api.ObjectMeta{Namespace: "testns"},
+			Spec:       api.PodSpec{NodeName: "node-a1"},
+			Status:     api.PodStatus{PodIP: ip},
+		},
+	}
+}
+
+func (APIConnTopologyTest) ServiceList() []*api.Service {
+	return []*api.Service{
+		{
+			ObjectMeta: api.ObjectMeta{Name: "hdls1", Namespace: "testns"},
+			Spec:       api.ServiceSpec{ClusterIP: api.ClusterIPNone},
+		},
+	}
+}
+
+func (APIConnTopologyTest) EndpointsList() api.EndpointsList {
+	remote, region, zone := "node-c1", "node-a2", "node-a1"
+
+	return api.EndpointsList{
+		Items: []api.Endpoints{
+			{
+				Subsets: []api.EndpointSubset{
+					{
+						Addresses: []api.EndpointAddress{
+							{IP: "172.0.0.1", NodeName: &remote},
+							{IP: "172.0.0.2", NodeName: &region},
+							{IP: "172.0.0.3", NodeName: &zone},
+						},
+						Ports: []api.EndpointPort{
+							{Port: 80, Protocol: "tcp", Name: "http"},
+						},
+					},
+				},
+				ObjectMeta: api.ObjectMeta{Name: "hdls1", Namespace: "testns"},
+			},
+		},
+	}
+}
+
+func (APIConnTopologyTest) GetNodeByName(name string) (api.Node, error) {
+	labels := map[string]map[string]string{
+		"node-a1": {LabelZone: "a1", LabelRegion: "a"},
+		"node-a2": {LabelZone: "a2", LabelRegion: "a"},
+		"node-c1": {LabelZone: "c1", LabelRegion: "c"},
+	}
+	l, ok := labels[name]
+	if !ok {
+		return api.Node{}, fmt.Errorf("node %q not found", name)
+	}
+	return api.Node{ObjectMeta: api.ObjectMeta{Name: name, Labels: l}}, nil
+}
+
+func TestTopology(t *testing.T) {
+	tests := []struct {
+		topology string
+		w        dns.ResponseWriter
+		hosts    []string
+		prios    []int
+	}{
+		{"", &test.ResponseWriter{}, []string{"172.0.0.1", "172.0.0.2", "172.0.0.3"}, []int{0, 0, 0}},
+		{topologySort, &test.ResponseWriter{}, []string{"172.0.0.3", "172.0.0.2", "172.0.0.1"}, []int{0, 1, 2}},
+		{topologyFilter, &test.ResponseWriter{}, []string{"172.0.0.3"}, []int{0}},
+		// Client is not a pod, keep the original order.
+		{topologySort, &test.ResponseWriter6{}, []string{"172.0.0.1", "172.0.0.2", "172.0.0.3"}, []int{0, 0, 0}},
+	}
+
+	for i, tc := range tests {
+		k := New([]string{"cluster.local."})
+		k.APIConn = &APIConnTopologyTest{}
+		k.topology = tc.topology
+
+		state := request.Request{
+			Req:  &dns.Msg{Question: []dns.Question{{Name: "hdls1.testns.svc.cluster.local.", Qtype: dns.TypeA}}},
+			W:    tc.w,
+			Zone: "cluster.local.",
+		}
+		svcs, err := k.Services(state, false, plugin.Options{})
+		if err != nil {
+			t.Errorf("Test %d: got error '%v'", i, err)
+			continue
+		}
+		if len(svcs) != len(tc.hosts) {
+			t.Errorf("Test %d: expected %d services, got %d", i, len(tc.hosts), len(svcs))
+			continue
+		}
+		for j, s := range svcs {
+			if s.Host != tc.hosts[j] {
+				t.Errorf("Test %d: expected host %d to be %s, got %s", i, j, tc.hosts[j], s.Host)
+			}
+			if s.Priority != tc.prios[j] {
+				t.Errorf("Test %d: expected priority %d to be %d, got %d", i, j, tc.prios[j], s.Priority)
+			}
+		}
+	}
+}
+
+func TestTopologyOrderSingle(t *testing.T) {
+	k := New([]string{"cluster.local."})
+	k.topology = topologySort
+
+	// A lone endpoint gets the priority of its distance too.
+	svcs := k.topologyOrder([]msg.Service{{Host: "172.0.0.2"}}, []int{distanceRegion})
+	if len(svcs) != 1 || svcs[0].Priority != distanceRegion {
+		t.Errorf("Expected a single service with priority %d, got %v", distanceRegion, svcs)
+	}
+}
+
+func TestKubernetesParseTopology(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{`kubernetes cluster.local`, "", false},
+		{`kubernetes cluster.local {
+			topology sort
+		}`, topologySort, false},
+		{`kubernetes cluster.local {
+			topology filter
+		}`, topologyFilter, false},
+		{`kubernetes cluster.local {
+			topology nearest
+		}`, "", true},
+		{`kubernetes cluster.local {
+			topology
+		}`, "", true},
+	}
+
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		k, _, err := kubernetesParse(c)
+		if err != nil && !tc.err {
+			t.Fatalf("Test %d: Expected no error, got %q", i, err)
+		}
+		if err == nil && tc.err {
+			t.Fatalf("Test %d: Expected error, got none", i)
+		}
+		if err != nil {
+			continue
+		}
+		if k.topology != tc.expected {
+			t.Errorf("Test %d: Expected topology to be %q, got %q", i, tc.expected, k.topology)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package log
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotate configures when a log file is rotated. A zero Rotate never rotates.
+type Rotate struct {
+	Size int64         // rotate when the file grows beyond this many bytes
+	Age  time.Duration // rotate when the file was opened this long ago
+	Keep int           // number of rotated files to keep, 0 keeps all of them
+}
+
+// file is a log file that is shared by all rules that write to the same path. It is kept across
+// reloads, when the last rule using it is gone it is closed.
+type file struct {
+	path   string
+	rotate Rotate
+	users  int
+
+	sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+var (
+	filesMu sync.Mutex
+	files   = map[string]*file{}
+)
+
+// openFile returns the writer for name. This is stdout or stderr, or the (shared) file at that path.
+func openFile(name string, rotate Rotate) (io.Writer, error) {
+	switch name {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	if f, ok := files[name]; ok {
+		// On reload the new rules open the file before the old ones close it, the new rotation wins.
+		f.Lock()
+		f.rotate = rotate
+		f.Unlock()
+		f.users++
+		return f, nil
+	}
+
+	f := &file{path: name, rotate: rotate, users: 1}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	files[name] = f
+	return f, nil
+}
+
+// closeFile closes the file at name once its last user is gone.
+func closeFile(name string) error {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+
+	f, ok := files[name]
+	if !ok {
+		return nil
+	}
+	f.users--
+	if f.users > 0 {
+		return nil
+	}
+	delete(files, name)
+
+	f.Lock()
+	defer f.Unlock()
+	return f.f.Close()
+}
+
+// open opens the file for appending, f must be locked or not be shared yet.
+func (f *file) open() error {
+	fh, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := fh.Stat()
+	if err != nil {
+		fh.Close()
+		return err
+	}
+	f.f = fh
+	f.size = fi.Size()
+	f.opened = time.Now()
+	return nil
+}
+
+// Write implements io.Writer. A log.Logger writes a line with each call, so lines are never split
+// over two files.
+func (f *file) Write(b []byte) (int, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if f.due(int64(len(b))) {
+		if err := f.rotateFile(); err != nil {
+			// Keep writing to the current file, rather than losing the logs.
+			log.Printf("[ERROR] Failed to rotate log file %s: %s", f.path, err)
+		}
+	}
+	n, err := f.f.Write(b)
+	f.size += int64(n)
+	return n, err
+}
+
+// due returns true if the file must be rotated before writing n more bytes to it.
+func (f *file) due(n int64) bool {
+	if f.rotate.Size > 0 && f.size > 0 && f.size+n > f.rotate.Size {
+		return true
+	}
+	if f.rotate.Age > 0 && time.Since(f.opened) >= f.rotate.Age {
+		return true
+	}
+	return false
+}
+
+// rotateFile renames the file to its path with the current time appended, opens a new one and
+// removes the rotated files that are no longer kept.
+func (f *file) rotateFile() error {
+	rotated := f.path + "." + time.Now().UTC().Format(rotateFormat)
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+	old := f.f
+	if err := f.open(); err != nil {
+		// Continue with the renamed file, and only try again after another rotation period.
+		f.size = 0
+		f.opened = time.Now()
+		return err
+	}
+	old.Close()
+
+	if f.rotate.Keep == 0 {
+		return nil
+	}
+	return removeRotated(f.path, f.rotate.Keep)
+}
+
+// removeRotated removes the oldest rotated files of path, leaving keep of them.
+func removeRotated(path string, keep int) error {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return err
+	}
+	rotated := []string{}
+	for _, m := range matches {
+		if _, err := time.Parse(rotateFormat, strings.TrimPrefix(m, path+".")); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	if len(rotated) <= keep {
+		return nil
+	}
+	// The time format sorts in chronological order.
+	sort.Strings(rotated)
+	for _, r := range rotated[:len(rotated)-keep] {
+		if err := os.Remove(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const rotateFormat = "20060102-150405.000"
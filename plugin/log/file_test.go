@@ -0,0 +1,82 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns-log")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "query.log")
+
+	w, err := openFile(name, Rotate{Size: 10, Keep: 2})
+	if err != nil {
+		t.Fatalf("Failed to open log file: %s", err)
+	}
+	defer closeFile(name)
+
+	line := []byte("0123456789\n")
+	for i := 0; i < 4; i++ {
+		if _, err := w.Write(line); err != nil {
+			t.Fatalf("Failed to write: %s", err)
+		}
+		// Rotated files are named after the time, with millisecond resolution.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %s", err)
+	}
+	if string(b) != string(line) {
+		t.Errorf("Expected only the last line in the log file, got %q", b)
+	}
+
+	rotated, _ := filepath.Glob(name + ".*")
+	if len(rotated) != 2 {
+		t.Errorf("Expected 2 rotated files, got %v", rotated)
+	}
+}
+
+func TestFileShared(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns-log")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "query.log")
+
+	w1, err := openFile(name, Rotate{})
+	if err != nil {
+		t.Fatalf("Failed to open log file: %s", err)
+	}
+	w2, err := openFile(name, Rotate{})
+	if err != nil {
+		t.Fatalf("Failed to open log file: %s", err)
+	}
+	if w1 != w2 {
+		t.Errorf("Expected the log file to be shared")
+	}
+
+	closeFile(name)
+	if _, err := w2.Write([]byte("still open\n")); err != nil {
+		t.Errorf("Expected the log file to stay open for its other user: %s", err)
+	}
+	closeFile(name)
+	if _, ok := files[name]; ok {
+		t.Errorf("Expected the log file to be closed")
+	}
+
+	b, _ := ioutil.ReadFile(name)
+	if !strings.Contains(string(b), "still open") {
+		t.Errorf("Expected the line to be written, got %q", b)
+	}
+}
@@ -0,0 +1,162 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/plugin/pkg/rcode"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// entry is a query log entry with typed fields, for the structured log formats.
+type entry struct {
+	Time     string            `json:"time"`
+	Server   string            `json:"server"`
+	Client   string            `json:"client"`
+	Port     string            `json:"port"`
+	Proto    string            `json:"proto"`
+	ID       uint16            `json:"id"`
+	Qname    string            `json:"qname"`
+	Qtype    string            `json:"qtype"`
+	Qclass   string            `json:"qclass"`
+	Size     int               `json:"size"`
+	Do       bool              `json:"do"`
+	Bufsize  int               `json:"bufsize"`
+	Rcode    string            `json:"rcode"`
+	Flags    []string          `json:"flags"`
+	Rsize    int               `json:"rsize"`
+	Duration float64           `json:"duration"` // in seconds
+	Answer   []string          `json:"answer"`
+	Meta     map[string]string `json:"meta,omitempty"` // metadata set by plugins
+}
+
+// newEntry returns the entry for the query r and the response recorded in rr.
+func newEntry(ctx context.Context, server string, r *dns.Msg, rr *dnsrecorder.Recorder) entry {
+	state := request.Request{W: rr, Req: r}
+	e := entry{
+		Time:     time.Now().UTC().Format(time.RFC3339Nano),
+		Server:   server,
+		Client:   state.IP(),
+		Port:     state.Port(),
+		Proto:    state.Proto(),
+		ID:       r.Id,
+		Qname:    state.Name(),
+		Qtype:    state.Type(),
+		Qclass:   state.Class(),
+		Size:     state.Len(),
+		Do:       state.Do(),
+		Bufsize:  state.Size(),
+		Rcode:    rcode.ToString(rr.Rcode),
+		Flags:    []string{},
+		Rsize:    rr.Len,
+		Duration: time.Since(rr.Start).Seconds(),
+		Answer:   []string{},
+		Meta:     metadata.Values(ctx),
+	}
+	if rr.Msg != nil {
+		e.Flags = flags(rr.Msg.MsgHdr)
+		for _, a := range rr.Msg.Answer {
+			e.Answer = append(e.Answer, strings.Replace(a.String(), "\t", " ", -1))
+		}
+	}
+	return e
+}
+
+// JSON returns the entry as a JSON object.
+func (e entry) JSON() string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		// Can't happen, all fields are strings, numbers or lists of strings.
+		return ""
+	}
+	return string(b)
+}
+
+// Logfmt returns the entry as key=value pairs. Flags and answer RRs are separated by commas, the
+// metadata values are prefixed with "meta.".
+func (e entry) Logfmt() string {
+	b := &bytes.Buffer{}
+	pair := func(key, value string) {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(value))
+	}
+
+	pair("time", e.Time)
+	pair("server", e.Server)
+	pair("client", e.Client)
+	pair("port", e.Port)
+	pair("proto", e.Proto)
+	pair("id", strconv.Itoa(int(e.ID)))
+	pair("qname", e.Qname)
+	pair("qtype", e.Qtype)
+	pair("qclass", e.Qclass)
+	pair("size", strconv.Itoa(e.Size))
+	pair("do", strconv.FormatBool(e.Do))
+	pair("bufsize", strconv.Itoa(e.Bufsize))
+	pair("rcode", e.Rcode)
+	pair("flags", strings.Join(e.Flags, ","))
+	pair("rsize", strconv.Itoa(e.Rsize))
+	pair("duration", strconv.FormatFloat(e.Duration, 'f', -1, 64))
+	pair("answer", strings.Join(e.Answer, ","))
+
+	keys := make([]string, 0, len(e.Meta))
+	for k := range e.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		pair("meta."+k, e.Meta[k])
+	}
+	return b.String()
+}
+
+// logfmtValue quotes s if it is empty or contains characters that would make the line ambiguous.
+func logfmtValue(s string) string {
+	if s == "" || strings.IndexFunc(s, func(r rune) bool { return r <= ' ' || r == '=' || r == '"' || r == '\\' }) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// flags returns the header flags that are set.
+func flags(h dns.MsgHdr) []string {
+	f := []string{}
+	if h.Response {
+		f = append(f, "qr")
+	}
+	if h.Authoritative {
+		f = append(f, "aa")
+	}
+	if h.Truncated {
+		f = append(f, "tc")
+	}
+	if h.RecursionDesired {
+		f = append(f, "rd")
+	}
+	if h.RecursionAvailable {
+		f = append(f, "ra")
+	}
+	if h.Zero {
+		f = append(f, "z")
+	}
+	if h.AuthenticatedData {
+		f = append(f, "ad")
+	}
+	if h.CheckingDisabled {
+		f = append(f, "cd")
+	}
+	return f
+}
@@ -3,11 +3,13 @@ package log
 
 import (
 	"log"
+	"math/rand"
 	"time"
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metrics/vars"
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/pkg/rcode"
 	"github.com/coredns/coredns/plugin/pkg/replacer"
 	"github.com/coredns/coredns/plugin/pkg/response"
@@ -22,6 +24,7 @@ type Logger struct {
 	Next      plugin.Handler
 	Rules     []Rule
 	ErrorFunc func(dns.ResponseWriter, *dns.Msg, int) // failover error handler
+	Server    string                                  // server block, as logged in the structured formats
 }
 
 // ServeDNS implements the plugin.Handler interface.
@@ -33,6 +36,7 @@ func (l Logger) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 		}
 
 		rrw := dnsrecorder.New(w)
+		ctx = metadata.NewContext(ctx)
 		rc, err := plugin.NextOrFailure(l.Name(), l.Next, ctx, rrw, r)
 
 		if rc > 0 {
@@ -54,9 +58,17 @@ func (l Logger) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg)
 
 		tpe, _ := response.Typify(rrw.Msg, time.Now().UTC())
 		class := response.Classify(tpe)
-		if rule.Class == response.All || rule.Class == class {
-			rep := replacer.New(r, rrw, CommonLogEmptyValue)
-			rule.Log.Println(rep.Replace(rule.Format))
+		if (rule.Class == response.All || rule.Class == class) && rule.sampled() {
+			switch rule.Format {
+			case JSONLogFormat:
+				rule.Log.Println(newEntry(ctx, l.Server, r, rrw).JSON())
+			case LogfmtLogFormat:
+				rule.Log.Println(newEntry(ctx, l.Server, r, rrw).Logfmt())
+			default:
+				rep := replacer.New(r, rrw, CommonLogEmptyValue)
+				replacer.Apply(ctx, rep)
+				rule.Log.Println(rep.Replace(rule.Format))
+			}
 		}
 
 		return rc, err
@@ -74,11 +86,18 @@ type Rule struct {
 	Class      response.Class
 	OutputFile string
 	Format     string
+	Rotate     Rotate
+	Sample     float64 // fraction of the queries to log, 0 logs all of them
 	Log        *log.Logger
 }
 
+// sampled returns true if the query must be logged according to the sample rate of the rule.
+func (r Rule) sampled() bool {
+	return r.Sample == 0 || r.Sample >= 1 || rand.Float64() < r.Sample
+}
+
 const (
-	// DefaultLogFilename is the default output name. This is the only supported value.
+	// DefaultLogFilename is the default output name.
 	DefaultLogFilename = "stdout"
 	// CommonLogFormat is the common log format.
 	CommonLogFormat = `{remote} ` + CommonLogEmptyValue + ` [{when}] "{type} {class} {name} {proto} {size} {>do} {>bufsize}" {rcode} {>rflags} {rsize} {duration}`
@@ -86,6 +105,10 @@ const (
 	CommonLogEmptyValue = "-"
 	// CombinedLogFormat is the combined log format.
 	CombinedLogFormat = CommonLogFormat + ` "{>opcode}"`
+	// JSONLogFormat logs each query as a JSON object.
+	JSONLogFormat = "{json}"
+	// LogfmtLogFormat logs each query as logfmt key=value pairs.
+	LogfmtLogFormat = "{logfmt}"
 	// DefaultLogFormat is the default log format.
 	DefaultLogFormat = CommonLogFormat
 )
@@ -2,11 +2,14 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
 	"log"
 	"strings"
 	"testing"
 
+	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/pkg/response"
 	"github.com/coredns/coredns/plugin/test"
 
@@ -99,3 +102,125 @@ func TestLoggedClassError(t *testing.T) {
 		t.Errorf("Expected it to be logged. Logged string: %s", logged)
 	}
 }
+
+var aclKey = metadata.Register("test/acl")
+
+// answerHandler answers with an A record and sets metadata.
+func answerHandler() plugin.Handler {
+	return plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		metadata.Set(ctx, aclKey, "allow")
+		m := new(dns.Msg)
+		m.SetReply(r)
+		m.Authoritative = true
+		m.Answer = []dns.RR{test.A("example.org. 3600 IN A 127.0.0.1")}
+		w.WriteMsg(m)
+		return dns.RcodeSuccess, nil
+	})
+}
+
+func TestLoggedJSON(t *testing.T) {
+	var f bytes.Buffer
+	rule := Rule{
+		NameScope: ".",
+		Format:    JSONLogFormat,
+		Log:       log.New(&f, "", 0),
+	}
+
+	logger := Logger{
+		Rules:  []Rule{rule},
+		Next:   answerHandler(),
+		Server: "dns://example.org.:53",
+	}
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+	// Like the server, record the plugin that writes the response.
+	ctx := metadata.NewContext(context.TODO())
+	rec := dnsrecorder.New(&test.ResponseWriter{})
+	logger.ServeDNS(ctx, metadata.NewWriter(ctx, rec), r)
+
+	var e struct {
+		Server string            `json:"server"`
+		Client string            `json:"client"`
+		Qname  string            `json:"qname"`
+		Qtype  string            `json:"qtype"`
+		Rcode  string            `json:"rcode"`
+		Flags  []string          `json:"flags"`
+		Answer []string          `json:"answer"`
+		Meta   map[string]string `json:"meta"`
+	}
+	if err := json.Unmarshal(f.Bytes(), &e); err != nil {
+		t.Fatalf("Expected JSON to be logged, got %q: %s", f.String(), err)
+	}
+	if e.Server != "dns://example.org.:53" || e.Client != "10.240.0.1" || e.Qname != "example.org." || e.Qtype != "A" || e.Rcode != "NOERROR" {
+		t.Errorf("Unexpected log entry: %+v", e)
+	}
+	if strings.Join(e.Flags, ",") != "qr,aa,rd" {
+		t.Errorf("Expected flags qr,aa,rd, got %v", e.Flags)
+	}
+	if len(e.Answer) != 1 || e.Answer[0] != "example.org. 3600 IN A 127.0.0.1" {
+		t.Errorf("Expected answer example.org. 3600 IN A 127.0.0.1, got %v", e.Answer)
+	}
+	if e.Meta["test/acl"] != "allow" || e.Meta["server/plugin"] != "handlerfunc" {
+		t.Errorf("Expected meta test/acl=allow and server/plugin=handlerfunc, got %v", e.Meta)
+	}
+}
+
+func TestLoggedLogfmt(t *testing.T) {
+	var f bytes.Buffer
+	rule := Rule{
+		NameScope: ".",
+		Format:    LogfmtLogFormat,
+		Log:       log.New(&f, "", 0),
+	}
+
+	logger := Logger{
+		Rules: []Rule{rule},
+		Next:  answerHandler(),
+	}
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+	// Like the server, record the plugin that writes the response.
+	ctx := metadata.NewContext(context.TODO())
+	rec := dnsrecorder.New(&test.ResponseWriter{})
+	logger.ServeDNS(ctx, metadata.NewWriter(ctx, rec), r)
+
+	logged := f.String()
+	for _, s := range []string{
+		` server="" `,
+		` qname=example.org. qtype=A qclass=IN `,
+		` rcode=NOERROR flags=qr,aa,rd `,
+		` answer="example.org. 3600 IN A 127.0.0.1" meta.server/plugin=handlerfunc meta.test/acl=allow`,
+	} {
+		if !strings.Contains(logged, s) {
+			t.Errorf("Expected %q to be logged. Logged string: %s", s, logged)
+		}
+	}
+}
+
+func TestLoggedSample(t *testing.T) {
+	var f bytes.Buffer
+	rule := Rule{
+		NameScope: ".",
+		Format:    DefaultLogFormat,
+		Sample:    0.000001,
+		Log:       log.New(&f, "", 0),
+	}
+
+	logger := Logger{
+		Rules: []Rule{rule},
+		Next:  answerHandler(),
+	}
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+	for i := 0; i < 10; i++ {
+		rec := dnsrecorder.New(&test.ResponseWriter{})
+		logger.ServeDNS(context.TODO(), rec, r)
+	}
+
+	if logged := f.String(); len(logged) != 0 {
+		t.Errorf("Expected it not to be logged, but got string: %s", logged)
+	}
+}
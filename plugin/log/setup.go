@@ -1,9 +1,9 @@
 package log
 
 import (
-	"fmt"
 	"log"
-	"os"
+	"strconv"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -29,10 +29,9 @@ func setup(c *caddy.Controller) error {
 	// Open the log files for writing when the server starts
 	c.OnStartup(func() error {
 		for i := 0; i < len(rules); i++ {
-			// We only support stdout
-			writer := os.Stdout
-			if rules[i].OutputFile != "stdout" {
-				return plugin.Error("log", fmt.Errorf("invalid log file: %s", rules[i].OutputFile))
+			writer, err := openFile(rules[i].OutputFile, rules[i].Rotate)
+			if err != nil {
+				return plugin.Error("log", err)
 			}
 
 			rules[i].Log = log.New(writer, "", 0)
@@ -40,9 +39,19 @@ func setup(c *caddy.Controller) error {
 
 		return nil
 	})
+	c.OnShutdown(func() error {
+		for i := 0; i < len(rules); i++ {
+			if err := closeFile(rules[i].OutputFile); err != nil {
+				return plugin.Error("log", err)
+			}
+		}
+		return nil
+	})
 
-	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
-		return Logger{Next: next, Rules: rules, ErrorFunc: dnsserver.DefaultErrorFunc}
+	config := dnsserver.GetConfig(c)
+	server := config.Transport + "://" + config.Zone + ":" + config.Port
+	config.AddPlugin(func(next plugin.Handler) plugin.Handler {
+		return Logger{Next: next, Rules: rules, ErrorFunc: dnsserver.DefaultErrorFunc, Server: server}
 	})
 
 	return nil
@@ -79,6 +88,10 @@ func logParse(c *caddy.Controller) ([]Rule, error) {
 					format = CommonLogFormat
 				case "{combined}":
 					format = CombinedLogFormat
+				case "{json}":
+					format = JSONLogFormat
+				case "{logfmt}":
+					format = LogfmtLogFormat
 				default:
 					format = args[2]
 				}
@@ -106,11 +119,65 @@ func logParse(c *caddy.Controller) ([]Rule, error) {
 				}
 				// update class and the last added Rule (bit icky)
 				rules[len(rules)-1].Class = cls
+			case "sample":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				rate, err := strconv.ParseFloat(c.Val(), 64)
+				if err != nil {
+					return nil, err
+				}
+				if rate <= 0 || rate > 1 {
+					return nil, c.Errf("sample rate must be larger than 0 and at most 1: %s", c.Val())
+				}
+				rules[len(rules)-1].Sample = rate
+			case "rotate_size":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				mb, err := strconv.Atoi(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if mb <= 0 {
+					return nil, c.Errf("rotate_size must be positive: %d", mb)
+				}
+				rules[len(rules)-1].Rotate.Size = int64(mb) * 1024 * 1024
+			case "rotate_age":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if d <= 0 {
+					return nil, c.Errf("rotate_age must be positive: %s", d)
+				}
+				rules[len(rules)-1].Rotate.Age = d
+			case "rotate_keep":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				n, err := strconv.Atoi(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if n < 0 {
+					return nil, c.Errf("rotate_keep can not be negative: %d", n)
+				}
+				rules[len(rules)-1].Rotate.Keep = n
 			default:
 				return nil, c.ArgErr()
 			}
 		}
 	}
 
+	for _, r := range rules {
+		if r.Rotate != (Rotate{}) && (r.OutputFile == "stdout" || r.OutputFile == "stderr") {
+			return nil, c.Errf("can not rotate %s", r.OutputFile)
+		}
+	}
+
 	return rules, nil
 }
@@ -2,6 +2,7 @@ package log
 
 import (
 	"testing"
+	"time"
 
 	"github.com/coredns/coredns/plugin/pkg/response"
 
@@ -89,6 +90,44 @@ func TestLogParse(t *testing.T) {
 			Format:     CommonLogFormat,
 			Class:      response.Denial,
 		}}},
+		{`log example.org log.json {json}`, false, []Rule{{
+			NameScope:  "example.org.",
+			OutputFile: "log.json",
+			Format:     JSONLogFormat,
+		}}},
+		{`log . stderr {logfmt} {
+			sample 0.01
+		}`, false, []Rule{{
+			NameScope:  ".",
+			OutputFile: "stderr",
+			Format:     LogfmtLogFormat,
+			Sample:     0.01,
+		}}},
+		{`log . /var/log/coredns.log {json} {
+			rotate_size 100
+			rotate_age 24h
+			rotate_keep 7
+		}`, false, []Rule{{
+			NameScope:  ".",
+			OutputFile: "/var/log/coredns.log",
+			Format:     JSONLogFormat,
+			Rotate:     Rotate{Size: 100 * 1024 * 1024, Age: 24 * time.Hour, Keep: 7},
+		}}},
+		{`log {
+			sample 0
+		}`, true, nil},
+		{`log {
+			sample 1.5
+		}`, true, nil},
+		{`log {
+			rotate_size 10
+		}`, true, nil},
+		{`log log.txt {
+			rotate_age -1h
+		}`, true, nil},
+		{`log log.txt {
+			rotate_keep
+		}`, true, nil},
 	}
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.inputLogRules)
@@ -124,6 +163,16 @@ func TestLogParse(t *testing.T) {
 				t.Errorf("Test %d expected %dth LogRule Class to be  %s  , but got %s",
 					i, j, test.expectedLogRules[j].Class, actualLogRule.Class)
 			}
+
+			if actualLogRule.Sample != test.expectedLogRules[j].Sample {
+				t.Errorf("Test %d expected %dth LogRule Sample to be  %f  , but got %f",
+					i, j, test.expectedLogRules[j].Sample, actualLogRule.Sample)
+			}
+
+			if actualLogRule.Rotate != test.expectedLogRules[j].Rotate {
+				t.Errorf("Test %d expected %dth LogRule Rotate to be  %v  , but got %v",
+					i, j, test.expectedLogRules[j].Rotate, actualLogRule.Rotate)
+			}
 		}
 	}
 
@@ -4,6 +4,7 @@ import (
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/metrics/vars"
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/pkg/rcode"
 	"github.com/coredns/coredns/request"
 
@@ -21,14 +22,31 @@ func (m *Metrics) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 		zone = "."
 	}
 
+	if m.top != nil {
+		m.top.Add(state)
+	}
+
 	// Record response to get status code and size of the reply.
 	rw := dnsrecorder.New(w)
 	status, err := plugin.NextOrFailure(m.Name(), m.Next, ctx, rw, r)
 
 	vars.Report(state, zone, rcode.ToString(rw.Rcode), rw.Len, rw.Start)
+	if cached(ctx) {
+		vars.ResponseCached.WithLabelValues(zone).Inc()
+	}
 
 	return status, err
 }
 
 // Name implements the Handler interface.
 func (m *Metrics) Name() string { return "prometheus" }
+
+// cached returns true if the cache plugin answered the query.
+func cached(ctx context.Context) bool {
+	k, ok := metadata.Lookup("cache/hit")
+	if !ok {
+		return false
+	}
+	hit, _ := metadata.Get(ctx, k)
+	return hit == "true"
+}
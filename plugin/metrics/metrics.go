@@ -22,6 +22,14 @@ func init() {
 
 	prometheus.MustRegister(vars.ResponseSize)
 	prometheus.MustRegister(vars.ResponseRcode)
+	prometheus.MustRegister(vars.ResponseCached)
+	prometheus.MustRegister(vars.SocketRequestCount)
+	prometheus.MustRegister(vars.TCPConnections)
+	prometheus.MustRegister(vars.TCPConnectionsRejected)
+	prometheus.MustRegister(vars.ReloadCount)
+	prometheus.MustRegister(vars.ReloadLastSuccess)
+
+	prometheus.MustRegister(topCollector{})
 }
 
 // Metrics holds the prometheus configuration. The metrics' path is fixed to be /metrics
@@ -34,6 +42,9 @@ type Metrics struct {
 	zoneNames []string
 	zoneMap   map[string]bool
 	zoneMu    sync.RWMutex
+
+	// top counts the most frequent clients and query names, nil if that is not enabled.
+	top *top
 }
 
 // AddZone adds zone z to m.
@@ -73,6 +84,7 @@ func (m *Metrics) OnStartup() error {
 
 	m.mux = http.NewServeMux()
 	m.mux.Handle("/metrics", prometheus.Handler())
+	m.mux.HandleFunc("/top", topHandler)
 
 	go func() {
 		http.Serve(m.ln, m.mux)
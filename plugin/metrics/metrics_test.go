@@ -6,6 +6,7 @@ import (
 	"github.com/coredns/coredns/plugin"
 	mtest "github.com/coredns/coredns/plugin/metrics/test"
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
@@ -81,3 +82,33 @@ func TestMetrics(t *testing.T) {
 		}
 	}
 }
+
+var hitKey = metadata.Register("cache/hit")
+
+func TestMetricsCached(t *testing.T) {
+	met := &Metrics{Addr: "localhost:0", zoneMap: make(map[string]bool)}
+	if err := met.OnStartup(); err != nil {
+		t.Fatalf("Failed to start metrics handler: %s", err)
+	}
+	defer met.OnShutdown()
+
+	met.AddZone("example.net.")
+
+	// The cache serves the query.
+	met.Next = plugin.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		metadata.Set(ctx, hitKey, "true")
+		return test.NextHandler(dns.RcodeSuccess, nil).ServeDNS(ctx, w, r)
+	})
+
+	req := new(dns.Msg)
+	req.SetQuestion("example.net.", dns.TypeA)
+	ctx := metadata.NewContext(context.TODO())
+	if _, err := met.ServeDNS(ctx, dnsrecorder.New(&test.ResponseWriter{}), req); err != nil {
+		t.Fatalf("Expected no error, but got %s", err)
+	}
+
+	result := mtest.Scrape(t, "http://"+ListenAddr+"/metrics")
+	if got, labels := mtest.MetricValue("coredns_dns_response_cached_count_total", result); got != "1" || labels["zone"] != "example.net." {
+		t.Errorf("Expected 1 cached response for example.net., got %s %v", got, labels)
+	}
+}
@@ -2,6 +2,8 @@ package metrics
 
 import (
 	"net"
+	"strconv"
+	"time"
 
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
@@ -29,15 +31,26 @@ func setup(c *caddy.Controller) error {
 		return m
 	})
 
-	for a, v := range uniqAddr.a {
-		if v == todo {
+	// The address is only marked done once its listener is started: setup also runs when a
+	// Corefile is validated, and then nothing must be started.
+	c.OnStartup(func() error {
+		if uniqAddr.a[m.Addr] == done {
 			// During restarts we will keep this handler running, BUG.
-			c.OncePerServerBlock(m.OnStartup)
+			return nil
 		}
-		uniqAddr.a[a] = done
-	}
+		if err := m.OnStartup(); err != nil {
+			return err
+		}
+		uniqAddr.a[m.Addr] = done
+		return nil
+	})
 	c.OnFinalShutdown(m.OnShutdown)
 
+	if m.top != nil {
+		c.OnStartup(m.top.OnStartup)
+		c.OnShutdown(m.top.OnShutdown)
+	}
+
 	return nil
 }
 
@@ -72,6 +85,54 @@ func prometheusParse(c *caddy.Controller) (*Metrics, error) {
 		default:
 			return met, c.ArgErr()
 		}
+
+		var (
+			n      int
+			window = defaultTopWindow
+			v4, v6 = defaultTopV4, defaultTopV6
+		)
+		for c.NextBlock() {
+			switch c.Val() {
+			case "top":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return met, c.ArgErr()
+				}
+				n, err = strconv.Atoi(args[0])
+				if err != nil || n <= 0 {
+					return met, c.Errf("invalid top count '%s'", args[0])
+				}
+			case "top_window":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return met, c.ArgErr()
+				}
+				window, err = time.ParseDuration(args[0])
+				if err != nil || window <= 0 {
+					return met, c.Errf("invalid top window '%s'", args[0])
+				}
+			case "top_prefix":
+				args := c.RemainingArgs()
+				if len(args) != 2 {
+					return met, c.ArgErr()
+				}
+				v4, err = strconv.Atoi(args[0])
+				if err != nil || v4 < 0 || v4 > 32 {
+					return met, c.Errf("invalid IPv4 prefix length '%s'", args[0])
+				}
+				v6, err = strconv.Atoi(args[1])
+				if err != nil || v6 < 0 || v6 > 128 {
+					return met, c.Errf("invalid IPv6 prefix length '%s'", args[1])
+				}
+			default:
+				return met, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+		if n > 0 {
+			config := dnsserver.GetConfig(c)
+			server := config.Transport + "://" + net.JoinHostPort(config.ListenHost, config.Port)
+			met.top = newTop(server, n, window, v4, v6)
+		}
 	}
 	return met, err
 }
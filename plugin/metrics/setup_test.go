@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/mholt/caddy"
 )
@@ -11,14 +12,43 @@ func TestPrometheusParse(t *testing.T) {
 		input     string
 		shouldErr bool
 		addr      string
+		top       int
+		window    time.Duration
+		v4, v6    int
 	}{
 		// oks
-		{`prometheus`, false, "localhost:9153"},
-		{`prometheus localhost:53`, false, "localhost:53"},
+		{`prometheus`, false, "localhost:9153", 0, 0, 0, 0},
+		{`prometheus localhost:53`, false, "localhost:53", 0, 0, 0, 0},
+		{`prometheus {
+			top 10
+		}`, false, "localhost:9153", 10, time.Minute, 32, 128},
+		{`prometheus localhost:53 {
+			top 5
+			top_window 10s
+			top_prefix 24 56
+		}`, false, "localhost:53", 5, 10 * time.Second, 24, 56},
 		// fails
-		{`prometheus {}`, true, ""},
-		{`prometheus /foo`, true, ""},
-		{`prometheus a b c`, true, ""},
+		{`prometheus {}`, true, "", 0, 0, 0, 0},
+		{`prometheus /foo`, true, "", 0, 0, 0, 0},
+		{`prometheus a b c`, true, "", 0, 0, 0, 0},
+		{`prometheus {
+			top 0
+		}`, true, "", 0, 0, 0, 0},
+		{`prometheus {
+			top 10
+			top_window -1s
+		}`, true, "", 0, 0, 0, 0},
+		{`prometheus {
+			top 10
+			top_prefix 33 64
+		}`, true, "", 0, 0, 0, 0},
+		{`prometheus {
+			top 10
+			top_prefix 24
+		}`, true, "", 0, 0, 0, 0},
+		{`prometheus {
+			bottom 10
+		}`, true, "", 0, 0, 0, 0},
 	}
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.input)
@@ -38,5 +68,20 @@ func TestPrometheusParse(t *testing.T) {
 		if test.addr != m.Addr {
 			t.Errorf("Test %v: Expected address %s but found: %s", i, test.addr, m.Addr)
 		}
+
+		if test.top == 0 {
+			if m.top != nil {
+				t.Errorf("Test %v: Expected no top tracker", i)
+			}
+			continue
+		}
+		if m.top == nil {
+			t.Errorf("Test %v: Expected a top tracker", i)
+			continue
+		}
+		if m.top.n != test.top || m.top.window != test.window || m.top.v4 != test.v4 || m.top.v6 != test.v6 {
+			t.Errorf("Test %v: Expected top %d, window %s and prefixes %d/%d, got %d, %s and %d/%d", i,
+				test.top, test.window, test.v4, test.v6, m.top.n, m.top.window, m.top.v4, m.top.v6)
+		}
 	}
 }
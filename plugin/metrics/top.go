@@ -0,0 +1,232 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/topk"
+	"github.com/coredns/coredns/request"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// top counts the queries of the most frequent clients and query names of a server, in windows of
+// a fixed length. The counts of the last complete window are exported, so the number of series
+// never exceeds n per server, whatever the number of clients and names.
+type top struct {
+	server string
+	n      int
+	window time.Duration
+	v4, v6 int // prefix lengths the client addresses are grouped by
+
+	sync.Mutex
+	start   time.Time
+	clients *topk.TopK
+	qnames  *topk.TopK
+	last    topWindow
+}
+
+// topWindow holds the most frequent clients and query names of a window.
+type topWindow struct {
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"`
+	Clients []topk.Item `json:"clients"`
+	Qnames  []topk.Item `json:"qnames"`
+}
+
+func newTop(server string, n int, window time.Duration, v4, v6 int) *top {
+	return &top{
+		server:  server,
+		n:       n,
+		window:  window,
+		v4:      v4,
+		v6:      v6,
+		start:   time.Now(),
+		clients: topk.New(n),
+		qnames:  topk.New(n),
+	}
+}
+
+// Add counts the query in state.
+func (t *top) Add(state request.Request) {
+	client := t.prefix(state.IP())
+	qname := strings.ToLower(state.Name())
+
+	t.Lock()
+	defer t.Unlock()
+	t.rotate(time.Now())
+	t.clients.Add(client)
+	t.qnames.Add(qname)
+}
+
+// Windows returns the current window, which is still counting, and the last complete one.
+func (t *top) Windows() (current, last topWindow) {
+	t.Lock()
+	defer t.Unlock()
+	now := time.Now()
+	t.rotate(now)
+	current = topWindow{Start: t.start, End: now, Clients: t.clients.Top(), Qnames: t.qnames.Top()}
+	return current, t.last
+}
+
+// rotate starts a new window when the current one is over. If no queries were counted for a whole
+// window, the last window is empty.
+func (t *top) rotate(now time.Time) {
+	elapsed := now.Sub(t.start)
+	if elapsed < t.window {
+		return
+	}
+	windows := elapsed / t.window
+	end := t.start.Add(windows * t.window)
+
+	t.last = topWindow{Start: end.Add(-t.window), End: end}
+	if windows == 1 {
+		t.last.Clients, t.last.Qnames = t.clients.Top(), t.qnames.Top()
+	}
+	t.start = end
+	t.clients.Reset()
+	t.qnames.Reset()
+}
+
+// prefix returns the network of ip, with the prefix length configured for its family.
+func (t *top) prefix(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ip
+	}
+	if v4 := addr.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(t.v4, 32)), Mask: net.CIDRMask(t.v4, 32)}).String()
+	}
+	return (&net.IPNet{IP: addr.Mask(net.CIDRMask(t.v6, 128)), Mask: net.CIDRMask(t.v6, 128)}).String()
+}
+
+// tops holds the trackers of the running servers. A tracker is added on startup and removed on
+// shutdown, so during a reload the trackers of both the old and the new servers are in it.
+var tops = struct {
+	sync.RWMutex
+	m map[*top]bool
+}{m: make(map[*top]bool)}
+
+func (t *top) OnStartup() error {
+	tops.Lock()
+	tops.m[t] = true
+	tops.Unlock()
+	return nil
+}
+
+func (t *top) OnShutdown() error {
+	tops.Lock()
+	delete(tops.m, t)
+	tops.Unlock()
+	return nil
+}
+
+// lastWindows returns the last complete windows of all trackers, keyed by server. The counts of
+// trackers of the same server are added up, and only the n most frequent are kept, so a reload
+// does not double the number of series.
+func lastWindows() map[string]topWindow {
+	tops.RLock()
+	defer tops.RUnlock()
+
+	ws := make(map[string]topWindow)
+	for t := range tops.m {
+		_, last := t.Windows()
+		w, ok := ws[t.server]
+		if !ok {
+			ws[t.server] = last
+			continue
+		}
+		w.Clients = merge(w.Clients, last.Clients, t.n)
+		w.Qnames = merge(w.Qnames, last.Qnames, t.n)
+		ws[t.server] = w
+	}
+	return ws
+}
+
+// merge adds up the counts in a and b and returns the n most frequent, the most frequent first.
+func merge(a, b []topk.Item, n int) []topk.Item {
+	counts := make(map[string]uint64, len(a)+len(b))
+	for _, it := range a {
+		counts[it.Key] += it.Count
+	}
+	for _, it := range b {
+		counts[it.Key] += it.Count
+	}
+	items := make([]topk.Item, 0, len(counts))
+	for k, c := range counts {
+		items = append(items, topk.Item{Key: k, Count: c})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Key < items[j].Key
+	})
+	if len(items) > n {
+		items = items[:n]
+	}
+	return items
+}
+
+// topCollector exports the last complete windows of the trackers. The series are created on every
+// scrape, so clients and names that drop out of the top are not exported anymore.
+type topCollector struct{}
+
+var (
+	topClientDesc = prometheus.NewDesc(prometheus.BuildFQName(plugin.Namespace, "dns", "top_client_requests"),
+		"Requests of the most frequent client networks in the last complete window, per server.", []string{"server", "client"}, nil)
+	topQnameDesc = prometheus.NewDesc(prometheus.BuildFQName(plugin.Namespace, "dns", "top_qname_requests"),
+		"Requests for the most frequent query names in the last complete window, per server.", []string{"server", "qname"}, nil)
+)
+
+// Describe implements prometheus.Collector.
+func (topCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- topClientDesc
+	ch <- topQnameDesc
+}
+
+// Collect implements prometheus.Collector.
+func (topCollector) Collect(ch chan<- prometheus.Metric) {
+	for server, w := range lastWindows() {
+		for _, it := range w.Clients {
+			ch <- prometheus.MustNewConstMetric(topClientDesc, prometheus.GaugeValue, float64(it.Count), server, it.Key)
+		}
+		for _, it := range w.Qnames {
+			ch <- prometheus.MustNewConstMetric(topQnameDesc, prometheus.GaugeValue, float64(it.Count), server, it.Key)
+		}
+	}
+}
+
+// topHandler serves the current and last windows of every tracker as JSON.
+func topHandler(w http.ResponseWriter, r *http.Request) {
+	type server struct {
+		Server  string    `json:"server"`
+		Window  string    `json:"window"`
+		Current topWindow `json:"current"`
+		Last    topWindow `json:"last"`
+	}
+
+	tops.RLock()
+	servers := []server{}
+	for t := range tops.m {
+		current, last := t.Windows()
+		servers = append(servers, server{Server: t.server, Window: t.window.String(), Current: current, Last: last})
+	}
+	tops.RUnlock()
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Server < servers[j].Server })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(servers)
+}
+
+const (
+	defaultTopWindow = time.Minute
+	defaultTopV4     = 32
+	defaultTopV6     = 128
+)
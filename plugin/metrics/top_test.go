@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/topk"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+func TestTopPrefix(t *testing.T) {
+	tp := newTop("dns://:53", 10, time.Minute, 24, 64)
+	tests := []struct {
+		ip, prefix string
+	}{
+		{"10.0.0.1", "10.0.0.0/24"},
+		{"10.0.1.200", "10.0.1.0/24"},
+		{"2001:db8::1", "2001:db8::/64"},
+		{"::ffff:10.0.0.1", "10.0.0.0/24"},
+	}
+	for i, tc := range tests {
+		if p := tp.prefix(tc.ip); p != tc.prefix {
+			t.Errorf("Test %d: expected prefix %s for %s, got %s", i, tc.prefix, tc.ip, p)
+		}
+	}
+}
+
+func TestTopWindows(t *testing.T) {
+	tp := newTop("dns://:53", 10, time.Minute, 32, 128)
+
+	m := new(dns.Msg)
+	m.SetQuestion("Example.ORG.", dns.TypeA)
+	state := request.Request{W: &test.ResponseWriter{}, Req: m}
+	tp.Add(state)
+	tp.Add(state)
+
+	current, last := tp.Windows()
+	if len(current.Qnames) != 1 || current.Qnames[0].Key != "example.org." || current.Qnames[0].Count != 2 {
+		t.Errorf("Expected example.org. to be counted twice, got %v", current.Qnames)
+	}
+	if len(current.Clients) != 1 || current.Clients[0].Key != "10.240.0.1/32" {
+		t.Errorf("Expected client 10.240.0.1/32, got %v", current.Clients)
+	}
+	if len(last.Qnames) != 0 {
+		t.Errorf("Expected an empty last window, got %v", last.Qnames)
+	}
+
+	// Move the window back in time, so the queries were counted in the previous one.
+	start := tp.start
+	tp.start = start.Add(-time.Minute)
+	current, last = tp.Windows()
+	if len(current.Qnames) != 0 {
+		t.Errorf("Expected an empty current window, got %v", current.Qnames)
+	}
+	if len(last.Qnames) != 1 || last.Qnames[0].Count != 2 {
+		t.Errorf("Expected example.org. to be counted twice in the last window, got %v", last.Qnames)
+	}
+	if !last.End.Equal(start) {
+		t.Errorf("Expected last window to end at %v, got %v", start, last.End)
+	}
+
+	// Nothing was counted in the window before the current one.
+	tp.Add(state)
+	tp.start = tp.start.Add(-2 * time.Minute)
+	_, last = tp.Windows()
+	if len(last.Qnames) != 0 {
+		t.Errorf("Expected an empty last window, got %v", last.Qnames)
+	}
+}
+
+func TestTopMerge(t *testing.T) {
+	a := []topk.Item{{Key: "a.", Count: 5}, {Key: "b.", Count: 3}}
+	b := []topk.Item{{Key: "c.", Count: 4}, {Key: "b.", Count: 3}}
+
+	// Only the 2 most frequent of the combined counts are kept.
+	got := merge(a, b, 2)
+	expected := []topk.Item{{Key: "b.", Count: 6}, {Key: "a.", Count: 5}}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, got)
+		}
+	}
+}
@@ -59,6 +59,46 @@ var (
 		Name:      "response_rcode_count_total",
 		Help:      "Counter of response status codes.",
 	}, []string{"zone", "rcode"})
+
+	ResponseCached = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: subsystem,
+		Name:      "response_cached_count_total",
+		Help:      "Counter of responses served from the cache, per zone.",
+	}, []string{"zone"})
+
+	SocketRequestCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: subsystem,
+		Name:      "socket_request_count_total",
+		Help:      "Counter of DNS requests received per UDP socket of a server.",
+	}, []string{"server", "socket"})
+
+	TCPConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: subsystem,
+		Name:      "tcp_connections",
+		Help:      "Gauge of open TCP connections of a server with connection limits.",
+	}, []string{"server"})
+
+	TCPConnectionsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: subsystem,
+		Name:      "tcp_connections_rejected_total",
+		Help:      "Counter of TCP connections rejected because of a connection limit.",
+	}, []string{"server", "reason"})
+
+	ReloadCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Name:      "reload_count_total",
+		Help:      "Counter of Corefile reloads per result: success, invalid or failed.",
+	}, []string{"result"})
+
+	ReloadLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Name:      "reload_last_success_timestamp_seconds",
+		Help:      "Time of the last successful Corefile reload, in seconds since the epoch.",
+	})
 )
 
 const (
@@ -62,6 +62,14 @@ func (c *Cache) Remove(key uint32) {
 	c.shards[shard].Remove(key)
 }
 
+// Walk calls f for every element in the cache. When f returns true the element is removed.
+// The shard of the element is locked while f runs, f must not call the cache.
+func (c *Cache) Walk(f func(key uint32, el interface{}) bool) {
+	for _, s := range c.shards {
+		s.Walk(f)
+	}
+}
+
 // Len returns the number of elements in the cache.
 func (c *Cache) Len() int {
 	l := 0
@@ -110,6 +118,17 @@ func (s *shard) Evict() {
 	delete(s.items, uint32(key))
 }
 
+// Walk calls f for every element in the shard, and removes those f returns true for.
+func (s *shard) Walk(f func(key uint32, el interface{}) bool) {
+	s.Lock()
+	defer s.Unlock()
+	for k, el := range s.items {
+		if f(k, el) {
+			delete(s.items, k)
+		}
+	}
+}
+
 // Get looks up the element indexed under key.
 func (s *shard) Get(key uint32) (interface{}, bool) {
 	s.RLock()
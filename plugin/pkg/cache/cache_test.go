@@ -29,3 +29,25 @@ func TestCacheLen(t *testing.T) {
 		t.Fatalf("Cache size should %d, got %d", 2, l)
 	}
 }
+
+func TestCacheWalk(t *testing.T) {
+	c := New(4)
+	for i := uint32(0); i < 10; i++ {
+		c.Add(i, int(i))
+	}
+
+	seen := 0
+	c.Walk(func(key uint32, el interface{}) bool {
+		seen++
+		return el.(int)%2 == 0
+	})
+	if seen != 10 {
+		t.Errorf("Expected to walk 10 elements, got %d", seen)
+	}
+	if l := c.Len(); l != 5 {
+		t.Errorf("Expected 5 elements after removing the even ones, got %d", l)
+	}
+	if _, found := c.Get(2); found {
+		t.Errorf("Expected element 2 to be removed")
+	}
+}
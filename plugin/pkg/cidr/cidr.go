@@ -0,0 +1,27 @@
+// Package cidr parses the networks that plugins match client addresses against.
+package cidr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Parse parses a network in CIDR notation, a plain address is taken as a network of one address.
+func Parse(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("not a valid IP address: %s", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid network: %s", s)
+	}
+	return n, nil
+}
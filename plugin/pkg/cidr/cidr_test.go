@@ -0,0 +1,37 @@
+package cidr
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+		err      bool
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8", false},
+		{"10.1.2.3/8", "10.0.0.0/8", false},
+		{"10.1.2.3", "10.1.2.3/32", false},
+		{"2001:db8::/32", "2001:db8::/32", false},
+		{"2001:db8::1", "2001:db8::1/128", false},
+		{"::ffff:10.1.2.3", "10.1.2.3/32", false},
+		{"10.1.2", "", true},
+		{"10.0.0.0/33", "", true},
+		{"example.org", "", true},
+	}
+	for i, tc := range tests {
+		n, err := Parse(tc.s)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Test %d: expected an error for %q, got %s", i, tc.s, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error for %q, got %s", i, tc.s, err)
+			continue
+		}
+		if n.String() != tc.expected {
+			t.Errorf("Test %d: expected %s, got %s", i, tc.expected, n)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Package metadata lets plugins pass facts about a query to the plugins in front of them in the
+// chain, and to the plugins that report on queries, like log and trace.
+//
+// A plugin declares the values it sets with Register, usually in a package level variable, and
+// sets them with Set while handling a query:
+//
+//	var hit = metadata.Register("cache/hit")
+//	...
+//	metadata.Set(ctx, hit, "true")
+//
+// The server adds a store for the values to the context of every query. Names are made up of the
+// name of the plugin and the name of the value, separated by a slash.
+package metadata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Key is a metadata value that was declared with Register.
+type Key struct {
+	name string
+}
+
+// Name returns the name the key was registered with.
+func (k Key) Name() string { return k.name }
+
+var (
+	keysMu sync.RWMutex
+	keys   = map[string]Key{}
+)
+
+// Register declares the metadata value name and returns its key. It panics if name was already
+// registered, or if it is not of the form "plugin/value".
+func Register(name string) Key {
+	if i := strings.Index(name, "/"); i <= 0 || i == len(name)-1 {
+		panic(fmt.Sprintf("metadata: invalid name %q", name))
+	}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if _, ok := keys[name]; ok {
+		panic(fmt.Sprintf("metadata: %q registered twice", name))
+	}
+	k := Key{name: name}
+	keys[name] = k
+	return k
+}
+
+// Keys returns all registered keys, sorted by name.
+func Keys() []Key {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	ks := make([]Key, 0, len(keys))
+	for _, k := range keys {
+		ks = append(ks, k)
+	}
+	sort.Slice(ks, func(i, j int) bool { return ks[i].name < ks[j].name })
+	return ks
+}
+
+// Lookup returns the key registered with name, if any. This lets a plugin read a value set by
+// another plugin, without depending on it.
+func Lookup(name string) (Key, bool) {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	k, ok := keys[name]
+	return k, ok
+}
+
+// NewContext returns a context that stores the metadata of a query. If ctx already has such a
+// store it is returned as is, so values that were set earlier are kept.
+func NewContext(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(storeKey{}).(*store); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, storeKey{}, &store{m: make(map[Key]string)})
+}
+
+// NewQueryContext returns a context with a new, empty store for the metadata of a query, also when
+// ctx already has one. Queries that share a parent context, like those on a gRPC stream, so don't
+// see each other's values.
+func NewQueryContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, storeKey{}, &store{m: make(map[Key]string)})
+}
+
+// Detach returns a context for work that is done while handling a query, but that does not
+// contribute to its response, like refreshing a cache. Values set in it are not seen in ctx.
+func Detach(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(storeKey{}).(*store); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, storeKey{}, &store{m: make(map[Key]string)})
+}
+
+// Set sets the value of k in ctx. It does nothing if ctx was not created with NewContext.
+func Set(ctx context.Context, k Key, value string) {
+	s, ok := ctx.Value(storeKey{}).(*store)
+	if !ok {
+		return
+	}
+	s.Lock()
+	s.m[k] = value
+	s.Unlock()
+}
+
+// Get returns the value of k in ctx, and whether it was set.
+func Get(ctx context.Context, k Key) (string, bool) {
+	s, ok := ctx.Value(storeKey{}).(*store)
+	if !ok {
+		return "", false
+	}
+	s.Lock()
+	defer s.Unlock()
+	v, ok := s.m[k]
+	return v, ok
+}
+
+// Values returns a copy of the values that were set in ctx, keyed by name.
+func Values(ctx context.Context) map[string]string {
+	s, ok := ctx.Value(storeKey{}).(*store)
+	if !ok {
+		return nil
+	}
+	s.Lock()
+	defer s.Unlock()
+	m := make(map[string]string, len(s.m))
+	for k, v := range s.m {
+		m[k.name] = v
+	}
+	return m
+}
+
+type storeKey struct{}
+
+type store struct {
+	sync.Mutex
+	m       map[Key]string
+	serving string // the plugin that is handling the query, see Serving
+}
@@ -0,0 +1,94 @@
+package metadata
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+)
+
+var (
+	hit      = Register("test/hit")
+	upstream = Register("test/upstream")
+)
+
+func TestRegister(t *testing.T) {
+	for _, name := range []string{"test/hit", "hit", "/hit", "test/"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Register(%q) to panic", name)
+				}
+			}()
+			Register(name)
+		}()
+	}
+
+	found := 0
+	for _, k := range Keys() {
+		if k == hit || k == upstream {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected test/hit and test/upstream in the keys, got %v", Keys())
+	}
+
+	if k, ok := Lookup("test/hit"); !ok || k != hit {
+		t.Errorf("Expected Lookup to return test/hit, got %v", k)
+	}
+	if _, ok := Lookup("test/miss"); ok {
+		t.Errorf("Expected Lookup of test/miss to fail")
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	// Without NewContext the value is ignored.
+	ctx := context.TODO()
+	Set(ctx, hit, "true")
+	if _, ok := Get(ctx, hit); ok {
+		t.Errorf("Expected value not to be set")
+	}
+
+	ctx = NewContext(ctx)
+	Set(ctx, hit, "true")
+	if v, ok := Get(ctx, hit); !ok || v != "true" {
+		t.Errorf("Expected value true, got %q", v)
+	}
+	if _, ok := Get(ctx, upstream); ok {
+		t.Errorf("Expected value not to be set")
+	}
+
+	// NewContext keeps the store.
+	if v := Values(NewContext(ctx)); len(v) != 1 || v["test/hit"] != "true" {
+		t.Errorf("Expected values %v, got %v", map[string]string{"test/hit": "true"}, v)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	ctx := NewContext(context.TODO())
+	Set(ctx, hit, "true")
+
+	detached := Detach(ctx)
+	Set(detached, hit, "false")
+	Set(detached, upstream, "127.0.0.1:53")
+
+	if v := Values(ctx); len(v) != 1 || v["test/hit"] != "true" {
+		t.Errorf("Expected values %v, got %v", map[string]string{"test/hit": "true"}, v)
+	}
+}
+
+func TestNewQueryContext(t *testing.T) {
+	stream := NewContext(context.TODO())
+	Set(stream, hit, "true")
+
+	// Queries that share a parent context start with an empty store each.
+	q1, q2 := NewQueryContext(stream), NewQueryContext(stream)
+	Set(q1, upstream, "127.0.0.1:53")
+
+	if v := Values(q1); len(v) != 1 || v["test/upstream"] != "127.0.0.1:53" {
+		t.Errorf("Expected values %v, got %v", map[string]string{"test/upstream": "127.0.0.1:53"}, v)
+	}
+	if v := Values(q2); len(v) != 0 {
+		t.Errorf("Expected no values, got %v", v)
+	}
+}
@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+var (
+	// Plugin is the name of the plugin that wrote the response.
+	Plugin = Register("server/plugin")
+	// ErrorPlugin is the name of the plugin that returned an error, it is set by plugin.NextOrFailure.
+	ErrorPlugin = Register("server/error_plugin")
+)
+
+// Serving records in ctx that the plugin name is now handling the query, and returns the plugin
+// that handled it before. The caller restores that one with Serving when name returns; this is
+// done by plugin.NextOrFailure. It does nothing if ctx was not created with NewContext.
+func Serving(ctx context.Context, name string) string {
+	s, ok := ctx.Value(storeKey{}).(*store)
+	if !ok {
+		return ""
+	}
+	s.Lock()
+	prev := s.serving
+	s.serving = name
+	s.Unlock()
+	return prev
+}
+
+// NewWriter returns a writer that sets Plugin in ctx to the plugin that is serving the query when
+// the response is written through it. Only the writer of the server needs this: a plugin that
+// buffers the response of the plugins after it, like cache, writes it before it returns. If ctx
+// was not created with NewContext, w is returned as is.
+func NewWriter(ctx context.Context, w dns.ResponseWriter) dns.ResponseWriter {
+	if _, ok := ctx.Value(storeKey{}).(*store); !ok {
+		return w
+	}
+	return &writer{ResponseWriter: w, ctx: ctx}
+}
+
+type writer struct {
+	dns.ResponseWriter
+	ctx context.Context
+}
+
+// WriteMsg implements dns.ResponseWriter.
+func (w *writer) WriteMsg(m *dns.Msg) error {
+	w.setPlugin()
+	return w.ResponseWriter.WriteMsg(m)
+}
+
+// Write implements dns.ResponseWriter.
+func (w *writer) Write(buf []byte) (int, error) {
+	w.setPlugin()
+	return w.ResponseWriter.Write(buf)
+}
+
+func (w *writer) setPlugin() {
+	s := w.ctx.Value(storeKey{}).(*store)
+	s.Lock()
+	if s.serving != "" {
+		s.m[Plugin] = s.serving
+	}
+	s.Unlock()
+}
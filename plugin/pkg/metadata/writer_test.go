@@ -0,0 +1,52 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+func TestWriter(t *testing.T) {
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+	m := new(dns.Msg)
+	m.SetReply(r)
+
+	if w := NewWriter(context.TODO(), &test.ResponseWriter{}); w == nil {
+		t.Fatal("Expected a writer")
+	} else if _, ok := w.(*writer); ok {
+		t.Errorf("Expected the writer not to be wrapped without NewContext")
+	}
+
+	// The chain "cache" -> "proxy": proxy writes through cache before proxy returns.
+	ctx := NewContext(context.TODO())
+	w := NewWriter(ctx, &test.ResponseWriter{})
+	Serving(ctx, "cache")
+	prev := Serving(ctx, "proxy")
+	w.WriteMsg(m)
+	Serving(ctx, prev)
+	if v, _ := Get(ctx, Plugin); v != "proxy" {
+		t.Errorf("Expected plugin to be proxy, got %q", v)
+	}
+
+	ctx = NewContext(context.TODO())
+	w = NewWriter(ctx, &test.ResponseWriter{})
+	Serving(ctx, "cache")
+	prev = Serving(ctx, "proxy")
+	Serving(ctx, prev)
+	w.WriteMsg(m)
+	if v, _ := Get(ctx, Plugin); v != "cache" {
+		t.Errorf("Expected plugin to be cache, got %q", v)
+	}
+
+	// Written by the server, not by a plugin.
+	ctx = NewContext(context.TODO())
+	w = NewWriter(ctx, &test.ResponseWriter{})
+	w.WriteMsg(m)
+	if v, ok := Get(ctx, Plugin); ok {
+		t.Errorf("Expected plugin not to be set, got %q", v)
+	}
+}
@@ -0,0 +1,24 @@
+// Package parse contains helpers for parsing the properties of plugins in the Corefile.
+package parse
+
+import (
+	"strconv"
+
+	"github.com/mholt/caddy"
+)
+
+// Int parses the next argument as an integer in the range [min, max].
+func Int(c *caddy.Controller, min, max int) (int, error) {
+	args := c.RemainingArgs()
+	if len(args) != 1 {
+		return 0, c.ArgErr()
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, err
+	}
+	if n < min || n > max {
+		return 0, c.Errf("%s must be in range [%d, %d]: %d", c.Val(), min, max, n)
+	}
+	return n, nil
+}
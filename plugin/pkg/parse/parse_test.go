@@ -0,0 +1,42 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/mholt/caddy"
+)
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+		err      bool
+	}{
+		{"slip 2", 2, false},
+		{"slip 0", 0, false},
+		{"slip 10", 10, false},
+		{"slip 11", 0, true},
+		{"slip -1", 0, true},
+		{"slip two", 0, true},
+		{"slip", 0, true},
+		{"slip 1 2", 0, true},
+	}
+	for i, tc := range tests {
+		c := caddy.NewTestController("dns", tc.input)
+		c.Next()
+		n, err := Int(c, 0, 10)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Test %d: expected an error for %q, got %d", i, tc.input, n)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error for %q, got %s", i, tc.input, err)
+			continue
+		}
+		if n != tc.expected {
+			t.Errorf("Test %d: expected %d, got %d", i, tc.expected, n)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+)
+
+// Listener is a net.Listener whose connections from trusted networks may start with a PROXY
+// protocol header. The RemoteAddr of those connections is the client from the header.
+type Listener struct {
+	net.Listener
+	trusted []*net.IPNet
+}
+
+// NewListener returns a Listener that accepts PROXY protocol headers from the trusted networks.
+func NewListener(l net.Listener, trusted []*net.IPNet) *Listener {
+	return &Listener{Listener: l, trusted: trusted}
+}
+
+// Accept implements the net.Listener interface.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !Trusted(l.trusted, c.RemoteAddr()) {
+		return c, nil
+	}
+	return &Conn{Conn: c, r: bufio.NewReader(c)}, nil
+}
+
+// Conn is a connection that may start with a PROXY protocol header. The header is read on the
+// first Read or RemoteAddr, so Accept does not block on slow clients.
+type Conn struct {
+	net.Conn
+	r *bufio.Reader
+
+	once   sync.Once
+	remote net.Addr
+	err    error
+}
+
+// Read implements the net.Conn interface.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr implements the net.Conn interface.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	return c.remote
+}
+
+func (c *Conn) readHeader() {
+	c.remote = c.Conn.RemoteAddr()
+
+	h, err := c.header()
+	switch err {
+	case nil:
+		if h.Source != nil {
+			c.remote = &net.TCPAddr{IP: h.Source, Port: h.Port}
+		}
+	case ErrNoHeader:
+	default:
+		c.err = err
+		c.Conn.Close()
+	}
+}
+
+// header reads the header from the connection. Nothing is consumed if there is none.
+func (c *Conn) header() (Header, error) {
+	b, err := c.r.Peek(len(sigV1))
+	if err != nil {
+		if !bytes.HasPrefix(sigV1, b) && !bytes.HasPrefix(sigV2, b) {
+			return Header{}, ErrNoHeader
+		}
+		return Header{}, err
+	}
+
+	var hdr []byte
+	switch {
+	case bytes.Equal(b, sigV1):
+		if hdr, err = c.r.ReadSlice('\n'); err != nil {
+			return Header{}, ErrInvalid
+		}
+	case bytes.Equal(b, sigV2[:len(sigV1)]):
+		fixed, err := c.r.Peek(v2HdrLen)
+		if err != nil {
+			return Header{}, err
+		}
+		hdr = make([]byte, v2HdrLen+int(binary.BigEndian.Uint16(fixed[14:16])))
+		if _, err := io.ReadFull(c.r, hdr); err != nil {
+			return Header{}, err
+		}
+	default:
+		return Header{}, ErrNoHeader
+	}
+
+	// The header has been consumed, it must be a valid one now.
+	h, n, err := Parse(hdr)
+	if err == ErrNoHeader || (err == nil && n != len(hdr)) {
+		err = ErrInvalid
+	}
+	return h, err
+}
@@ -0,0 +1,64 @@
+package proxyproto
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestListener(t *testing.T) {
+	tests := []struct {
+		trusted string
+		data    string
+		remote  string // empty for the real remote address
+		rest    string
+		err     bool
+	}{
+		{"127.0.0.0/8", "PROXY TCP4 192.0.2.1 10.0.0.1 4711 53\r\nquery", "192.0.2.1:4711", "query", false},
+		{"127.0.0.0/8", string(v2(1, 0x11, 192, 0, 2, 1, 10, 0, 0, 1, 0x12, 0x67, 0, 53)) + "query", "192.0.2.1:4711", "query", false},
+		{"127.0.0.0/8", "query", "", "query", false},
+		{"127.0.0.0/8", "PROXY TCP4 192.0.2.1\r\nquery", "", "", true},
+		// Not trusted, so the header is not parsed.
+		{"10.0.0.0/8", "PROXY TCP4 192.0.2.1 10.0.0.1 4711 53\r\nquery", "", "PROXY TCP4 192.0.2.1 10.0.0.1 4711 53\r\nquery", false},
+	}
+
+	for i, tc := range tests {
+		_, n, _ := net.ParseCIDR(tc.trusted)
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %s", err)
+		}
+		l := NewListener(ln, []*net.IPNet{n})
+
+		client, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatalf("Failed to dial: %s", err)
+		}
+		client.Write([]byte(tc.data))
+		client.Close()
+
+		c, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Failed to accept: %s", err)
+		}
+		rest, err := ioutil.ReadAll(c)
+		if tc.err {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			}
+		} else {
+			remote := tc.remote
+			if remote == "" {
+				remote = client.LocalAddr().String()
+			}
+			if c.RemoteAddr().String() != remote {
+				t.Errorf("Test %d: expected remote address %s, got %s", i, remote, c.RemoteAddr())
+			}
+			if string(rest) != tc.rest {
+				t.Errorf("Test %d: expected %q, got %q", i, tc.rest, rest)
+			}
+		}
+		c.Close()
+		l.Close()
+	}
+}
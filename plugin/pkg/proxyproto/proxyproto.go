@@ -0,0 +1,142 @@
+// Package proxyproto implements the receiving side of the PROXY protocol, version 1 and 2. Load
+// balancers use it to pass on the address of the client to the server behind them.
+// See https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt.
+package proxyproto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Header is a parsed PROXY protocol header.
+type Header struct {
+	// Source is the address of the client, nil when the header does not carry one (a health
+	// check from the load balancer itself).
+	Source net.IP
+	Port   int
+}
+
+var (
+	sigV1 = []byte("PROXY ")
+	sigV2 = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+var (
+	// ErrNoHeader is returned when the data does not start with a PROXY protocol header.
+	ErrNoHeader = errors.New("no PROXY protocol header")
+	// ErrInvalid is returned for malformed headers.
+	ErrInvalid = errors.New("invalid PROXY protocol header")
+)
+
+const (
+	maxV1Len = 107 // including the CRLF
+	v2HdrLen = 16
+)
+
+// Parse parses the header at the start of b, it returns the header and its length. If b does not
+// start with a header ErrNoHeader is returned.
+func Parse(b []byte) (Header, int, error) {
+	switch {
+	case bytes.HasPrefix(b, sigV2):
+		if len(b) < v2HdrLen {
+			return Header{}, 0, ErrInvalid
+		}
+		n := v2HdrLen + int(binary.BigEndian.Uint16(b[14:16]))
+		if len(b) < n {
+			return Header{}, 0, ErrInvalid
+		}
+		h, err := parseV2(b[:n])
+		return h, n, err
+	case bytes.HasPrefix(b, sigV1):
+		end := bytes.Index(b, []byte("\r\n"))
+		if end < 0 || end+2 > maxV1Len {
+			return Header{}, 0, ErrInvalid
+		}
+		h, err := parseV1(string(b[:end]))
+		return h, end + 2, err
+	}
+	return Header{}, 0, ErrNoHeader
+}
+
+// parseV1 parses the human readable header, without its CRLF.
+func parseV1(line string) (Header, error) {
+	fields := strings.Split(line, " ")
+	if len(fields) < 2 {
+		return Header{}, ErrInvalid
+	}
+	switch fields[1] {
+	case "UNKNOWN":
+		return Header{}, nil
+	case "TCP4", "TCP6":
+	default:
+		return Header{}, ErrInvalid
+	}
+	// PROXY TCP4 SRC DST SRCPORT DSTPORT
+	if len(fields) != 6 {
+		return Header{}, ErrInvalid
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return Header{}, ErrInvalid
+	}
+	port, err := strconv.Atoi(fields[4])
+	if err != nil || port < 0 || port > 65535 {
+		return Header{}, ErrInvalid
+	}
+	return Header{Source: ip, Port: port}, nil
+}
+
+// parseV2 parses the binary header, b holds the complete header.
+func parseV2(b []byte) (Header, error) {
+	if b[12]>>4 != 2 {
+		return Header{}, ErrInvalid
+	}
+	switch b[12] & 0xF {
+	case 0: // LOCAL
+		return Header{}, nil
+	case 1: // PROXY
+	default:
+		return Header{}, ErrInvalid
+	}
+
+	addr := b[v2HdrLen:]
+	switch b[13] >> 4 {
+	case 1: // AF_INET
+		if len(addr) < 12 {
+			return Header{}, ErrInvalid
+		}
+		return Header{Source: net.IP(dup(addr[:4])), Port: int(binary.BigEndian.Uint16(addr[8:10]))}, nil
+	case 2: // AF_INET6
+		if len(addr) < 36 {
+			return Header{}, ErrInvalid
+		}
+		return Header{Source: net.IP(dup(addr[:16])), Port: int(binary.BigEndian.Uint16(addr[32:34]))}, nil
+	}
+	// AF_UNSPEC or AF_UNIX: no address we can use.
+	return Header{}, nil
+}
+
+func dup(b []byte) []byte { return append([]byte(nil), b...) }
+
+// Trusted returns true if addr is in one of the networks.
+func Trusted(networks []*net.IPNet, addr net.Addr) bool {
+	var ip net.IP
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		ip = a.IP
+	case *net.UDPAddr:
+		ip = a.IP
+	default:
+		return false
+	}
+	for _, n := range networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
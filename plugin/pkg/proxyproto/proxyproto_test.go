@@ -0,0 +1,77 @@
+package proxyproto
+
+import (
+	"net"
+	"testing"
+)
+
+// v2 returns a version 2 header for the command and family, followed by addr.
+func v2(command, family byte, addr ...byte) []byte {
+	b := append([]byte(nil), sigV2...)
+	b = append(b, 0x20|command, family, byte(len(addr)>>8), byte(len(addr)))
+	return append(b, addr...)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		err    error
+		source string
+		port   int
+		n      int
+	}{
+		{[]byte("PROXY TCP4 192.0.2.1 10.0.0.1 4711 53\r\nrest"), nil, "192.0.2.1", 4711, 39},
+		{[]byte("PROXY TCP6 2001:db8::1 2001:db8::2 4711 53\r\n"), nil, "2001:db8::1", 4711, 44},
+		{[]byte("PROXY UNKNOWN\r\n"), nil, "", 0, 15},
+		{[]byte("PROXY TCP4 2001:db8::1 10.0.0.1 4711 53\r\n"), ErrInvalid, "", 0, 0},
+		{[]byte("PROXY TCP4 192.0.2.1 10.0.0.1 4711\r\n"), ErrInvalid, "", 0, 0},
+		{[]byte("PROXY TCP4 192.0.2.1 10.0.0.1 4711 53"), ErrInvalid, "", 0, 0},
+		{[]byte("PROXY UDP4 192.0.2.1 10.0.0.1 4711 53\r\n"), ErrInvalid, "", 0, 0},
+		{v2(1, 0x12, 192, 0, 2, 1, 10, 0, 0, 1, 0x12, 0x67, 0, 53), nil, "192.0.2.1", 4711, 28},
+		{v2(1, 0x22,
+			0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+			0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2,
+			0x12, 0x67, 0, 53), nil, "2001:db8::1", 4711, 52},
+		{v2(0, 0x00), nil, "", 0, 16},
+		{v2(1, 0x12, 192, 0, 2, 1), ErrInvalid, "", 0, 0},
+		{v2(2, 0x12, 192, 0, 2, 1, 10, 0, 0, 1, 0x12, 0x67, 0, 53), ErrInvalid, "", 0, 0},
+		{v2(1, 0x12)[:14], ErrInvalid, "", 0, 0},
+		{[]byte{0, 1, 2, 3}, ErrNoHeader, "", 0, 0},
+	}
+
+	for i, tc := range tests {
+		h, n, err := Parse(tc.data)
+		if err != tc.err {
+			t.Errorf("Test %d: expected error %v, got %v", i, tc.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if tc.source == "" {
+			if h.Source != nil {
+				t.Errorf("Test %d: expected no source, got %s", i, h.Source)
+			}
+		} else if !h.Source.Equal(net.ParseIP(tc.source)) {
+			t.Errorf("Test %d: expected source %s, got %s", i, tc.source, h.Source)
+		}
+		if h.Port != tc.port {
+			t.Errorf("Test %d: expected port %d, got %d", i, tc.port, h.Port)
+		}
+		if n != tc.n {
+			t.Errorf("Test %d: expected length %d, got %d", i, tc.n, n)
+		}
+	}
+}
+
+func TestTrusted(t *testing.T) {
+	_, n, _ := net.ParseCIDR("10.0.0.0/8")
+	nets := []*net.IPNet{n}
+
+	if !Trusted(nets, &net.UDPAddr{IP: net.ParseIP("10.1.2.3")}) {
+		t.Errorf("Expected 10.1.2.3 to be trusted")
+	}
+	if Trusted(nets, &net.TCPAddr{IP: net.ParseIP("192.0.2.1")}) {
+		t.Errorf("Expected 192.0.2.1 not to be trusted")
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
+	"golang.org/x/net/context"
 )
 
 // Replacer is a type which can replace placeholder
@@ -45,6 +47,9 @@ func New(r *dns.Msg, rr *dnsrecorder.Recorder, emptyValue string) Replacer {
 			"{size}":   strconv.Itoa(req.Len()),
 			"{remote}": req.IP(),
 			"{port}":   req.Port(),
+			// Set from the response below, if there is one.
+			"{answer}": "",
+			"{ttl}":    "",
 		},
 		emptyValue: emptyValue,
 	}
@@ -58,6 +63,8 @@ func New(r *dns.Msg, rr *dnsrecorder.Recorder, emptyValue string) Replacer {
 		rep.replacements["{duration}"] = time.Since(rr.Start).String()
 		if rr.Msg != nil {
 			rep.replacements[headerReplacer+"rflags}"] = flagsToString(rr.Msg.MsgHdr)
+			rep.replacements["{answer}"] = answerToString(rr.Msg.Answer)
+			rep.replacements["{ttl}"] = minTTL(rr.Msg)
 		}
 	}
 
@@ -106,6 +113,44 @@ func (r replacer) Set(key, value string) {
 	r.replacements["{"+key+"}"] = value
 }
 
+// Apply sets the placeholders for the metadata values in ctx in rep: {cache/hit} for the value
+// registered as "cache/hit". Registered values that are not set in ctx are empty.
+func Apply(ctx context.Context, rep Replacer) {
+	values := metadata.Values(ctx)
+	for _, k := range metadata.Keys() {
+		rep.Set(k.Name(), values[k.Name()])
+	}
+}
+
+// answerToString returns the type and rdata of each RR in answer, separated by commas.
+func answerToString(answer []dns.RR) string {
+	rrs := make([]string, len(answer))
+	for i, rr := range answer {
+		rdata := strings.TrimPrefix(rr.String(), rr.Header().String())
+		rrs[i] = dns.TypeToString[rr.Header().Rrtype] + " " + rdata
+	}
+	return strings.Join(rrs, ",")
+}
+
+// minTTL returns the lowest TTL of the RRs in m, or the empty string if there are none.
+func minTTL(m *dns.Msg) string {
+	min := int64(-1)
+	for _, section := range [][]dns.RR{m.Answer, m.Ns, m.Extra} {
+		for _, rr := range section {
+			if rr.Header().Rrtype == dns.TypeOPT {
+				continue
+			}
+			if ttl := int64(rr.Header().Ttl); min < 0 || ttl < min {
+				min = ttl
+			}
+		}
+	}
+	if min < 0 {
+		return ""
+	}
+	return strconv.FormatInt(min, 10)
+}
+
 func boolToString(b bool) string {
 	if b {
 		return "true"
@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
+	"golang.org/x/net/context"
 )
 
 func TestNewReplacer(t *testing.T) {
@@ -36,6 +38,28 @@ func TestNewReplacer(t *testing.T) {
 	}
 }
 
+func TestAnswer(t *testing.T) {
+	w := dnsrecorder.New(&test.ResponseWriter{})
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Answer = []dns.RR{
+		test.CNAME("example.org. 3600 IN CNAME www.example.org."),
+		test.A("www.example.org. 300 IN A 127.0.0.1"),
+	}
+	m.Ns = []dns.RR{test.NS("example.org. 3600 IN NS a.iana-servers.net.")}
+	m.SetEdns0(4096, false)
+	w.WriteMsg(m)
+
+	rep := New(r, w, "-")
+	if got := rep.Replace("{answer} {ttl}"); got != "CNAME www.example.org.,A 127.0.0.1 300" {
+		t.Errorf("Expected %q, got %q", "CNAME www.example.org.,A 127.0.0.1 300", got)
+	}
+}
+
 func TestSet(t *testing.T) {
 	w := dnsrecorder.New(&test.ResponseWriter{})
 
@@ -59,3 +83,24 @@ func TestSet(t *testing.T) {
 		t.Error("Expected size replacement failed")
 	}
 }
+
+var testKey = metadata.Register("test/acl")
+
+func TestApply(t *testing.T) {
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+
+	// Registered values that are not set are empty.
+	ctx := metadata.NewContext(context.TODO())
+	rep := New(r, dnsrecorder.New(&test.ResponseWriter{}), "-")
+	Apply(ctx, rep)
+	if got := rep.Replace("{test/acl}"); got != "-" {
+		t.Errorf("Expected %q, got %q", "-", got)
+	}
+
+	metadata.Set(ctx, testKey, "refuse")
+	Apply(ctx, rep)
+	if got := rep.Replace("{name} {test/acl}"); got != "example.org. refuse" {
+		t.Errorf("Expected %q, got %q", "example.org. refuse", got)
+	}
+}
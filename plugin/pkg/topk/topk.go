@@ -0,0 +1,133 @@
+// Package topk finds the most frequent keys in a stream, in constant memory.
+//
+// The count of every key is estimated with a count-min sketch, which never underestimates. The K
+// keys with the highest estimates are kept in a min-heap; a key that is not in it replaces the
+// least frequent one as soon as its estimate is higher.
+package topk
+
+import (
+	"container/heap"
+	"hash/fnv"
+	"sort"
+)
+
+// Item is a key and its estimated count.
+type Item struct {
+	Key   string `json:"key"`
+	Count uint64 `json:"count"`
+}
+
+// TopK tracks the K most frequent keys. It is not safe for concurrent use.
+type TopK struct {
+	k      int
+	sketch [depth][]uint64
+	top    items
+	index  map[string]int // position of a key in top
+}
+
+// New returns a TopK that tracks the k most frequent keys.
+func New(k int) *TopK {
+	t := &TopK{k: k}
+	for i := range t.sketch {
+		t.sketch[i] = make([]uint64, width)
+	}
+	t.Reset()
+	return t
+}
+
+// Add counts one occurrence of key.
+func (t *TopK) Add(key string) {
+	count := t.count(key)
+
+	if i, ok := t.index[key]; ok {
+		t.top[i].Count = count
+		heap.Fix(t, i)
+		return
+	}
+	if len(t.top) < t.k {
+		heap.Push(t, Item{Key: key, Count: count})
+		return
+	}
+	if t.k > 0 && count > t.top[0].Count {
+		delete(t.index, t.top[0].Key)
+		t.top[0] = Item{Key: key, Count: count}
+		t.index[key] = 0
+		heap.Fix(t, 0)
+	}
+}
+
+// Top returns the most frequent keys, the most frequent first.
+func (t *TopK) Top() []Item {
+	top := make([]Item, len(t.top))
+	copy(top, t.top)
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Key < top[j].Key
+	})
+	return top
+}
+
+// Reset forgets all counts.
+func (t *TopK) Reset() {
+	for i := range t.sketch {
+		for j := range t.sketch[i] {
+			t.sketch[i][j] = 0
+		}
+	}
+	t.top = items{}
+	t.index = make(map[string]int, t.k)
+}
+
+// count increments the counters of key in the sketch and returns its estimated count: the lowest
+// of its counters.
+func (t *TopK) count(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	// Derive a hash for each row from the two halves of the 64 bit hash.
+	h1, h2 := uint32(sum), uint32(sum>>32)
+
+	var min uint64
+	for i := range t.sketch {
+		j := (h1 + uint32(i)*h2) % width
+		t.sketch[i][j]++
+		if c := t.sketch[i][j]; i == 0 || c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// items is a min-heap, ordered by count.
+type items []Item
+
+// The heap.Interface is implemented by TopK, so the index can be kept up to date.
+
+func (t *TopK) Len() int           { return len(t.top) }
+func (t *TopK) Less(i, j int) bool { return t.top[i].Count < t.top[j].Count }
+
+func (t *TopK) Swap(i, j int) {
+	t.top[i], t.top[j] = t.top[j], t.top[i]
+	t.index[t.top[i].Key] = i
+	t.index[t.top[j].Key] = j
+}
+
+func (t *TopK) Push(x interface{}) {
+	it := x.(Item)
+	t.index[it.Key] = len(t.top)
+	t.top = append(t.top, it)
+}
+
+func (t *TopK) Pop() interface{} {
+	it := t.top[len(t.top)-1]
+	t.top = t.top[:len(t.top)-1]
+	delete(t.index, it.Key)
+	return it
+}
+
+const (
+	depth = 4    // rows in the sketch, each with its own hash
+	width = 2048 // counters per row
+)
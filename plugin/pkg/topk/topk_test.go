@@ -0,0 +1,74 @@
+package topk
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	tk := New(3)
+
+	// a few heavy hitters among many keys that are seen once.
+	for i := 0; i < 1000; i++ {
+		tk.Add(fmt.Sprintf("key%d", i))
+		if i%2 == 0 {
+			tk.Add("a")
+		}
+		if i%4 == 0 {
+			tk.Add("b")
+		}
+		if i%10 == 0 {
+			tk.Add("c")
+		}
+	}
+
+	top := tk.Top()
+	if len(top) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(top))
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		if top[i].Key != key {
+			t.Errorf("Expected item %d to be %q, got %q", i, key, top[i].Key)
+		}
+	}
+	// The sketch never underestimates, and with this few keys it should be close.
+	for _, x := range []struct {
+		count uint64
+		item  Item
+	}{{500, top[0]}, {250, top[1]}, {100, top[2]}} {
+		if x.item.Count < x.count || x.item.Count > x.count+10 {
+			t.Errorf("Expected count of %q to be about %d, got %d", x.item.Key, x.count, x.item.Count)
+		}
+	}
+}
+
+func TestTopKFewKeys(t *testing.T) {
+	tk := New(10)
+	tk.Add("b")
+	tk.Add("a")
+	tk.Add("b")
+
+	top := tk.Top()
+	expected := []Item{{"b", 2}, {"a", 1}}
+	if len(top) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, top)
+	}
+	for i := range expected {
+		if top[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, top)
+		}
+	}
+}
+
+func TestTopKReset(t *testing.T) {
+	tk := New(2)
+	tk.Add("a")
+	tk.Add("a")
+	tk.Reset()
+	tk.Add("a")
+
+	top := tk.Top()
+	if len(top) != 1 || top[0].Count != 1 {
+		t.Errorf("Expected a single item with count 1, got %v", top)
+	}
+}
@@ -2,7 +2,9 @@ package trace
 
 import (
 	"github.com/coredns/coredns/plugin"
+
 	ot "github.com/opentracing/opentracing-go"
+	"golang.org/x/net/context"
 )
 
 // Trace holds the tracer and endpoint info
@@ -10,3 +12,17 @@ type Trace interface {
 	plugin.Handler
 	Tracer() ot.Tracer
 }
+
+// ContextWithParent returns a context that carries parent, the context of a span that was started
+// by the client of a query. The span of the query is made a child of it.
+func ContextWithParent(ctx context.Context, parent ot.SpanContext) context.Context {
+	return context.WithValue(ctx, parentKey{}, parent)
+}
+
+// ParentFromContext returns the parent that was added to ctx with ContextWithParent, or nil.
+func ParentFromContext(ctx context.Context) ot.SpanContext {
+	parent, _ := ctx.Value(parentKey{}).(ot.SpanContext)
+	return parent
+}
+
+type parentKey struct{}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+
 	"github.com/miekg/dns"
 	ot "github.com/opentracing/opentracing-go"
 	"golang.org/x/net/context"
@@ -68,20 +70,68 @@ func (f HandlerFunc) Name() string { return "handlerfunc" }
 func Error(name string, err error) error { return fmt.Errorf("%s/%s: %s", "plugin", name, err) }
 
 // NextOrFailure calls next.ServeDNS when next is not nill, otherwise it will return, a ServerFailure
-// and a nil error.
+// and a nil error. It records next as the plugin that is serving the query, for the metadata.Plugin
+// value, and sets metadata.ErrorPlugin to the name of the plugin that returned an error first.
 func NextOrFailure(name string, next Handler, ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	if next != nil {
+		prev := metadata.Serving(ctx, next.Name())
 		if span := ot.SpanFromContext(ctx); span != nil {
 			child := span.Tracer().StartSpan(next.Name(), ot.ChildOf(span.Context()))
-			defer child.Finish()
+			TagSpan(child, r)
 			ctx = ot.ContextWithSpan(ctx, child)
+			sw := &spanWriter{ResponseWriter: w, rcode: -1}
+			w = sw
+			defer func() { sw.finish(child) }()
+		}
+		rcode, err := next.ServeDNS(ctx, w, r)
+		metadata.Serving(ctx, prev)
+		if err != nil {
+			setErrorPlugin(ctx, next.Name())
 		}
-		return next.ServeDNS(ctx, w, r)
+		return rcode, err
 	}
 
+	setErrorPlugin(ctx, name)
 	return dns.RcodeServerFailure, Error(name, errors.New("no next plugin found"))
 }
 
+// TagSpan tags span with the name and type of the query r.
+func TagSpan(span ot.Span, r *dns.Msg) {
+	if len(r.Question) == 0 {
+		return
+	}
+	span.SetTag("qname", r.Question[0].Name)
+	span.SetTag("qtype", dns.Type(r.Question[0].Qtype).String())
+}
+
+// spanWriter records the rcode of the response written by a plugin, to tag its span with.
+type spanWriter struct {
+	dns.ResponseWriter
+	rcode int // -1 until a response is written
+}
+
+// WriteMsg implements dns.ResponseWriter.
+func (w *spanWriter) WriteMsg(m *dns.Msg) error {
+	w.rcode = m.Rcode
+	return w.ResponseWriter.WriteMsg(m)
+}
+
+// finish tags span with the rcode of the response, if one was written, and finishes it.
+func (w *spanWriter) finish(span ot.Span) {
+	if w.rcode >= 0 {
+		span.SetTag("rcode", dns.RcodeToString[w.rcode])
+	}
+	span.Finish()
+}
+
+// setErrorPlugin sets metadata.ErrorPlugin to name, unless a plugin further down the chain
+// already did so. That plugin returned the error first, the others pass it on.
+func setErrorPlugin(ctx context.Context, name string) {
+	if _, ok := metadata.Get(ctx, metadata.ErrorPlugin); !ok {
+		metadata.Set(ctx, metadata.ErrorPlugin, name)
+	}
+}
+
 // ClientWrite returns true if the response has been written to the client.
 // Each plugin to adhire to this protocol.
 func ClientWrite(rcode int) bool {
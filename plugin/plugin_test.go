@@ -1 +1,52 @@
 package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// named is a handler that calls the next one, or fails with err if there is none.
+type named struct {
+	name string
+	next Handler
+	err  error
+}
+
+func (n named) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	if n.next == nil {
+		return dns.RcodeServerFailure, n.err
+	}
+	return NextOrFailure(n.name, n.next, ctx, w, r)
+}
+
+func (n named) Name() string { return n.name }
+
+func TestNextOrFailureErrorPlugin(t *testing.T) {
+	r := new(dns.Msg)
+	r.SetQuestion("example.org.", dns.TypeA)
+
+	// errors -> cache -> proxy, where proxy fails.
+	proxy := named{name: "proxy", err: errors.New("unreachable backend")}
+	cache := named{name: "cache", next: proxy}
+
+	ctx := metadata.NewContext(context.TODO())
+	if _, err := NextOrFailure("errors", cache, ctx, &test.ResponseWriter{}, r); err == nil {
+		t.Fatal("Expected an error")
+	}
+	if v, _ := metadata.Get(ctx, metadata.ErrorPlugin); v != "proxy" {
+		t.Errorf("Expected error plugin to be proxy, got %q", v)
+	}
+
+	// Without a next plugin, the caller is blamed.
+	ctx = metadata.NewContext(context.TODO())
+	NextOrFailure("cache", nil, ctx, &test.ResponseWriter{}, r)
+	if v, _ := metadata.Get(ctx, metadata.ErrorPlugin); v != "cache" {
+		t.Errorf("Expected error plugin to be cache, got %q", v)
+	}
+}
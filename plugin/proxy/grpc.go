@@ -19,6 +19,7 @@ import (
 type grpcClient struct {
 	dialOpts []grpc.DialOption
 	clients  map[string]pb.DnsServiceClient
+	streams  map[string]*grpcStream
 	conns    []*grpc.ClientConn
 	upstream *staticUpstream
 }
@@ -32,11 +33,20 @@ func newGrpcClient(tls *tls.Config, u *staticUpstream) *grpcClient {
 		g.dialOpts = append(g.dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(tls)))
 	}
 	g.clients = map[string]pb.DnsServiceClient{}
+	g.streams = map[string]*grpcStream{}
 
 	return g
 }
 
 func (g *grpcClient) Exchange(ctx context.Context, addr string, state request.Request) (*dns.Msg, error) {
+	if s, ok := g.streams[addr]; ok {
+		d, err := s.exchange(ctx, state.Req)
+		if err != errStreamUnsupported {
+			return d, err
+		}
+		// The upstream only implements Query.
+	}
+
 	msg, err := state.Req.Pack()
 	if err != nil {
 		return nil, err
@@ -60,6 +70,10 @@ func (g *grpcClient) Protocol() string { return "grpc" }
 
 func (g *grpcClient) OnShutdown(p *Proxy) error {
 	g.clients = map[string]pb.DnsServiceClient{}
+	for _, s := range g.streams {
+		s.close()
+	}
+	g.streams = map[string]*grpcStream{}
 	for i, conn := range g.conns {
 		err := conn.Close()
 		if err != nil {
@@ -88,7 +102,9 @@ func (g *grpcClient) OnStartup(p *Proxy) error {
 		if err != nil {
 			log.Printf("[WARNING] Skipping gRPC host '%s' due to Dial error: %s\n", host.Name, err)
 		} else {
-			g.clients[host.Name] = pb.NewDnsServiceClient(conn)
+			client := pb.NewDnsServiceClient(conn)
+			g.clients[host.Name] = client
+			g.streams[host.Name] = newGrpcStream(client)
 			g.conns = append(g.conns, conn)
 		}
 	}
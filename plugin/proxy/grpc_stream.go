@@ -0,0 +1,183 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/pb"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// grpcStream multiplexes the queries to one upstream over a single QueryStream call. Each query
+// is sent with an ID that is unique on the stream, and the reply is matched to it by that ID.
+type grpcStream struct {
+	client pb.DnsServiceClient
+
+	sendMu sync.Mutex // Send must not be called concurrently
+
+	sync.Mutex
+	stream      pb.DnsService_QueryStreamClient
+	cancel      context.CancelFunc
+	pending     map[uint16]chan *dns.Msg
+	unsupported bool // upstream doesn't implement QueryStream
+}
+
+var (
+	errStreamUnsupported = errors.New("upstream does not support streaming")
+	errStreamClosed      = errors.New("stream closed")
+	errStreamFull        = errors.New("no free message ID on stream")
+)
+
+func newGrpcStream(client pb.DnsServiceClient) *grpcStream {
+	return &grpcStream{client: client, pending: map[uint16]chan *dns.Msg{}}
+}
+
+// exchange sends req on the stream, opening it if needed, and waits for the reply. It returns
+// errStreamUnsupported when the upstream doesn't implement QueryStream.
+func (s *grpcStream) exchange(ctx context.Context, req *dns.Msg) (*dns.Msg, error) {
+	msg, err := req.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	if s.unsupported {
+		s.Unlock()
+		return nil, errStreamUnsupported
+	}
+	if s.stream == nil {
+		if err := s.open(); err != nil {
+			s.Unlock()
+			return nil, err
+		}
+	}
+	stream := s.stream
+	id, ok := s.newID()
+	if !ok {
+		s.Unlock()
+		return nil, errStreamFull
+	}
+	ch := make(chan *dns.Msg, 1)
+	s.pending[id] = ch
+	s.Unlock()
+
+	binary.BigEndian.PutUint16(msg, id)
+
+	s.sendMu.Lock()
+	err = stream.Send(&pb.DnsPacket{Msg: msg})
+	s.sendMu.Unlock()
+	if err != nil {
+		s.forget(id)
+		return nil, err
+	}
+
+	timer := time.NewTimer(defaultTimeout)
+	defer timer.Stop()
+
+	select {
+	case reply, ok := <-ch:
+		if !ok {
+			s.Lock()
+			defer s.Unlock()
+			if s.unsupported {
+				return nil, errStreamUnsupported
+			}
+			return nil, errStreamClosed
+		}
+		reply.Id = req.Id
+		return reply, nil
+	case <-timer.C:
+		s.forget(id)
+		return nil, context.DeadlineExceeded
+	case <-ctx.Done():
+		s.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+// open opens the stream and starts receiving from it. The lock must be held.
+func (s *grpcStream) open() error {
+	// The stream outlives the query that opens it, so it doesn't use that query's context.
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := s.client.QueryStream(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	s.stream = stream
+	s.cancel = cancel
+	go s.receive(stream)
+	return nil
+}
+
+// receive hands the replies on stream to the queries waiting for them. When the stream fails all
+// pending queries fail with it, and the next query opens a new stream.
+func (s *grpcStream) receive(stream pb.DnsService_QueryStreamClient) {
+	for {
+		in, err := stream.Recv()
+		if err != nil {
+			s.Lock()
+			if grpc.Code(err) == codes.Unimplemented {
+				s.unsupported = true
+			}
+			if s.stream == stream {
+				s.cancel()
+				s.stream = nil
+			}
+			for id, ch := range s.pending {
+				close(ch)
+				delete(s.pending, id)
+			}
+			s.Unlock()
+			return
+		}
+
+		d := new(dns.Msg)
+		if err := d.Unpack(in.Msg); err != nil {
+			continue
+		}
+
+		s.Lock()
+		if ch, ok := s.pending[d.Id]; ok {
+			delete(s.pending, d.Id)
+			ch <- d
+		}
+		s.Unlock()
+	}
+}
+
+// newID returns a message ID that is not in use on the stream. The lock must be held.
+func (s *grpcStream) newID() (uint16, bool) {
+	if len(s.pending) > 0xFFFF {
+		return 0, false
+	}
+	for {
+		id := dns.Id()
+		if _, ok := s.pending[id]; !ok {
+			return id, true
+		}
+	}
+}
+
+// forget stops waiting for the reply to id.
+func (s *grpcStream) forget(id uint16) {
+	s.Lock()
+	delete(s.pending, id)
+	s.Unlock()
+}
+
+// close closes the stream, failing all pending queries.
+func (s *grpcStream) close() {
+	s.Lock()
+	if s.stream != nil {
+		s.cancel()
+		s.stream = nil
+	}
+	s.Unlock()
+}
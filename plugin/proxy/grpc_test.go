@@ -1,11 +1,19 @@
 package proxy
 
 import (
+	"context"
+	"io"
+	"net"
 	"testing"
 	"time"
 
+	"github.com/coredns/coredns/pb"
 	"github.com/coredns/coredns/plugin/pkg/healthcheck"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
 
+	"github.com/miekg/dns"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/grpclog"
 )
 
@@ -60,6 +68,141 @@ func TestStartupShutdown(t *testing.T) {
 	}
 }
 
+func TestGrpcExchange(t *testing.T) {
+	for _, streaming := range []bool{true, false} {
+		srv := &echoServer{}
+		addr, stop := startEchoServer(t, srv, streaming)
+
+		upstream := &staticUpstream{
+			from: ".",
+			HealthCheck: healthcheck.HealthCheck{
+				Hosts: []*healthcheck.UpstreamHost{{Name: addr}},
+			},
+		}
+		g := newGrpcClient(nil, upstream)
+		upstream.ex = g
+
+		p := &Proxy{}
+		if err := g.OnStartup(p); err != nil {
+			t.Fatalf("Error starting grpc client exchanger: %s", err)
+		}
+
+		for i := 0; i < 3; i++ {
+			m := new(dns.Msg)
+			m.SetQuestion("example.org.", dns.TypeA)
+			state := request.Request{W: &test.ResponseWriter{}, Req: m}
+
+			reply, err := g.Exchange(context.TODO(), addr, state)
+			if err != nil {
+				t.Fatalf("Streaming %t: expected no error, got %s", streaming, err)
+			}
+			if reply.Id != m.Id {
+				t.Errorf("Streaming %t: expected ID %d, got %d", streaming, m.Id, reply.Id)
+			}
+			if !reply.Response {
+				t.Errorf("Streaming %t: expected a response", streaming)
+			}
+		}
+
+		if streaming && (srv.queries != 0 || srv.streams != 1) {
+			t.Errorf("Expected 1 stream and no unary queries, got %d and %d", srv.streams, srv.queries)
+		}
+		if !streaming && srv.queries != 3 {
+			t.Errorf("Expected 3 unary queries, got %d", srv.queries)
+		}
+
+		g.OnShutdown(p)
+		stop()
+	}
+}
+
+// echoServer answers every query with an empty reply.
+type echoServer struct {
+	queries int
+	streams int
+}
+
+func (e *echoServer) Query(ctx context.Context, in *pb.DnsPacket) (*pb.DnsPacket, error) {
+	e.queries++
+	return echo(in)
+}
+
+func (e *echoServer) QueryStream(stream pb.DnsService_QueryStreamServer) error {
+	e.streams++
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		out, err := echo(in)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *echoServer) Transfer(in *pb.DnsPacket, stream pb.DnsService_TransferServer) error {
+	out, err := echo(in)
+	if err != nil {
+		return err
+	}
+	return stream.Send(out)
+}
+
+func echo(in *pb.DnsPacket) (*pb.DnsPacket, error) {
+	m := new(dns.Msg)
+	if err := m.Unpack(in.Msg); err != nil {
+		return nil, err
+	}
+	r := new(dns.Msg)
+	r.SetReply(m)
+	msg, err := r.Pack()
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DnsPacket{Msg: msg}, nil
+}
+
+// startEchoServer serves srv on a local port. Without streaming only the Query method is
+// registered, like a server built before QueryStream existed.
+func startEchoServer(t *testing.T, srv *echoServer, streaming bool) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+
+	s := grpc.NewServer()
+	if streaming {
+		pb.RegisterDnsServiceServer(s, srv)
+	} else {
+		s.RegisterService(&grpc.ServiceDesc{
+			ServiceName: "coredns.dns.DnsService",
+			HandlerType: (*interface{})(nil),
+			Methods: []grpc.MethodDesc{
+				{
+					MethodName: "Query",
+					Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+						in := new(pb.DnsPacket)
+						if err := dec(in); err != nil {
+							return nil, err
+						}
+						return srv.Query(ctx, in)
+					},
+				},
+			},
+		}, srv)
+	}
+	go s.Serve(l)
+
+	return l.Addr().String(), s.Stop
+}
+
 // discard is a Logger that outputs nothing.
 type discard struct{}
 
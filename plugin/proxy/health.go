@@ -0,0 +1,36 @@
+package proxy
+
+import "sync/atomic"
+
+// Health describes the health of an upstream host.
+type Health struct {
+	From  string `json:"from"`
+	Host  string `json:"host"`
+	Down  bool   `json:"down"`
+	Fails int32  `json:"fails"`
+	Conns int64  `json:"conns"`
+}
+
+// Health returns the health of the hosts of all upstreams of p.
+func (p Proxy) Health() []Health {
+	health := []Health{}
+	if p.Upstreams == nil {
+		return health
+	}
+	for _, u := range *p.Upstreams {
+		su, ok := u.(*staticUpstream)
+		if !ok {
+			continue
+		}
+		for _, host := range su.Hosts {
+			health = append(health, Health{
+				From:  su.From(),
+				Host:  host.Name,
+				Down:  host.Down(),
+				Fails: atomic.LoadInt32(&host.Fails),
+				Conns: atomic.LoadInt64(&host.Conns),
+			})
+		}
+	}
+	return health
+}
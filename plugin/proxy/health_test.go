@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/healthcheck"
+)
+
+func TestHealth(t *testing.T) {
+	upstream := &staticUpstream{
+		from: "example.org.",
+		HealthCheck: healthcheck.HealthCheck{
+			Hosts: []*healthcheck.UpstreamHost{
+				{Name: "10.0.0.1:53"},
+				{Name: "10.0.0.2:53", Fails: 2},
+			},
+		},
+	}
+	p := Proxy{Upstreams: &[]Upstream{upstream}}
+
+	health := p.Health()
+	if len(health) != 2 {
+		t.Fatalf("Expected 2 hosts, got %d", len(health))
+	}
+	if h := health[0]; h.From != "example.org." || h.Host != "10.0.0.1:53" || h.Down {
+		t.Errorf("Expected healthy host 10.0.0.1:53, got %+v", h)
+	}
+	if h := health[1]; h.Host != "10.0.0.2:53" || !h.Down || h.Fails != 2 {
+		t.Errorf("Expected host 10.0.0.2:53 to be down with 2 fails, got %+v", h)
+	}
+
+	if n := len(Proxy{}.Health()); n != 0 {
+		t.Errorf("Expected no hosts, got %d", n)
+	}
+}
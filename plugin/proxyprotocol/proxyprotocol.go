@@ -0,0 +1,11 @@
+// Package proxyprotocol allows the listeners to accept PROXY protocol headers from load balancers.
+package proxyprotocol
+
+import "github.com/mholt/caddy"
+
+func init() {
+	caddy.RegisterPlugin("proxyprotocol", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
@@ -0,0 +1,32 @@
+package proxyprotocol
+
+import (
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+
+	"github.com/mholt/caddy"
+)
+
+func setup(c *caddy.Controller) error {
+	config := dnsserver.GetConfig(c)
+
+	if config.ProxyProtocol != nil {
+		return plugin.Error("proxyprotocol", c.Errf("PROXY protocol already configured for this server instance"))
+	}
+
+	for c.Next() {
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return plugin.Error("proxyprotocol", c.ArgErr())
+		}
+		for _, a := range args {
+			n, err := cidr.Parse(a)
+			if err != nil {
+				return plugin.Error("proxyprotocol", err)
+			}
+			config.ProxyProtocol = append(config.ProxyProtocol, n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package proxyprotocol
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupProxyProtocol(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		networks  []string
+	}{
+		{`proxyprotocol 10.0.0.0/8 192.168.1.1 2001:db8::/32`, false, []string{"10.0.0.0/8", "192.168.1.1/32", "2001:db8::/32"}},
+		{`proxyprotocol`, true, nil},
+		{`proxyprotocol 10.0.0.bla`, true, nil},
+		{`proxyprotocol 10.0.0.0/33`, true, nil},
+		{`proxyprotocol 10.0.0.0/8
+		proxyprotocol 192.168.0.0/16`, false, []string{"10.0.0.0/8", "192.168.0.0/16"}},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		err := setup(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		cfg := dnsserver.GetConfig(c)
+		if len(cfg.ProxyProtocol) != len(test.networks) {
+			t.Fatalf("Test %d: Expected %d networks, got %d", i, len(test.networks), len(cfg.ProxyProtocol))
+		}
+		for j, n := range cfg.ProxyProtocol {
+			if n.String() != test.networks[j] {
+				t.Errorf("Test %d: Expected network %s, got %s", i, test.networks[j], n)
+			}
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Run fetches the document until Stop is called.
+func (reg *Registry) Run() {
+	for {
+		var err error
+		if reg.stream {
+			err = reg.follow()
+		} else {
+			err = reg.poll()
+		}
+		if reg.ctx.Err() != nil {
+			return
+		}
+
+		wait := reg.refresh
+		if reg.stream {
+			// Reconnect when the stream ends.
+			wait = retryInterval
+		}
+		if err != nil {
+			log.Printf("[ERROR] Failed to fetch registry from %s: %s", reg.endpoint, err)
+			reg.failed(err)
+			if wait == 0 {
+				wait = retryInterval
+			}
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-reg.ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops fetching the document.
+func (reg *Registry) Stop() error {
+	reg.cancel()
+	return nil
+}
+
+// poll fetches the document once. The ETag of the last document is sent along, so the endpoint can
+// tell us nothing has changed, or hold on to the request until something does.
+func (reg *Registry) poll() error {
+	req, err := http.NewRequest("GET", reg.endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	reg.RLock()
+	if reg.etag != "" {
+		req.Header.Set("If-None-Match", reg.etag)
+	}
+	reg.RUnlock()
+
+	resp, err := reg.client.Do(req.WithContext(reg.ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		reg.Lock()
+		reg.updated = time.Now()
+		reg.err = nil
+		reg.Unlock()
+		return nil
+	case http.StatusOK:
+		return reg.load(resp.Body, resp.Header.Get("ETag"))
+	}
+	return fmt.Errorf("unexpected status: %s", resp.Status)
+}
+
+// follow reads the endpoint as a stream of server-sent events, each event carries a complete
+// document. It returns when the stream ends.
+func (reg *Registry) follow() error {
+	req, err := http.NewRequest("GET", reg.endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := reg.client.Do(req.WithContext(reg.ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var data bytes.Buffer
+	rd := bufio.NewReader(resp.Body)
+	for {
+		line, err := rd.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		switch {
+		case len(line) == 0:
+			// End of the event.
+			if data.Len() == 0 {
+				continue
+			}
+			if err := reg.load(&data, ""); err != nil {
+				log.Printf("[WARNING] Failed to load registry event from %s: %s", reg.endpoint, err)
+			}
+			data.Reset()
+		case bytes.HasPrefix(line, []byte("data:")):
+			line = bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(line)
+		}
+		// Comments and other fields are ignored.
+	}
+}
+
+// load decodes the document from rd and makes it the current one.
+func (reg *Registry) load(rd io.Reader, etag string) error {
+	var entries []entry
+	if err := json.NewDecoder(rd).Decode(&entries); err != nil {
+		return err
+	}
+	entries, err := normalize(entries)
+	if err != nil {
+		return err
+	}
+
+	reg.Lock()
+	reg.entries = entries
+	reg.etag = etag
+	reg.updated = time.Now()
+	reg.err = nil
+	reg.Unlock()
+	return nil
+}
+
+// failed records that fetching the document failed.
+func (reg *Registry) failed(err error) {
+	reg.Lock()
+	reg.err = err
+	reg.Unlock()
+}
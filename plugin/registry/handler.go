@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// ServeDNS implements the plugin.Handler interface.
+func (reg *Registry) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	opt := plugin.Options{}
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(reg.Zones).Matches(state.Name())
+	if zone == "" {
+		return plugin.NextOrFailure(reg.Name(), reg.Next, ctx, w, r)
+	}
+
+	var (
+		records, extra []dns.RR
+		err            error
+	)
+	switch state.Type() {
+	case "A":
+		records, err = plugin.A(reg, zone, state, nil, opt)
+	case "AAAA":
+		records, err = plugin.AAAA(reg, zone, state, nil, opt)
+	case "TXT":
+		records, err = plugin.TXT(reg, zone, state, opt)
+	case "CNAME":
+		records, err = plugin.CNAME(reg, zone, state, opt)
+	case "PTR":
+		records, err = plugin.PTR(reg, zone, state, opt)
+	case "MX":
+		records, extra, err = plugin.MX(reg, zone, state, opt)
+	case "SRV":
+		records, extra, err = plugin.SRV(reg, zone, state, opt)
+	case "SOA":
+		records, err = plugin.SOA(reg, zone, state, opt)
+	case "NS":
+		if state.Name() == zone {
+			records, extra, err = plugin.NS(reg, zone, state, opt)
+			break
+		}
+		fallthrough
+	default:
+		// Do a fake A lookup, so we can distinguish between NODATA and NXDOMAIN
+		_, err = plugin.A(reg, zone, state, nil, opt)
+	}
+
+	if reg.IsNameError(err) {
+		if reg.Fallthrough {
+			return plugin.NextOrFailure(reg.Name(), reg.Next, ctx, w, r)
+		}
+		// Make err nil when returning here, so we don't log spam for NXDOMAIN.
+		return plugin.BackendError(reg, zone, dns.RcodeNameError, state, nil /* err */, opt)
+	}
+	if err != nil {
+		return plugin.BackendError(reg, zone, dns.RcodeServerFailure, state, err, opt)
+	}
+
+	if len(records) == 0 {
+		return plugin.BackendError(reg, zone, dns.RcodeSuccess, state, err, opt)
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative, m.RecursionAvailable, m.Compress = true, true, true
+	m.Answer = append(m.Answer, records...)
+	m.Extra = append(m.Extra, extra...)
+
+	m = dnsutil.Dedup(m)
+	state.SizeAndDo(m)
+	m, _ = state.Scrub(m)
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// Name implements the Handler interface.
+func (reg *Registry) Name() string { return "registry" }
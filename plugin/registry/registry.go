@@ -0,0 +1,158 @@
+// Package registry provides a backend plugin that serves services read from an HTTP endpoint.
+package registry
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/etcd/msg"
+	"github.com/coredns/coredns/plugin/proxy"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// Registry is a plugin that serves the services from a JSON document that is fetched from an
+// HTTP endpoint.
+type Registry struct {
+	Next        plugin.Handler
+	Fallthrough bool
+	Zones       []string
+	Proxy       proxy.Proxy // Proxy for looking up names during the resolution process
+
+	endpoint string
+	client   *http.Client
+	refresh  time.Duration // time between polls, 0 for long-polling
+	stream   bool          // follow the endpoint as a server-sent events stream
+	stale    bool          // keep serving the last document when the endpoint fails
+	staleFor time.Duration // how long to keep serving it, 0 is forever
+	ttl      uint32
+
+	sync.RWMutex
+	entries []entry
+	etag    string
+	updated time.Time // last time the endpoint confirmed the document
+	err     error     // error of the last fetch, if it failed
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// entry is a service in the document, it is a msg.Service with the name it should be served under.
+type entry struct {
+	Name string `json:"name"`
+	msg.Service
+}
+
+var (
+	errNoItems   = errors.New("no items found")
+	errNotLoaded = errors.New("registry not loaded yet")
+)
+
+// New returns a new Registry that fetches services from endpoint.
+func New(zones []string, endpoint string) *Registry {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Registry{
+		Zones:    zones,
+		endpoint: endpoint,
+		client:   http.DefaultClient,
+		refresh:  defaultRefresh,
+		ttl:      defaultTTL,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Services implements the ServiceBackend interface.
+func (reg *Registry) Services(state request.Request, exact bool, opt plugin.Options) (services []msg.Service, err error) {
+	services, err = reg.Records(state, exact)
+	if err != nil {
+		return
+	}
+
+	services = msg.Group(services)
+	return
+}
+
+// Reverse implements the ServiceBackend interface.
+func (reg *Registry) Reverse(state request.Request, exact bool, opt plugin.Options) (services []msg.Service, err error) {
+	return reg.Services(state, exact, opt)
+}
+
+// Lookup implements the ServiceBackend interface.
+func (reg *Registry) Lookup(state request.Request, name string, typ uint16) (*dns.Msg, error) {
+	return reg.Proxy.Lookup(state, name, typ)
+}
+
+// IsNameError implements the ServiceBackend interface.
+func (reg *Registry) IsNameError(err error) bool { return err == errNoItems }
+
+// Records returns the services with the name from state. If exact is false services with names
+// below it are returned as well, just like the etcd plugin does.
+func (reg *Registry) Records(state request.Request, exact bool) ([]msg.Service, error) {
+	entries, err := reg.current()
+	if err != nil {
+		return nil, err
+	}
+
+	name := state.Name()
+	var services []msg.Service
+	for _, e := range entries {
+		if e.Name != name && (exact || !dns.IsSubDomain(name, e.Name)) {
+			continue
+		}
+		serv := e.Service
+		serv.Key = msg.Path(e.Name, "coredns")
+		if serv.TTL == 0 {
+			serv.TTL = reg.ttl
+		}
+		services = append(services, serv)
+	}
+	if len(services) == 0 {
+		return nil, errNoItems
+	}
+	return services, nil
+}
+
+// current returns the entries from the last document. If the last fetch failed an error is returned,
+// unless stale documents may be served.
+func (reg *Registry) current() ([]entry, error) {
+	reg.RLock()
+	defer reg.RUnlock()
+
+	if reg.err == nil {
+		if reg.updated.IsZero() {
+			return nil, errNotLoaded
+		}
+		return reg.entries, nil
+	}
+	if !reg.stale || reg.updated.IsZero() {
+		return nil, reg.err
+	}
+	if reg.staleFor > 0 && time.Since(reg.updated) > reg.staleFor {
+		return nil, reg.err
+	}
+	return reg.entries, nil
+}
+
+// normalize lower cases and fully qualifies the names of the entries.
+func normalize(entries []entry) ([]entry, error) {
+	for i := range entries {
+		if entries[i].Name == "" {
+			return nil, errors.New("entry without a name")
+		}
+		entries[i].Name = dns.Fqdn(strings.ToLower(entries[i].Name))
+	}
+	return entries, nil
+}
+
+const (
+	defaultRefresh = 30 * time.Second
+	defaultTTL     = 30
+	retryInterval  = 1 * time.Second
+)
@@ -0,0 +1,216 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+const document = `[
+  {"name": "a.web.example.org", "host": "10.0.0.1", "port": 80},
+  {"name": "b.web.example.org", "host": "10.0.0.2", "port": 80},
+  {"name": "db.example.org", "host": "2001:db8::1", "port": 5432, "ttl": 10},
+  {"name": "txt.example.org", "text": "hello"},
+  {"name": "Alias.Example.org", "host": "web.example.net"}
+]`
+
+var dnsTestCases = []test.Case{
+	{
+		Qname: "web.example.org.", Qtype: dns.TypeA,
+		Answer: []dns.RR{
+			test.A("web.example.org.	30	IN	A	10.0.0.1"),
+			test.A("web.example.org.	30	IN	A	10.0.0.2"),
+		},
+	},
+	{
+		Qname: "web.example.org.", Qtype: dns.TypeSRV,
+		Answer: []dns.RR{
+			test.SRV("web.example.org.	30	IN	SRV	0 50 80 a.web.example.org."),
+			test.SRV("web.example.org.	30	IN	SRV	0 50 80 b.web.example.org."),
+		},
+		Extra: []dns.RR{
+			test.A("a.web.example.org.	30	IN	A	10.0.0.1"),
+			test.A("b.web.example.org.	30	IN	A	10.0.0.2"),
+		},
+	},
+	{
+		Qname: "db.example.org.", Qtype: dns.TypeAAAA,
+		Answer: []dns.RR{
+			test.AAAA("db.example.org.	10	IN	AAAA	2001:db8::1"),
+		},
+	},
+	{
+		Qname: "txt.example.org.", Qtype: dns.TypeTXT,
+		Answer: []dns.RR{
+			test.TXT("txt.example.org.	30	IN	TXT	\"hello\""),
+		},
+	},
+	{
+		Qname: "alias.example.org.", Qtype: dns.TypeCNAME,
+		Answer: []dns.RR{
+			test.CNAME("alias.example.org.	30	IN	CNAME	web.example.net."),
+		},
+	},
+	{
+		Qname: "db.example.org.", Qtype: dns.TypeA,
+		Ns: []dns.RR{
+			test.SOA("example.org.	300	IN	SOA	ns.dns.example.org. hostmaster.example.org. 1502308051 7200 1800 86400 60"),
+		},
+	},
+	{
+		Qname: "nothere.example.org.", Qtype: dns.TypeA,
+		Rcode: dns.RcodeNameError,
+		Ns: []dns.RR{
+			test.SOA("example.org.	300	IN	SOA	ns.dns.example.org. hostmaster.example.org. 1502308051 7200 1800 86400 60"),
+		},
+	},
+}
+
+// endpoint serves document with an ETag, or fails when down is set.
+type endpoint struct {
+	sync.Mutex
+	down    bool
+	matches int // requests with a matching If-None-Match
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.Lock()
+	defer e.Unlock()
+	if e.down {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Header.Get("If-None-Match") == `"1"` {
+		e.matches++
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", `"1"`)
+	fmt.Fprint(w, document)
+}
+
+func TestRegistry(t *testing.T) {
+	ep := &endpoint{}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	reg := New([]string{"example.org."}, srv.URL)
+	reg.refresh = 10 * time.Millisecond
+
+	if err := reg.poll(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	checkCases(t, reg, dnsTestCases)
+
+	// The ETag is sent along.
+	if err := reg.poll(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if ep.matches != 1 {
+		t.Errorf("Expected 1 conditional request, got %d", ep.matches)
+	}
+
+	// The endpoint fails: SERVFAIL unless stale documents may be served.
+	ep.Lock()
+	ep.down = true
+	ep.Unlock()
+	if err := reg.poll(); err == nil {
+		t.Fatalf("Expected error, got none")
+	} else {
+		reg.failed(err)
+	}
+
+	r := new(dns.Msg)
+	r.SetQuestion("web.example.org.", dns.TypeA)
+	rec := dnsrecorder.New(&test.ResponseWriter{})
+	reg.ServeDNS(context.TODO(), rec, r)
+	if rec.Msg.Rcode != dns.RcodeServerFailure {
+		t.Errorf("Expected SERVFAIL, got %s", dns.RcodeToString[rec.Msg.Rcode])
+	}
+
+	reg.stale = true
+	checkCases(t, reg, dnsTestCases)
+
+	// ... for a limited time.
+	reg.staleFor = time.Millisecond
+	time.Sleep(2 * time.Millisecond)
+	rec = dnsrecorder.New(&test.ResponseWriter{})
+	reg.ServeDNS(context.TODO(), rec, r)
+	if rec.Msg.Rcode != dns.RcodeServerFailure {
+		t.Errorf("Expected SERVFAIL, got %s", dns.RcodeToString[rec.Msg.Rcode])
+	}
+}
+
+func TestRegistryNotLoaded(t *testing.T) {
+	reg := New([]string{"example.org."}, "http://127.0.0.1:0")
+
+	r := new(dns.Msg)
+	r.SetQuestion("web.example.org.", dns.TypeA)
+	rec := dnsrecorder.New(&test.ResponseWriter{})
+	reg.ServeDNS(context.TODO(), rec, r)
+	if rec.Msg.Rcode != dns.RcodeServerFailure {
+		t.Errorf("Expected SERVFAIL, got %s", dns.RcodeToString[rec.Msg.Rcode])
+	}
+}
+
+func TestRegistryStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "text/event-stream" {
+			http.Error(w, "not a stream", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": a comment\n\n")
+		fmt.Fprint(w, "data: [{\"name\": \"web.example.org\",\n")
+		fmt.Fprint(w, "data: \"host\": \"10.0.0.9\"}]\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	reg := New([]string{"example.org."}, srv.URL)
+	reg.stream = true
+	go reg.Run()
+	defer reg.Stop()
+
+	for i := 0; i < 100; i++ {
+		if _, err := reg.current(); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	checkCases(t, reg, []test.Case{
+		{
+			Qname: "web.example.org.", Qtype: dns.TypeA,
+			Answer: []dns.RR{
+				test.A("web.example.org.	30	IN	A	10.0.0.9"),
+			},
+		},
+	})
+}
+
+func checkCases(t *testing.T, reg *Registry, cases []test.Case) {
+	ctx := context.TODO()
+	for i, tc := range cases {
+		m := tc.Msg()
+
+		rec := dnsrecorder.New(&test.ResponseWriter{})
+		if _, err := reg.ServeDNS(ctx, rec, m); err != nil {
+			t.Errorf("Test %d, expected no error, got %v", i, err)
+			continue
+		}
+
+		test.SortAndCheck(t, rec.Msg, tc)
+	}
+}
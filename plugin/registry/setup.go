@@ -0,0 +1,139 @@
+package registry
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	mwtls "github.com/coredns/coredns/plugin/pkg/tls"
+	"github.com/coredns/coredns/plugin/proxy"
+
+	"github.com/mholt/caddy"
+)
+
+func init() {
+	caddy.RegisterPlugin("registry", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	reg, err := registryParse(c)
+	if err != nil {
+		return plugin.Error("registry", err)
+	}
+
+	c.OnStartup(func() error {
+		go reg.Run()
+		return nil
+	})
+
+	c.OnShutdown(func() error {
+		return reg.Stop()
+	})
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		reg.Next = next
+		return reg
+	})
+
+	return nil
+}
+
+func registryParse(c *caddy.Controller) (*Registry, error) {
+	var (
+		reg       *Registry
+		tlsConfig *tls.Config
+		err       error
+	)
+	for c.Next() {
+		args := c.RemainingArgs()
+		if len(args) == 0 {
+			return nil, c.ArgErr()
+		}
+		zones := args[1:]
+		if len(zones) == 0 {
+			zones = make([]string, len(c.ServerBlockKeys))
+			copy(zones, c.ServerBlockKeys)
+		}
+		for i, str := range zones {
+			zones[i] = plugin.Host(str).Normalize()
+		}
+		reg = New(zones, args[0])
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "refresh":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if d < 0 {
+					return nil, c.Errf("refresh can not be negative: %s", d)
+				}
+				reg.refresh = d
+			case "stream":
+				reg.stream = true
+			case "stale":
+				reg.stale = true
+				if c.NextArg() {
+					d, err := time.ParseDuration(c.Val())
+					if err != nil {
+						return nil, err
+					}
+					if d < 0 {
+						return nil, c.Errf("stale can not be negative: %s", d)
+					}
+					reg.staleFor = d
+				}
+			case "ttl":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				t, err := strconv.Atoi(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if t < 0 || t > 3600 {
+					return nil, c.Errf("ttl must be in range [0, 3600]: %d", t)
+				}
+				reg.ttl = uint32(t)
+			case "tls": // cert key cacertfile
+				args := c.RemainingArgs()
+				tlsConfig, err = mwtls.NewTLSConfigFromArgs(args...)
+				if err != nil {
+					return nil, err
+				}
+			case "upstream":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				ups, err := dnsutil.ParseHostPortOrFile(args...)
+				if err != nil {
+					return nil, err
+				}
+				reg.Proxy = proxy.NewLookup(ups)
+			case "fallthrough":
+				reg.Fallthrough = true
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+	if reg == nil {
+		return nil, c.ArgErr()
+	}
+
+	reg.client = &http.Client{Transport: mwtls.NewHTTPSTransport(tlsConfig)}
+
+	return reg, nil
+}
@@ -0,0 +1,92 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupRegistry(t *testing.T) {
+	tests := []struct {
+		input              string
+		shouldErr          bool
+		expectedEndpoint   string
+		expectedRefresh    time.Duration
+		expectedStream     bool
+		expectedStale      bool
+		expectedErrContent string // substring from the expected error. Empty for positive cases.
+	}{
+		// positive
+		{
+			`registry http://registry.example.org/dns`, false, "http://registry.example.org/dns", defaultRefresh, false, false, "",
+		},
+		{
+			`registry http://registry.example.org/dns example.org {
+	refresh 0s
+	stale
+}
+`, false, "http://registry.example.org/dns", 0, false, true, "",
+		},
+		{
+			`registry http://registry.example.org/dns {
+	stream
+	stale 1h
+	ttl 60
+	fallthrough
+}
+`, false, "http://registry.example.org/dns", defaultRefresh, true, true, "",
+		},
+		// negative
+		{
+			`registry`, true, "", 0, false, false, "Wrong argument count",
+		},
+		{
+			`registry http://registry.example.org/dns {
+	refresh -1s
+}
+`, true, "", 0, false, false, "can not be negative",
+		},
+		{
+			`registry http://registry.example.org/dns {
+	etag
+}
+`, true, "", 0, false, false, "unknown property 'etag'",
+		},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		reg, err := registryParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found %s for input %s", i, err, test.input)
+		}
+
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+				continue
+			}
+
+			if !strings.Contains(err.Error(), test.expectedErrContent) {
+				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
+			}
+			continue
+		}
+
+		if reg.endpoint != test.expectedEndpoint {
+			t.Errorf("Test %d: Expected endpoint %q, got %q", i, test.expectedEndpoint, reg.endpoint)
+		}
+		if reg.refresh != test.expectedRefresh {
+			t.Errorf("Test %d: Expected refresh %s, got %s", i, test.expectedRefresh, reg.refresh)
+		}
+		if reg.stream != test.expectedStream {
+			t.Errorf("Test %d: Expected stream %t, got %t", i, test.expectedStream, reg.stream)
+		}
+		if reg.stale != test.expectedStale {
+			t.Errorf("Test %d: Expected stale %t, got %t", i, test.expectedStale, reg.stale)
+		}
+	}
+}
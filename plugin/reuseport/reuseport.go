@@ -0,0 +1,11 @@
+// Package reuseport allows a server to open multiple UDP sockets on the same address.
+package reuseport
+
+import "github.com/mholt/caddy"
+
+func init() {
+	caddy.RegisterPlugin("reuseport", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
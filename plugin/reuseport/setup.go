@@ -0,0 +1,41 @@
+package reuseport
+
+import (
+	"runtime"
+	"strconv"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/mholt/caddy"
+)
+
+func setup(c *caddy.Controller) error {
+	config := dnsserver.GetConfig(c)
+
+	for c.Next() {
+		if config.ReusePort != 0 {
+			return plugin.Error("reuseport", c.Errf("reuseport already configured for this server instance"))
+		}
+
+		args := c.RemainingArgs()
+		switch len(args) {
+		case 0:
+			config.ReusePort = runtime.NumCPU()
+		case 1:
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				return plugin.Error("reuseport", err)
+			}
+			if n < 1 || n > maxSockets {
+				return plugin.Error("reuseport", c.Errf("number of sockets must be in range [1, %d]: %d", maxSockets, n))
+			}
+			config.ReusePort = n
+		default:
+			return plugin.Error("reuseport", c.ArgErr())
+		}
+	}
+	return nil
+}
+
+const maxSockets = 1024
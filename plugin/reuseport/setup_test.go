@@ -0,0 +1,46 @@
+package reuseport
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupReusePort(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		sockets   int
+	}{
+		{`reuseport`, false, runtime.NumCPU()},
+		{`reuseport 4`, false, 4},
+		{`reuseport 0`, true, 0},
+		{`reuseport 2000`, true, 0},
+		{`reuseport many`, true, 0},
+		{`reuseport 2 4`, true, 0},
+		{`reuseport 2
+		reuseport 4`, true, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		err := setup(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+
+		if cfg := dnsserver.GetConfig(c); cfg.ReusePort != test.sockets {
+			t.Errorf("Test %d: Expected %d sockets, got %d", i, test.sockets, cfg.ReusePort)
+		}
+	}
+}
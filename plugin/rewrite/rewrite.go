@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 
 	"github.com/miekg/dns"
 
@@ -24,6 +25,9 @@ const (
 	RewriteStatus
 )
 
+// nameKey is the name of the query before it was rewritten, it is only set when the name changed.
+var nameKey = metadata.Register("rewrite/name")
+
 // Rewrite is plugin to rewrite requests internally before being handled.
 type Rewrite struct {
 	Next     plugin.Handler
@@ -34,9 +38,13 @@ type Rewrite struct {
 // ServeDNS implements the plugin.Handler interface.
 func (rw Rewrite) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	wr := NewResponseReverter(w, r)
+	name := r.Question[0].Name
 	for _, rule := range rw.Rules {
 		switch result := rule.Rewrite(w, r); result {
 		case RewriteDone:
+			if r.Question[0].Name != name {
+				metadata.Set(ctx, nameKey, name)
+			}
 			if rw.noRevert {
 				return plugin.NextOrFailure(rw.Name(), rw.Next, ctx, w, r)
 			}
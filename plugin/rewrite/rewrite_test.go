@@ -7,6 +7,7 @@ import (
 
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/miekg/dns"
@@ -137,15 +138,20 @@ func TestRewrite(t *testing.T) {
 		{"a.nl.", dns.TypeANY, dns.ClassCHAOS, "a.nl.", dns.TypeANY, dns.ClassINET},
 	}
 
-	ctx := context.TODO()
 	for i, tc := range tests {
 		m := new(dns.Msg)
 		m.SetQuestion(tc.from, tc.fromT)
 		m.Question[0].Qclass = tc.fromC
 
+		ctx := metadata.NewContext(context.TODO())
 		rec := dnsrecorder.New(&test.ResponseWriter{})
 		rw.ServeDNS(ctx, rec, m)
 
+		name, ok := metadata.Get(ctx, nameKey)
+		if rewritten := tc.from != tc.to; ok != rewritten || (ok && name != tc.from) {
+			t.Errorf("Test %d: Expected rewrite/name to be set to %q only when rewritten, got %q", i, tc.from, name)
+		}
+
 		resp := rec.Msg
 		if resp.Question[0].Name != tc.to {
 			t.Errorf("Test %d: Expected Name to be %q but was %q", i, tc.to, resp.Question[0].Name)
@@ -0,0 +1,20 @@
+package rrl
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LimitedCount counts the responses that exceeded their rate, by zone, category and what
+// was done with them.
+var LimitedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: plugin.Namespace,
+	Subsystem: "rrl",
+	Name:      "limited_responses_total",
+	Help:      "Counter of responses that exceeded their rate limit.",
+}, []string{"zone", "category", "action"})
+
+func init() {
+	prometheus.MustRegister(LimitedCount)
+}
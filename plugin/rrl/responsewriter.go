@@ -0,0 +1,59 @@
+package rrl
+
+import (
+	"log"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/response"
+
+	"github.com/miekg/dns"
+)
+
+// ResponseWriter applies the rate limits to the responses that are written.
+type ResponseWriter struct {
+	dns.ResponseWriter
+	rrl    *RRL
+	req    *dns.Msg
+	zone   string
+	prefix []byte
+}
+
+// WriteMsg implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) WriteMsg(res *dns.Msg) error {
+	t, _ := response.Typify(res, time.Now().UTC())
+	c, ok := classify(t)
+	if !ok || w.rrl.rates[c] == 0 || len(w.req.Question) == 0 {
+		return w.ResponseWriter.WriteMsg(res)
+	}
+
+	allowed, limited, unlogged := w.rrl.debit(key(w.prefix, c, w.zone, w.req.Question[0]), c)
+	if allowed {
+		return w.ResponseWriter.WriteMsg(res)
+	}
+
+	if w.rrl.logOnly {
+		if unlogged > 0 {
+			log.Printf("[INFO] Would limit %s to %s for %s, %d responses since the last report", c, w.RemoteAddr(), w.req.Question[0].Name, unlogged)
+		}
+		LimitedCount.WithLabelValues(w.zone, c.String(), "logged").Inc()
+		return w.ResponseWriter.WriteMsg(res)
+	}
+
+	if w.rrl.slip > 0 && limited%w.rrl.slip == 0 {
+		// Slip: tell the client to retry over TCP, which a spoofed client can't.
+		LimitedCount.WithLabelValues(w.zone, c.String(), "slipped").Inc()
+		m := new(dns.Msg)
+		m.SetReply(w.req)
+		m.Truncated = true
+		return w.ResponseWriter.WriteMsg(m)
+	}
+
+	LimitedCount.WithLabelValues(w.zone, c.String(), "dropped").Inc()
+	return nil
+}
+
+// Write implements the dns.ResponseWriter interface.
+func (w *ResponseWriter) Write(buf []byte) (int, error) {
+	log.Printf("[WARNING] RRL called with Write: not rate limiting reply")
+	return w.ResponseWriter.Write(buf)
+}
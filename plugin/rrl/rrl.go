@@ -0,0 +1,193 @@
+// Package rrl implements Response Rate Limiting (RRL) for authoritative servers.
+package rrl
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/response"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// RRL limits the rate of identical responses to a client network. This mitigates the use of
+// authoritative servers in reflection attacks.
+type RRL struct {
+	Next  plugin.Handler
+	Zones []string
+
+	rates    [numCategories]float64 // responses per second per category, 0 means no limit
+	window   float64                // seconds of rate a client can get in debt
+	ipv4Mask net.IPMask
+	ipv6Mask net.IPMask
+	slip     int          // every slip-th limited response is sent truncated, 0 never does
+	exempt   []*net.IPNet // clients that are never limited
+	logOnly  bool         // only log (and count) what would have been limited
+
+	table *cache.Cache
+}
+
+// category is the kind of response, each category has its own rate.
+type category int
+
+const (
+	responses category = iota
+	nodata
+	nxdomains
+	referrals
+	errorCategory
+	numCategories
+)
+
+var categoryString = [numCategories]string{"responses", "nodata", "nxdomains", "referrals", "errors"}
+
+func (c category) String() string { return categoryString[c] }
+
+// classify returns the category of the response. Meta responses, such as zone transfers, are not
+// rate limited.
+func classify(t response.Type) (category, bool) {
+	switch t {
+	case response.NoError:
+		return responses, true
+	case response.NoData:
+		return nodata, true
+	case response.NameError:
+		return nxdomains, true
+	case response.Delegation:
+		return referrals, true
+	case response.OtherError:
+		return errorCategory, true
+	}
+	return 0, false
+}
+
+// ServeDNS implements the plugin.Handler interface.
+func (rl *RRL) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	state := request.Request{W: w, Req: r}
+
+	zone := plugin.Zones(rl.Zones).Matches(state.Name())
+	if zone == "" {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	// TCP clients can't spoof their address.
+	if state.Proto() == "tcp" {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	ip := net.ParseIP(state.IP())
+	if ip == nil || rl.isExempt(ip) {
+		return plugin.NextOrFailure(rl.Name(), rl.Next, ctx, w, r)
+	}
+
+	rw := &ResponseWriter{ResponseWriter: w, rrl: rl, req: r, zone: zone, prefix: rl.prefix(ip)}
+	rcode, err := plugin.NextOrFailure(rl.Name(), rl.Next, ctx, rw, r)
+	if plugin.ClientWrite(rcode) {
+		return rcode, err
+	}
+
+	// A plugin that failed leaves the reply to the server, which would write it past rw. Write it
+	// here, so errors are rate limited too.
+	m := new(dns.Msg)
+	m.SetRcode(r, rcode)
+	state.SizeAndDo(m)
+	rw.WriteMsg(m)
+	return dns.RcodeSuccess, err
+}
+
+// Name implements the Handler interface.
+func (rl *RRL) Name() string { return "rrl" }
+
+// isExempt returns true if ip may never be limited.
+func (rl *RRL) isExempt(ip net.IP) bool {
+	for _, n := range rl.exempt {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// prefix returns the network of ip, it is the client part of the key of an account.
+func (rl *RRL) prefix(ip net.IP) []byte {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(rl.ipv4Mask)
+	}
+	return ip.Mask(rl.ipv6Mask)
+}
+
+// key returns the key of the account a response is debited from. Positive responses are accounted
+// per query name and type, negative responses and referrals per zone and errors per client only.
+func key(prefix []byte, c category, zone string, q dns.Question) uint32 {
+	b := make([]byte, 0, len(prefix)+len(q.Name)+4)
+	b = append(b, prefix...)
+	b = append(b, byte(c))
+	switch c {
+	case responses, nodata:
+		b = append(b, q.Name...)
+		b = append(b, byte(q.Qtype>>8), byte(q.Qtype))
+	case nxdomains, referrals:
+		b = append(b, zone...)
+	}
+	return cache.Hash(b)
+}
+
+// debit debits a response from the account with key k. It returns false if the account is
+// overdrawn, the number of responses limited on this account so far and, at most once per
+// logInterval, the number of responses limited since it last did, to be logged.
+func (rl *RRL) debit(k uint32, c category) (bool, int, int) {
+	now := time.Now()
+	rate := rl.rates[c]
+
+	var a *account
+	if i, ok := rl.table.Get(k); ok {
+		a = i.(*account)
+	} else {
+		a = &account{balance: rate, last: now}
+		rl.table.Add(k, a)
+	}
+
+	a.Lock()
+	defer a.Unlock()
+
+	a.balance += now.Sub(a.last).Seconds() * rate
+	a.last = now
+	if a.balance > rate {
+		a.balance = rate
+	}
+	a.balance--
+	if min := -rl.window * rate; a.balance < min {
+		a.balance = min
+	}
+	if a.balance >= 0 {
+		return true, 0, 0
+	}
+	a.limited++
+	a.unlogged++
+	if now.Sub(a.logged) < logInterval {
+		return false, a.limited, 0
+	}
+	unlogged := a.unlogged
+	a.logged, a.unlogged = now, 0
+	return false, a.limited, unlogged
+}
+
+// account is the token bucket of a key. The balance grows with the rate each second, to at most
+// a second worth of responses, and each response debits one.
+type account struct {
+	sync.Mutex
+	balance  float64
+	last     time.Time
+	limited  int
+	logged   time.Time // last time limited responses were logged
+	unlogged int       // limited responses since then
+}
+
+// logInterval is the shortest time between two log lines about the same account, so floods of
+// limited responses don't flood the log as well.
+const logInterval = time.Minute
@@ -0,0 +1,209 @@
+package rrl
+
+import (
+	"net"
+	"testing"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+)
+
+// answer is the authoritative plugin behind rrl: it answers www.example.org, with NXDOMAIN for
+// anything else.
+var answer = test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	if r.Question[0].Name != "www.example.org." {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = []dns.RR{test.SOA("example.org.	300	IN	SOA	ns.example.org. hostmaster.example.org. 1 7200 1800 86400 60")}
+		w.WriteMsg(m)
+		return dns.RcodeNameError, nil
+	}
+	m.Answer = []dns.RR{test.A("www.example.org.	300	IN	A	10.0.0.1")}
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+})
+
+func newRRL(rate float64) *RRL {
+	rl := &RRL{
+		Next:     answer,
+		Zones:    []string{"example.org."},
+		window:   defaultWindow,
+		ipv4Mask: net.CIDRMask(defaultIPv4PrefixLength, 32),
+		ipv6Mask: net.CIDRMask(defaultIPv6PrefixLength, 128),
+		slip:     defaultSlip,
+		table:    cache.New(defaultMaxTableSize),
+	}
+	for i := range rl.rates {
+		rl.rates[i] = rate
+	}
+	return rl
+}
+
+// outcome is what the client got: an answer, a truncated (slipped) response or nothing.
+type outcome int
+
+const (
+	answered outcome = iota
+	slipped
+	dropped
+)
+
+func query(rl *RRL, w dns.ResponseWriter, name string) outcome {
+	m := new(dns.Msg)
+	m.SetQuestion(name, dns.TypeA)
+	rec := dnsrecorder.New(w)
+	rl.ServeDNS(context.TODO(), rec, m)
+	switch {
+	case rec.Msg == nil:
+		return dropped
+	case rec.Msg.Truncated:
+		return slipped
+	}
+	return answered
+}
+
+func TestRRL(t *testing.T) {
+	rl := newRRL(2)
+
+	// Two answers per second, then every second limited response slips.
+	expected := []outcome{answered, answered, dropped, slipped, dropped, slipped}
+	for i, e := range expected {
+		if o := query(rl, &test.ResponseWriter{}, "www.example.org."); o != e {
+			t.Errorf("Test %d: expected outcome %d, got %d", i, e, o)
+		}
+	}
+
+	// NXDOMAIN responses have their own account.
+	if o := query(rl, &test.ResponseWriter{}, "a.example.org."); o != answered {
+		t.Errorf("Expected NXDOMAIN to be answered, got %d", o)
+	}
+	// ... which is shared by all names in the zone.
+	query(rl, &test.ResponseWriter{}, "b.example.org.")
+	if o := query(rl, &test.ResponseWriter{}, "c.example.org."); o == answered {
+		t.Errorf("Expected NXDOMAIN to be limited")
+	}
+
+	// Other clients are not affected.
+	if o := query(rl, &test.ResponseWriter6{}, "www.example.org."); o != answered {
+		t.Errorf("Expected other client to be answered, got %d", o)
+	}
+}
+
+func TestRRLErrors(t *testing.T) {
+	rl := newRRL(1)
+	// A plugin that fails leaves writing the reply to the server.
+	rl.Next = test.HandlerFunc(func(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		return dns.RcodeRefused, nil
+	})
+
+	expected := []outcome{answered, dropped, slipped}
+	for i, e := range expected {
+		m := new(dns.Msg)
+		m.SetQuestion("www.example.org.", dns.TypeA)
+		rec := dnsrecorder.New(&test.ResponseWriter{})
+		rcode, _ := rl.ServeDNS(context.TODO(), rec, m)
+		if !plugin.ClientWrite(rcode) {
+			t.Fatalf("Test %d: expected the reply to be written, got rcode %d", i, rcode)
+		}
+
+		o := answered
+		switch {
+		case rec.Msg == nil:
+			o = dropped
+		case rec.Msg.Truncated:
+			o = slipped
+		case rec.Msg.Rcode != dns.RcodeRefused:
+			t.Errorf("Test %d: expected REFUSED, got %d", i, rec.Msg.Rcode)
+		}
+		if o != e {
+			t.Errorf("Test %d: expected outcome %d, got %d", i, e, o)
+		}
+	}
+}
+
+func TestRRLExempt(t *testing.T) {
+	rl := newRRL(1)
+	_, n, _ := net.ParseCIDR("10.240.0.0/16")
+	rl.exempt = []*net.IPNet{n}
+
+	for i := 0; i < 5; i++ {
+		if o := query(rl, &test.ResponseWriter{}, "www.example.org."); o != answered {
+			t.Errorf("Test %d: expected exempt client to be answered, got %d", i, o)
+		}
+	}
+}
+
+func TestRRLLogOnly(t *testing.T) {
+	rl := newRRL(1)
+	rl.logOnly = true
+
+	for i := 0; i < 5; i++ {
+		if o := query(rl, &test.ResponseWriter{}, "www.example.org."); o != answered {
+			t.Errorf("Test %d: expected client to be answered in log-only mode, got %d", i, o)
+		}
+	}
+}
+
+func TestAccountLog(t *testing.T) {
+	rl := newRRL(1)
+	k := key([]byte{10, 240, 0}, responses, "example.org.", dns.Question{Name: "www.example.org.", Qtype: dns.TypeA})
+
+	rl.debit(k, responses)
+	// The first limited response is logged, the next ones only once per interval.
+	if _, _, unlogged := rl.debit(k, responses); unlogged != 1 {
+		t.Errorf("Expected the first limited response to be logged, got %d", unlogged)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, unlogged := rl.debit(k, responses); unlogged != 0 {
+			t.Errorf("Test %d: expected limited response not to be logged, got %d", i, unlogged)
+		}
+	}
+
+	i, _ := rl.table.Get(k)
+	a := i.(*account)
+	a.logged = a.logged.Add(-logInterval)
+	if _, limited, unlogged := rl.debit(k, responses); limited != 5 || unlogged != 4 {
+		t.Errorf("Expected 5 limited responses, of which 4 to be logged, got %d and %d", limited, unlogged)
+	}
+}
+
+func TestRRLNoLimit(t *testing.T) {
+	rl := newRRL(0)
+
+	for i := 0; i < 5; i++ {
+		if o := query(rl, &test.ResponseWriter{}, "www.example.org."); o != answered {
+			t.Errorf("Test %d: expected client to be answered without a limit, got %d", i, o)
+		}
+	}
+}
+
+func TestAccountRefill(t *testing.T) {
+	rl := newRRL(1)
+	k := key([]byte{10, 240, 0}, responses, "example.org.", dns.Question{Name: "www.example.org.", Qtype: dns.TypeA})
+
+	if ok, _, _ := rl.debit(k, responses); !ok {
+		t.Fatalf("Expected first response to be allowed")
+	}
+	if ok, _, _ := rl.debit(k, responses); ok {
+		t.Fatalf("Expected second response to be limited")
+	}
+
+	// Pretend a lot of time has passed, the debt is paid off, but the balance does not exceed
+	// a second worth of responses.
+	i, _ := rl.table.Get(k)
+	a := i.(*account)
+	a.last = a.last.Add(-3600 * 1e9)
+	if ok, _, _ := rl.debit(k, responses); !ok {
+		t.Fatalf("Expected response to be allowed after the debt is paid")
+	}
+	if ok, _, _ := rl.debit(k, responses); ok {
+		t.Fatalf("Expected response to be limited")
+	}
+}
@@ -0,0 +1,154 @@
+package rrl
+
+import (
+	"net"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cache"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+	"github.com/coredns/coredns/plugin/pkg/parse"
+
+	"github.com/mholt/caddy"
+)
+
+func init() {
+	caddy.RegisterPlugin("rrl", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setup,
+	})
+}
+
+func setup(c *caddy.Controller) error {
+	rl, err := rrlParse(c)
+	if err != nil {
+		return plugin.Error("rrl", err)
+	}
+
+	dnsserver.GetConfig(c).AddPlugin(func(next plugin.Handler) plugin.Handler {
+		rl.Next = next
+		return rl
+	})
+
+	return nil
+}
+
+func rrlParse(c *caddy.Controller) (*RRL, error) {
+	rl := &RRL{
+		window:   defaultWindow,
+		ipv4Mask: net.CIDRMask(defaultIPv4PrefixLength, 32),
+		ipv6Mask: net.CIDRMask(defaultIPv6PrefixLength, 128),
+		slip:     defaultSlip,
+	}
+	for i := range rl.rates {
+		rl.rates[i] = -1 // not set, defaults to responses-per-second
+	}
+	size := defaultMaxTableSize
+
+	i := 0
+	for c.Next() {
+		if i > 0 {
+			return nil, c.Err("rrl can only be specified once")
+		}
+		i++
+
+		zones := c.RemainingArgs()
+		if len(zones) == 0 {
+			zones = make([]string, len(c.ServerBlockKeys))
+			copy(zones, c.ServerBlockKeys)
+		}
+		for i, str := range zones {
+			zones[i] = plugin.Host(str).Normalize()
+		}
+		rl.Zones = zones
+
+		for c.NextBlock() {
+			switch x := c.Val(); x {
+			case "responses-per-second", "nodata-per-second", "nxdomains-per-second", "referrals-per-second", "errors-per-second":
+				n, err := parse.Int(c, 0, 1<<20)
+				if err != nil {
+					return nil, err
+				}
+				rl.rates[rateOption[x]] = float64(n)
+			case "window":
+				n, err := parse.Int(c, 1, 3600)
+				if err != nil {
+					return nil, err
+				}
+				rl.window = float64(n)
+			case "ipv4-prefix-length":
+				n, err := parse.Int(c, 1, 32)
+				if err != nil {
+					return nil, err
+				}
+				rl.ipv4Mask = net.CIDRMask(n, 32)
+			case "ipv6-prefix-length":
+				n, err := parse.Int(c, 1, 128)
+				if err != nil {
+					return nil, err
+				}
+				rl.ipv6Mask = net.CIDRMask(n, 128)
+			case "slip":
+				n, err := parse.Int(c, 0, 10)
+				if err != nil {
+					return nil, err
+				}
+				rl.slip = n
+			case "exempt":
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					n, err := cidr.Parse(a)
+					if err != nil {
+						return nil, err
+					}
+					rl.exempt = append(rl.exempt, n)
+				}
+			case "log-only":
+				if len(c.RemainingArgs()) != 0 {
+					return nil, c.ArgErr()
+				}
+				rl.logOnly = true
+			case "max-table-size":
+				n, err := parse.Int(c, 1, 1<<24)
+				if err != nil {
+					return nil, err
+				}
+				size = n
+			default:
+				return nil, c.Errf("unknown property '%s'", x)
+			}
+		}
+	}
+
+	// The other rates default to responses-per-second, which defaults to no limit.
+	if rl.rates[responses] < 0 {
+		rl.rates[responses] = 0
+	}
+	for i := range rl.rates {
+		if rl.rates[i] < 0 {
+			rl.rates[i] = rl.rates[responses]
+		}
+	}
+	rl.table = cache.New(size)
+
+	return rl, nil
+}
+
+var rateOption = map[string]category{
+	"responses-per-second": responses,
+	"nodata-per-second":    nodata,
+	"nxdomains-per-second": nxdomains,
+	"referrals-per-second": referrals,
+	"errors-per-second":    errorCategory,
+}
+
+const (
+	defaultWindow           = 15
+	defaultIPv4PrefixLength = 24
+	defaultIPv6PrefixLength = 56
+	defaultSlip             = 2
+	defaultMaxTableSize     = 20000
+)
@@ -0,0 +1,83 @@
+package rrl
+
+import (
+	"testing"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupRRL(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+		responses float64
+		nxdomains float64
+		slip      int
+	}{
+		{`rrl`, false, 0, 0, 2},
+		{`rrl example.org {
+			responses-per-second 10
+		}`, false, 10, 10, 2},
+		{`rrl {
+			responses-per-second 10
+			nxdomains-per-second 5
+			slip 0
+			window 5
+			ipv4-prefix-length 32
+			ipv6-prefix-length 64
+			exempt 10.0.0.0/8 2001:db8::/32
+			log-only
+			max-table-size 1000
+		}`, false, 10, 5, 0},
+		{`rrl {
+			responses-per-second
+		}`, true, 0, 0, 0},
+		{`rrl {
+			responses-per-second -1
+		}`, true, 0, 0, 0},
+		{`rrl {
+			slip 11
+		}`, true, 0, 0, 0},
+		{`rrl {
+			ipv4-prefix-length 33
+		}`, true, 0, 0, 0},
+		{`rrl {
+			exempt 10.0.0.1 2001:db8::1
+		}`, false, 0, 0, 2},
+		{`rrl {
+			exempt 10.0.0
+		}`, true, 0, 0, 0},
+		{`rrl {
+			log-only yes
+		}`, true, 0, 0, 0},
+		{`rrl {
+			queries-per-second 10
+		}`, true, 0, 0, 0},
+		{`rrl
+		rrl`, true, 0, 0, 0},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		rl, err := rrlParse(c)
+
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
+		}
+		if err != nil {
+			if !test.shouldErr {
+				t.Errorf("Test %d: Expected no error but found one for input %s. Error was: %v", i, test.input, err)
+			}
+			continue
+		}
+		if rl.rates[responses] != test.responses {
+			t.Errorf("Test %d: Expected responses-per-second %v, got %v", i, test.responses, rl.rates[responses])
+		}
+		if rl.rates[nxdomains] != test.nxdomains {
+			t.Errorf("Test %d: Expected nxdomains-per-second %v, got %v", i, test.nxdomains, rl.rates[nxdomains])
+		}
+		if rl.slip != test.slip {
+			t.Errorf("Test %d: Expected slip %d, got %d", i, test.slip, rl.slip)
+		}
+	}
+}
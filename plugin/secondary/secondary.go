@@ -8,3 +8,15 @@ import "github.com/coredns/coredns/plugin/file"
 type Secondary struct {
 	file.File
 }
+
+// Ready implements the health.Readiness interface. It returns true once all zones have been
+// transferred from their primary.
+func (s Secondary) Ready() bool {
+	for _, n := range s.Zones.Names {
+		z := s.Zones.Z[n]
+		if len(z.TransferFrom) > 0 && z.SOASerialIfDefined() == -1 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package secondary
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/plugin/test"
+)
+
+func TestReady(t *testing.T) {
+	z := file.NewZone("example.org.", "stdin")
+	z.TransferFrom = []string{"10.0.0.1:53"}
+	s := Secondary{file.File{Zones: file.Zones{Z: map[string]*file.Zone{"example.org.": z}, Names: []string{"example.org."}}}}
+
+	if s.Ready() {
+		t.Errorf("Expected not to be ready before the zone is transferred")
+	}
+
+	z.Insert(test.SOA("example.org. IN SOA sns.dns.icann.org. noc.dns.icann.org. 2017042745 7200 3600 1209600 3600"))
+	if !s.Ready() {
+		t.Errorf("Expected to be ready after the zone is transferred")
+	}
+}
@@ -0,0 +1,163 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certs holds the certificates of a server and reloads them when their files change. The TLS
+// config of the server hands out the current ones on every handshake, so connections that are
+// already established are not affected by a reload.
+type certs struct {
+	pairs      []pair
+	ca         string
+	clientAuth tls.ClientAuthType
+	reload     time.Duration // interval between checks for changed files, 0 disables reloading
+
+	sync.RWMutex
+	config  *tls.Config
+	modTime map[string]time.Time // modification times of the files the config was loaded from
+
+	stop chan struct{}
+}
+
+// pair is a certificate and the path to its private key.
+type pair struct {
+	cert string
+	key  string
+}
+
+func newCerts() *certs {
+	return &certs{reload: defaultReload, stop: make(chan struct{})}
+}
+
+// Config returns the TLS config to use for the server.
+func (k *certs) Config() *tls.Config {
+	return &tls.Config{GetConfigForClient: k.getConfigForClient}
+}
+
+func (k *certs) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	k.RLock()
+	defer k.RUnlock()
+	return k.config, nil
+}
+
+// files returns all files the config is loaded from.
+func (k *certs) files() []string {
+	files := []string{}
+	for _, p := range k.pairs {
+		files = append(files, p.cert, p.key)
+	}
+	if k.ca != "" {
+		files = append(files, k.ca)
+	}
+	return files
+}
+
+// load (re)loads the config from the files. On error the current config is kept.
+func (k *certs) load() error {
+	modTime := map[string]time.Time{}
+	for _, f := range k.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTime[f] = fi.ModTime()
+	}
+
+	// With more than one certificate, crypto/tls picks the one whose name matches the server name
+	// the client sent (SNI), through NameToCertificate, or the first one if none does.
+	config := &tls.Config{ClientAuth: k.clientAuth}
+	for _, p := range k.pairs {
+		cert, err := tls.LoadX509KeyPair(p.cert, p.key)
+		if err != nil {
+			return fmt.Errorf("could not load TLS cert: %s", err)
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+	config.BuildNameToCertificate()
+	if k.ca != "" {
+		pem, err := ioutil.ReadFile(k.ca)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %s", k.ca, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("could not read root certs from %s", k.ca)
+		}
+		// The CA verifies client certificates, and stays the root CA for backwards compatibility.
+		config.ClientCAs = pool
+		config.RootCAs = pool
+	}
+
+	k.Lock()
+	k.config = config
+	k.modTime = modTime
+	k.Unlock()
+	return nil
+}
+
+// changed returns true if any of the files changed since the config was loaded.
+func (k *certs) changed() bool {
+	k.RLock()
+	defer k.RUnlock()
+	for _, f := range k.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			// Possibly in the middle of being replaced, check again later.
+			continue
+		}
+		if !fi.ModTime().Equal(k.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run checks for changed files every reload interval until Stop is called.
+func (k *certs) Run() {
+	if k.reload == 0 {
+		return
+	}
+	tick := time.NewTicker(k.reload)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+			if !k.changed() {
+				continue
+			}
+			if err := k.load(); err != nil {
+				log.Printf("[ERROR] Failed to reload TLS certificates: %s", err)
+				continue
+			}
+			log.Printf("[INFO] Reloaded TLS certificates")
+		case <-k.stop:
+			return
+		}
+	}
+}
+
+// Stop stops checking for changed files.
+func (k *certs) Stop() error {
+	close(k.stop)
+	return nil
+}
+
+// clientAuthTypes maps the client_auth values to the tls.ClientAuthType.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"nocert":             tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+const defaultReload = time.Minute
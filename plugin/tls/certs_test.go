@@ -0,0 +1,138 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertsSNI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	k := newCerts()
+	for _, name := range []string{"a.example.org", "b.example.org"} {
+		cert, key := writeCert(t, dir, name)
+		k.pairs = append(k.pairs, pair{cert: cert, key: key})
+	}
+	if err := k.load(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	for sni, expected := range map[string]string{
+		"a.example.org": "a.example.org",
+		"b.example.org": "b.example.org",
+		"c.example.org": "a.example.org", // the first one is the default
+	} {
+		if got := handshake(t, k, sni); got != expected {
+			t.Errorf("Expected certificate for %s with SNI %s, got %s", expected, sni, got)
+		}
+	}
+}
+
+func TestCertsReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "coredns-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, key := writeCert(t, dir, "a.example.org")
+	k := newCerts()
+	k.pairs = []pair{{cert: cert, key: key}}
+	if err := k.load(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if k.changed() {
+		t.Errorf("Expected files to be unchanged")
+	}
+
+	// Replace the pair, as a certificate rotation would.
+	writeCert(t, dir, "b.example.org")
+	os.Rename(filepath.Join(dir, "b.example.org.crt"), cert)
+	os.Rename(filepath.Join(dir, "b.example.org.key"), key)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(cert, future, future)
+
+	if !k.changed() {
+		t.Fatalf("Expected files to be changed")
+	}
+	if err := k.load(); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if got := handshake(t, k, "a.example.org"); got != "b.example.org" {
+		t.Errorf("Expected reloaded certificate for b.example.org, got %s", got)
+	}
+
+	// A broken file keeps the current certificate.
+	ioutil.WriteFile(cert, []byte("garbage"), 0644)
+	if err := k.load(); err == nil {
+		t.Fatalf("Expected error, got none")
+	}
+	if got := handshake(t, k, "a.example.org"); got != "b.example.org" {
+		t.Errorf("Expected certificate for b.example.org to be kept, got %s", got)
+	}
+}
+
+// handshake does a TLS handshake with a server using k, and returns the name in the certificate
+// the server presented.
+func handshake(t *testing.T, k *certs, sni string) string {
+	c, s := net.Pipe()
+	defer c.Close()
+	defer s.Close()
+
+	go tls.Server(s, k.Config()).Handshake()
+
+	client := tls.Client(c, &tls.Config{ServerName: sni, InsecureSkipVerify: true})
+	if err := client.Handshake(); err != nil {
+		t.Fatalf("Handshake failed: %s", err)
+	}
+	return client.ConnectionState().PeerCertificates[0].DNSNames[0]
+}
+
+// writeCert writes a self-signed certificate for name and its key to dir.
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert := filepath.Join(dir, name+".crt")
+	key := filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
@@ -1,9 +1,11 @@
 package tls
 
 import (
+	"crypto/tls"
+	"time"
+
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
-	"github.com/coredns/coredns/plugin/pkg/tls"
 
 	"github.com/mholt/caddy"
 )
@@ -22,16 +24,80 @@ func setup(c *caddy.Controller) error {
 		return plugin.Error("tls", c.Errf("TLS already configured for this server instance"))
 	}
 
+	k, err := tlsParse(c)
+	if err != nil {
+		return plugin.Error("tls", err)
+	}
+	if err := k.load(); err != nil {
+		return plugin.Error("tls", err)
+	}
+
+	c.OnStartup(func() error {
+		go k.Run()
+		return nil
+	})
+	c.OnShutdown(k.Stop)
+
+	config.TLSConfig = k.Config()
+	return nil
+}
+
+func tlsParse(c *caddy.Controller) (*certs, error) {
+	k := newCerts()
+
 	for c.Next() {
+		if len(k.pairs) > 0 {
+			return nil, c.Err("TLS already configured for this server instance")
+		}
 		args := c.RemainingArgs()
-		if len(args) != 3 {
-			return plugin.Error("tls", c.ArgErr())
+		if len(args) < 2 || len(args) > 3 {
+			return nil, c.ArgErr()
 		}
-		tls, err := tls.NewTLSConfig(args[0], args[1], args[2])
-		if err != nil {
-			return plugin.Error("tls", err)
+		k.pairs = append(k.pairs, pair{cert: args[0], key: args[1]})
+		if len(args) == 3 {
+			k.ca = args[2]
+		}
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "cert":
+				args := c.RemainingArgs()
+				if len(args) != 2 {
+					return nil, c.ArgErr()
+				}
+				k.pairs = append(k.pairs, pair{cert: args[0], key: args[1]})
+			case "client_auth":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				auth, ok := clientAuthTypes[c.Val()]
+				if !ok {
+					return nil, c.Errf("unknown client_auth type '%s'", c.Val())
+				}
+				k.clientAuth = auth
+			case "reload":
+				if !c.NextArg() {
+					return nil, c.ArgErr()
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil {
+					return nil, err
+				}
+				if d < 0 {
+					return nil, c.Errf("reload can not be negative: %s", d)
+				}
+				k.reload = d
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
 		}
-		config.TLSConfig = tls
 	}
-	return nil
+	if len(k.pairs) == 0 {
+		return nil, c.ArgErr()
+	}
+	if k.clientAuth >= tls.VerifyClientCertIfGiven && k.ca == "" {
+		return nil, c.Errf("client_auth verification needs a CA")
+	}
+
+	return k, nil
 }
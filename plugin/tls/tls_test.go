@@ -1,10 +1,16 @@
 package tls
 
 import (
+	"crypto/tls"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/test"
 
 	"github.com/mholt/caddy"
 )
@@ -12,23 +18,53 @@ import (
 func TestTLS(t *testing.T) {
 	log.SetOutput(ioutil.Discard)
 
+	dir, rm, err := test.WritePEMFiles("")
+	if err != nil {
+		t.Fatalf("Could not write PEM files: %s", err)
+	}
+	defer rm()
+
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	ca := filepath.Join(dir, "ca.pem")
+
 	tests := []struct {
 		input              string
 		shouldErr          bool
-		expectedRoot       string // expected root, set to the controller. Empty for negative cases.
+		expectedPairs      int
+		expectedClientAuth tls.ClientAuthType
+		expectedReload     time.Duration
 		expectedErrContent string // substring from the expected error. Empty for positive cases.
 	}{
-	// positive
-	// negative
+		// positive
+		{fmt.Sprintf("tls %s %s %s", cert, key, ca), false, 1, tls.NoClientCert, defaultReload, ""},
+		{fmt.Sprintf("tls %s %s", cert, key), false, 1, tls.NoClientCert, defaultReload, ""},
+		{fmt.Sprintf("tls %s %s %s {\n cert %s %s\n}", cert, key, ca, cert, key), false, 2, tls.NoClientCert, defaultReload, ""},
+		{fmt.Sprintf("tls %s %s %s {\n client_auth require_and_verify\n}", cert, key, ca), false, 1, tls.RequireAndVerifyClientCert, defaultReload, ""},
+		{fmt.Sprintf("tls %s %s {\n client_auth request\n reload 10s\n}", cert, key), false, 1, tls.RequestClientCert, 10 * time.Second, ""},
+		{fmt.Sprintf("tls %s %s {\n reload 0\n}", cert, key), false, 1, tls.NoClientCert, 0, ""},
+		// negative
+		{"tls", true, 0, 0, 0, "Wrong argument count"},
+		{fmt.Sprintf("tls %s", cert), true, 0, 0, 0, "Wrong argument count"},
+		{fmt.Sprintf("tls %s %s %s %s", cert, key, ca, ca), true, 0, 0, 0, "Wrong argument count"},
+		{fmt.Sprintf("tls %s %s %s {\n client_auth always\n}", cert, key, ca), true, 0, 0, 0, "unknown client_auth type"},
+		{fmt.Sprintf("tls %s %s {\n client_auth verify_if_given\n}", cert, key), true, 0, 0, 0, "needs a CA"},
+		{fmt.Sprintf("tls %s %s {\n reload -1s\n}", cert, key), true, 0, 0, 0, "can not be negative"},
+		{fmt.Sprintf("tls %s %s {\n cert %s\n}", cert, key, cert), true, 0, 0, 0, "Wrong argument count"},
+		{fmt.Sprintf("tls %s %s {\n blah\n}", cert, key), true, 0, 0, 0, "unknown property"},
+		{fmt.Sprintf("tls %s %s\ntls %s %s", cert, key, cert, key), true, 0, 0, 0, "already configured"},
+		{fmt.Sprintf("tls %s %s", cert, filepath.Join(dir, "nokey.pem")), true, 0, 0, 0, "no such file"},
 	}
 
 	for i, test := range tests {
 		c := caddy.NewTestController("dns", test.input)
-		err := setup(c)
-		//cfg := dnsserver.GetConfig(c)
+		k, err := tlsParse(c)
+		if err == nil {
+			err = k.load()
+		}
 
 		if test.shouldErr && err == nil {
-			t.Errorf("Test %d: Expected error but found %s for input %s", i, err, test.input)
+			t.Errorf("Test %d: Expected error but found none for input %s", i, test.input)
 		}
 
 		if err != nil {
@@ -39,6 +75,17 @@ func TestTLS(t *testing.T) {
 			if !strings.Contains(err.Error(), test.expectedErrContent) {
 				t.Errorf("Test %d: Expected error to contain: %v, found error: %v, input: %s", i, test.expectedErrContent, err, test.input)
 			}
+			continue
+		}
+
+		if len(k.config.Certificates) != test.expectedPairs {
+			t.Errorf("Test %d: Expected %d certificates, got %d", i, test.expectedPairs, len(k.config.Certificates))
+		}
+		if k.config.ClientAuth != test.expectedClientAuth {
+			t.Errorf("Test %d: Expected client auth %d, got %d", i, test.expectedClientAuth, k.config.ClientAuth)
+		}
+		if k.reload != test.expectedReload {
+			t.Errorf("Test %d: Expected reload %s, got %s", i, test.expectedReload, k.reload)
 		}
 	}
 }
@@ -0,0 +1,257 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	zipkin "github.com/openzipkin/zipkin-go-opentracing"
+)
+
+// otlpRecorder sends the sampled spans to an OpenTelemetry collector, with OTLP over HTTP in its
+// JSON encoding. Spans are sent in batches from a queue; when the queue is full spans are dropped.
+type otlpRecorder struct {
+	endpoint string
+	service  string
+	client   *http.Client
+
+	spans chan zipkin.RawSpan
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+func newOTLPRecorder(endpoint, service string) *otlpRecorder {
+	r := &otlpRecorder{
+		endpoint: endpoint,
+		service:  service,
+		client:   &http.Client{Timeout: otlpTimeout},
+		spans:    make(chan zipkin.RawSpan, otlpQueue),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// RecordSpan implements zipkin.SpanRecorder.
+func (r *otlpRecorder) RecordSpan(span zipkin.RawSpan) {
+	if !span.Context.Sampled {
+		return
+	}
+	select {
+	case r.spans <- span:
+	default:
+	}
+}
+
+// Close sends the queued spans and stops the recorder.
+func (r *otlpRecorder) Close() error {
+	close(r.stop)
+	<-r.done
+	return nil
+}
+
+func (r *otlpRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(otlpFlush)
+	defer ticker.Stop()
+
+	batch := make([]zipkin.RawSpan, 0, otlpBatch)
+	for {
+		select {
+		case span := <-r.spans:
+			if batch = append(batch, span); len(batch) == otlpBatch {
+				r.send(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.send(batch)
+			batch = batch[:0]
+		case <-r.stop:
+			for len(r.spans) > 0 {
+				batch = append(batch, <-r.spans)
+			}
+			r.send(batch)
+			return
+		}
+	}
+}
+
+func (r *otlpRecorder) send(batch []zipkin.RawSpan) {
+	if len(batch) == 0 {
+		return
+	}
+	body, err := json.Marshal(r.request(batch))
+	if err != nil {
+		log.Printf("[ERROR] Failed to encode spans: %s", err)
+		return
+	}
+	resp, err := r.client.Post(r.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("[WARNING] Failed to send %d spans to %s: %s", len(batch), r.endpoint, err)
+		return
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Printf("[WARNING] Failed to send %d spans to %s: %s", len(batch), r.endpoint, resp.Status)
+	}
+}
+
+// The OTLP JSON encoding, see https://github.com/open-telemetry/opentelemetry-proto. Trace and span
+// IDs are hex strings, 64 bit integers are decimal strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Status            *otlpStatus    `json:"status,omitempty"`
+	}
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code int `json:"code"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+// Span kinds and status codes.
+const (
+	otlpKindInternal = 1
+	otlpKindServer   = 2
+	otlpKindClient   = 3
+
+	otlpStatusError = 2
+)
+
+func (r *otlpRecorder) request(batch []zipkin.RawSpan) otlpRequest {
+	spans := make([]otlpSpan, len(batch))
+	for i, s := range batch {
+		spans[i] = otlpSpanFrom(s)
+	}
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpValueOf(r.service)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "coredns"}, Spans: spans}},
+	}}}
+}
+
+func otlpSpanFrom(s zipkin.RawSpan) otlpSpan {
+	span := otlpSpan{
+		TraceID:           fmt.Sprintf("%016x%016x", s.Context.TraceID.High, s.Context.TraceID.Low),
+		SpanID:            fmt.Sprintf("%016x", s.Context.SpanID),
+		Name:              s.Operation,
+		Kind:              otlpKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.Start.Add(s.Duration).UnixNano(), 10),
+	}
+	if s.Context.ParentSpanID != nil {
+		span.ParentSpanID = fmt.Sprintf("%016x", *s.Context.ParentSpanID)
+	}
+
+	keys := make([]string, 0, len(s.Tags))
+	for k := range s.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := s.Tags[k]
+		switch k {
+		case string(ext.SpanKind):
+			switch fmt.Sprint(v) {
+			case string(ext.SpanKindRPCServerEnum):
+				span.Kind = otlpKindServer
+			case string(ext.SpanKindRPCClientEnum):
+				span.Kind = otlpKindClient
+			}
+			continue
+		case string(ext.Error):
+			if v == true {
+				span.Status = &otlpStatus{Code: otlpStatusError}
+			}
+		}
+		span.Attributes = append(span.Attributes, otlpKeyValue{Key: k, Value: otlpValueOf(v)})
+	}
+
+	for _, l := range s.Logs {
+		e := otlpEvent{TimeUnixNano: strconv.FormatInt(l.Timestamp.UnixNano(), 10), Name: "log"}
+		for _, f := range l.Fields {
+			if f.Key() == "event" {
+				e.Name = fmt.Sprint(f.Value())
+				continue
+			}
+			e.Attributes = append(e.Attributes, otlpKeyValue{Key: f.Key(), Value: otlpValueOf(f.Value())})
+		}
+		span.Events = append(span.Events, e)
+	}
+	return span
+}
+
+func otlpValueOf(v interface{}) otlpValue {
+	switch v := v.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s := fmt.Sprint(v)
+		return otlpValue{IntValue: &s}
+	case float32:
+		f := float64(v)
+		return otlpValue{DoubleValue: &f}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	}
+	s := fmt.Sprint(v)
+	return otlpValue{StringValue: &s}
+}
+
+const (
+	otlpQueue   = 10000 // spans waiting to be sent
+	otlpBatch   = 100   // spans per request
+	otlpFlush   = time.Second
+	otlpTimeout = 10 * time.Second
+)
@@ -0,0 +1,66 @@
+package trace
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	zipkin "github.com/openzipkin/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go-opentracing/types"
+)
+
+func TestOTLPRecorder(t *testing.T) {
+	requests := make(chan otlpRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON request for /v1/traces, got %s for %s", r.Header.Get("Content-Type"), r.URL.Path)
+		}
+		req := otlpRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests <- req
+	}))
+	defer srv.Close()
+
+	r := newOTLPRecorder(srv.URL+"/v1/traces", "dns")
+	parent := uint64(0x10)
+	start := time.Unix(1500000000, 0)
+	r.RecordSpan(zipkin.RawSpan{
+		Context:   zipkin.SpanContext{TraceID: types.TraceID{High: 1, Low: 2}, SpanID: 0x20, ParentSpanID: &parent, Sampled: true},
+		Operation: "servedns",
+		Start:     start,
+		Duration:  time.Millisecond,
+		Tags:      map[string]interface{}{"qname": "example.org.", string(ext.SpanKind): ext.SpanKindRPCServerEnum, "error": true},
+	})
+	r.RecordSpan(zipkin.RawSpan{Context: zipkin.SpanContext{Sampled: false}, Operation: "not sampled"})
+	r.Close()
+
+	req := <-requests
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Expected one resource and scope, got %+v", req)
+	}
+	if a := req.ResourceSpans[0].Resource.Attributes; len(a) != 1 || *a[0].Value.StringValue != "dns" {
+		t.Errorf("Expected service.name dns, got %+v", a)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.TraceID != "00000000000000010000000000000002" || s.SpanID != "0000000000000020" || s.ParentSpanID != "0000000000000010" {
+		t.Errorf("Expected IDs 00000000000000010000000000000002 0000000000000020 0000000000000010, got %s %s %s", s.TraceID, s.SpanID, s.ParentSpanID)
+	}
+	if s.Name != "servedns" || s.Kind != otlpKindServer || s.Status == nil || s.Status.Code != otlpStatusError {
+		t.Errorf("Expected a failed server span servedns, got %+v", s)
+	}
+	if s.StartTimeUnixNano != "1500000000000000000" || s.EndTimeUnixNano != "1500000000001000000" {
+		t.Errorf("Expected the span to last 1ms from 1500000000, got %s to %s", s.StartTimeUnixNano, s.EndTimeUnixNano)
+	}
+	if len(s.Attributes) != 2 || s.Attributes[1].Key != "qname" || *s.Attributes[1].Value.StringValue != "example.org." {
+		t.Errorf("Expected the attributes error and qname, got %+v", s.Attributes)
+	}
+}
@@ -0,0 +1,63 @@
+package trace
+
+import (
+	"strconv"
+	"strings"
+
+	ot "github.com/opentracing/opentracing-go"
+	zipkin "github.com/openzipkin/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go-opentracing/types"
+)
+
+// w3cTracer is a zipkin tracer that also extracts W3C trace contexts, the "traceparent" header
+// that OpenTelemetry clients send, next to zipkin's own B3 headers.
+type w3cTracer struct {
+	ot.Tracer
+}
+
+// Extract implements ot.Tracer.
+func (t w3cTracer) Extract(format interface{}, carrier interface{}) (ot.SpanContext, error) {
+	if r, ok := carrier.(ot.TextMapReader); ok {
+		var parent string
+		r.ForeachKey(func(k, v string) error {
+			if strings.ToLower(k) == "traceparent" {
+				parent = v
+			}
+			return nil
+		})
+		if parent != "" {
+			return parseTraceparent(parent)
+		}
+	}
+	return t.Tracer.Extract(format, carrier)
+}
+
+// parseTraceparent parses the value of a traceparent header: version-traceid-parentid-flags, all
+// in hex.
+func parseTraceparent(s string) (ot.SpanContext, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+
+	high, err1 := strconv.ParseUint(parts[1][:16], 16, 64)
+	low, err2 := strconv.ParseUint(parts[1][16:], 16, 64)
+	id, err3 := strconv.ParseUint(parts[2], 16, 64)
+	flags, err4 := strconv.ParseUint(parts[3], 16, 8)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+	if high == 0 && low == 0 || id == 0 {
+		return nil, ot.ErrSpanContextCorrupted
+	}
+
+	return zipkin.SpanContext{
+		TraceID: types.TraceID{High: high, Low: low},
+		SpanID:  id,
+		Sampled: flags&1 == 1,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package trace
+
+import (
+	"testing"
+
+	ot "github.com/opentracing/opentracing-go"
+	zipkin "github.com/openzipkin/zipkin-go-opentracing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value   string
+		high    uint64
+		low     uint64
+		id      uint64
+		sampled bool
+		fail    bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", 0x4bf92f3577b34da6, 0xa3ce929d0e0e4736, 0x00f067aa0ba902b7, true, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", 0x4bf92f3577b34da6, 0xa3ce929d0e0e4736, 0x00f067aa0ba902b7, false, false},
+		// A later version may add fields.
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", 0x4bf92f3577b34da6, 0xa3ce929d0e0e4736, 0x00f067aa0ba902b7, true, false},
+		// fails
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", 0, 0, 0, false, true},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", 0, 0, 0, false, true},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", 0, 0, 0, false, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", 0, 0, 0, false, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", 0, 0, 0, false, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01", 0, 0, 0, false, true},
+		{"junk", 0, 0, 0, false, true},
+	}
+	for i, tc := range tests {
+		sc, err := parseTraceparent(tc.value)
+		if tc.fail {
+			if err == nil {
+				t.Errorf("Test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: expected no error, got %s", i, err)
+			continue
+		}
+		c := sc.(zipkin.SpanContext)
+		if c.TraceID.High != tc.high || c.TraceID.Low != tc.low || c.SpanID != tc.id || c.Sampled != tc.sampled {
+			t.Errorf("Test %d: expected %x%x %x %t, got %+v", i, tc.high, tc.low, tc.id, tc.sampled, c)
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	zt, err := zipkin.NewTracer(zipkin.NewInMemoryRecorder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := w3cTracer{zt}
+
+	sc, err := tracer.Extract(ot.TextMap, ot.TextMapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+	if err != nil {
+		t.Fatalf("Expected traceparent to be extracted, got %s", err)
+	}
+	if id := sc.(zipkin.SpanContext).SpanID; id != 0x00f067aa0ba902b7 {
+		t.Errorf("Expected span ID 00f067aa0ba902b7, got %x", id)
+	}
+
+	// Without traceparent the B3 headers are used.
+	sc, err = tracer.Extract(ot.TextMap, ot.TextMapCarrier{"x-b3-traceid": "463ac35c9f6413ad", "x-b3-spanid": "72485a3953bb6124", "x-b3-sampled": "1"})
+	if err != nil {
+		t.Fatalf("Expected B3 headers to be extracted, got %s", err)
+	}
+	if id := sc.(zipkin.SpanContext).SpanID; id != 0x72485a3953bb6124 {
+		t.Errorf("Expected span ID 72485a3953bb6124, got %x", id)
+	}
+
+	if _, err := tracer.Extract(ot.TextMap, ot.TextMapCarrier{}); err == nil {
+		t.Errorf("Expected an error without a trace context")
+	}
+}
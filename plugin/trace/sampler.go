@@ -0,0 +1,70 @@
+package trace
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampler decides which queries are traced, when the client did not start a trace for them.
+type sampler interface {
+	sample() bool
+}
+
+// everySampler traces one query of every every queries.
+type everySampler struct {
+	every uint64
+	count uint64
+}
+
+func (s *everySampler) sample() bool {
+	if s.every == 0 {
+		return false
+	}
+	return atomic.AddUint64(&s.count, 1)%s.every == 0
+}
+
+// probabilitySampler traces each query with a probability between 0 and 1.
+type probabilitySampler float64
+
+func (p probabilitySampler) sample() bool {
+	return rand.Float64() < float64(p)
+}
+
+// rateSampler traces up to rate queries per second. It is a token bucket that holds a second's
+// worth of traces, and at least one, so short bursts are traced as well.
+type rateSampler struct {
+	rate  float64
+	burst float64
+
+	sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newRateSampler(rate float64) *rateSampler {
+	burst := rate
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateSampler{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+func (s *rateSampler) sample() bool {
+	s.Lock()
+	defer s.Unlock()
+
+	now := time.Now()
+	s.tokens += now.Sub(s.last).Seconds() * s.rate
+	if s.tokens > s.burst {
+		s.tokens = s.burst
+	}
+	s.last = now
+
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
@@ -0,0 +1,59 @@
+package trace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEverySampler(t *testing.T) {
+	s := &everySampler{every: 3}
+	sampled := 0
+	for i := 0; i < 9; i++ {
+		if s.sample() {
+			sampled++
+		}
+	}
+	if sampled != 3 {
+		t.Errorf("Expected 3 of 9 queries sampled, got %d", sampled)
+	}
+
+	if (&everySampler{every: 0}).sample() {
+		t.Errorf("Expected every 0 to sample nothing")
+	}
+}
+
+func TestProbabilitySampler(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if probabilitySampler(0).sample() {
+			t.Fatalf("Expected probability 0 to sample nothing")
+		}
+		if !probabilitySampler(1).sample() {
+			t.Fatalf("Expected probability 1 to sample everything")
+		}
+	}
+}
+
+func TestRateSampler(t *testing.T) {
+	s := newRateSampler(5)
+	sampled := 0
+	for i := 0; i < 100; i++ {
+		if s.sample() {
+			sampled++
+		}
+	}
+	if sampled != 5 {
+		t.Errorf("Expected a burst of 5 sampled queries, got %d", sampled)
+	}
+
+	// After a second the bucket is full again.
+	s.last = s.last.Add(-time.Second)
+	if !s.sample() {
+		t.Errorf("Expected a query to be sampled after a second")
+	}
+
+	// Less than one per second still samples.
+	s = newRateSampler(0.5)
+	if !s.sample() || s.sample() {
+		t.Errorf("Expected one query of a burst to be sampled")
+	}
+}
@@ -30,14 +30,16 @@ func setup(c *caddy.Controller) error {
 	})
 
 	c.OnStartup(t.OnStartup)
+	c.OnShutdown(t.OnShutdown)
 
 	return nil
 }
 
 func traceParse(c *caddy.Controller) (*trace, error) {
 	var (
-		tr  = &trace{Endpoint: defEP, EndpointType: defEpType, every: 1, serviceName: defServiceName}
-		err error
+		tr       = &trace{Endpoint: defEP, EndpointType: defEpType, every: 1, serviceName: defServiceName}
+		err      error
+		samplers int // number of sampling options, only one is allowed
 	)
 
 	cfg := dnsserver.GetConfig(c)
@@ -49,6 +51,12 @@ func traceParse(c *caddy.Controller) (*trace, error) {
 		case 0:
 			tr.Endpoint, err = normalizeEndpoint(tr.EndpointType, defEP)
 		case 1:
+			if ep, ok := defEPs[strings.ToLower(args[0])]; ok {
+				// Only the type, with its default endpoint.
+				tr.EndpointType = strings.ToLower(args[0])
+				tr.Endpoint, err = normalizeEndpoint(tr.EndpointType, ep)
+				break
+			}
 			tr.Endpoint, err = normalizeEndpoint(defEpType, args[0])
 		case 2:
 			tr.EndpointType = strings.ToLower(args[0])
@@ -70,6 +78,35 @@ func traceParse(c *caddy.Controller) (*trace, error) {
 				if err != nil {
 					return nil, err
 				}
+				samplers++
+			case "probability":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				tr.probability, err = strconv.ParseFloat(args[0], 64)
+				if err != nil {
+					return nil, err
+				}
+				if tr.probability < 0 || tr.probability > 1 {
+					return nil, c.Errf("probability must be between 0 and 1: %s", args[0])
+				}
+				tr.sampler = probabilitySampler(tr.probability)
+				samplers++
+			case "rate":
+				args := c.RemainingArgs()
+				if len(args) != 1 {
+					return nil, c.ArgErr()
+				}
+				tr.rate, err = strconv.ParseFloat(args[0], 64)
+				if err != nil {
+					return nil, err
+				}
+				if tr.rate <= 0 {
+					return nil, c.Errf("rate must be positive: %s", args[0])
+				}
+				tr.sampler = newRateSampler(tr.rate)
+				samplers++
 			case "service":
 				args := c.RemainingArgs()
 				if len(args) != 1 {
@@ -88,9 +125,17 @@ func traceParse(c *caddy.Controller) (*trace, error) {
 				if err != nil {
 					return nil, err
 				}
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
 			}
 		}
 	}
+	if samplers > 1 {
+		return nil, c.Err("only one of every, probability and rate can be used")
+	}
+	if tr.sampler == nil {
+		tr.sampler = &everySampler{every: tr.every}
+	}
 	return tr, err
 }
 
@@ -101,6 +146,11 @@ func normalizeEndpoint(epType, ep string) (string, error) {
 			ep = "http://" + ep + "/api/v1/spans"
 		}
 		return ep, nil
+	case "otlp", "jaeger":
+		if !strings.Contains(ep, "http") {
+			ep = "http://" + ep + "/v1/traces"
+		}
+		return ep, nil
 	default:
 		return "", fmt.Errorf("tracing endpoint type '%s' is not supported", epType)
 	}
@@ -111,3 +161,11 @@ const (
 	defEpType      = "zipkin"
 	defServiceName = "coredns"
 )
+
+// defEPs are the default endpoints of the endpoint types. Jaeger receives OTLP on the same port as
+// OpenTelemetry collectors.
+var defEPs = map[string]string{
+	"zipkin": defEP,
+	"otlp":   "localhost:4318",
+	"jaeger": "localhost:4318",
+}
@@ -25,8 +25,17 @@ func TestTraceParse(t *testing.T) {
 		{"trace {\n every 100\n service foobar\nclient_server\n}", false, "http://localhost:9411/api/v1/spans", 100, `foobar`, true},
 		{"trace {\n every 2\n client_server true\n}", false, "http://localhost:9411/api/v1/spans", 2, `coredns`, true},
 		{"trace {\n client_server false\n}", false, "http://localhost:9411/api/v1/spans", 1, `coredns`, false},
+		{`trace otlp`, false, "http://localhost:4318/v1/traces", 1, `coredns`, false},
+		{`trace jaeger jaeger:4318`, false, "http://jaeger:4318/v1/traces", 1, `coredns`, false},
+		{`trace otlp https://otel.example.org/v1/traces`, false, "https://otel.example.org/v1/traces", 1, `coredns`, false},
+		{"trace otlp {\n probability 0.25\n}", false, "http://localhost:4318/v1/traces", 1, `coredns`, false},
+		{"trace otlp {\n rate 10\n}", false, "http://localhost:4318/v1/traces", 1, `coredns`, false},
 		// fails
 		{`trace footype localhost:4321`, true, "", 1, "", false},
+		{"trace {\n probability 1.5\n}", true, "", 1, "", false},
+		{"trace {\n rate 0\n}", true, "", 1, "", false},
+		{"trace {\n every 10\n rate 5\n}", true, "", 1, "", false},
+		{"trace {\n sample 5\n}", true, "", 1, "", false},
 		{"trace {\n every 2\n client_server junk\n}", true, "", 1, "", false},
 	}
 	for i, test := range tests {
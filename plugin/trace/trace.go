@@ -3,15 +3,17 @@ package trace
 
 import (
 	"fmt"
+	"io"
 	"sync"
-	"sync/atomic"
 
 	"github.com/coredns/coredns/plugin"
-	// Plugin the trace package.
-	_ "github.com/coredns/coredns/plugin/pkg/trace"
+	"github.com/coredns/coredns/plugin/pkg/dnsrecorder"
+	"github.com/coredns/coredns/plugin/pkg/metadata"
+	pkgtrace "github.com/coredns/coredns/plugin/pkg/trace"
 
 	"github.com/miekg/dns"
 	ot "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	zipkin "github.com/openzipkin/zipkin-go-opentracing"
 	"golang.org/x/net/context"
 )
@@ -22,10 +24,13 @@ type trace struct {
 	Endpoint        string
 	EndpointType    string
 	tracer          ot.Tracer
+	exporter        io.Closer // sends the spans, closed on shutdown
 	serviceName     string
 	clientServer    bool
 	every           uint64
-	count           uint64
+	probability     float64
+	rate            float64
+	sampler         sampler
 	Once            sync.Once
 }
 
@@ -40,6 +45,8 @@ func (t *trace) OnStartup() error {
 		switch t.EndpointType {
 		case "zipkin":
 			err = t.setupZipkin()
+		case "otlp", "jaeger":
+			err = t.setupOTLP()
 		default:
 			err = fmt.Errorf("unknown endpoint type: %s", t.EndpointType)
 		}
@@ -47,17 +54,40 @@ func (t *trace) OnStartup() error {
 	return err
 }
 
+// OnShutdown sends the spans that were not sent yet.
+func (t *trace) OnShutdown() error {
+	if t.exporter == nil {
+		return nil
+	}
+	return t.exporter.Close()
+}
+
 func (t *trace) setupZipkin() error {
 
 	collector, err := zipkin.NewHTTPCollector(t.Endpoint)
 	if err != nil {
 		return err
 	}
+	t.exporter = collector
 
 	recorder := zipkin.NewRecorder(collector, false, t.ServiceEndpoint, t.serviceName)
-	t.tracer, err = zipkin.NewTracer(recorder, zipkin.ClientServerSameSpan(t.clientServer))
+	return t.setupTracer(recorder)
+}
 
-	return err
+// setupOTLP sets up a tracer that exports with OTLP over HTTP, Jaeger receives this as well.
+func (t *trace) setupOTLP() error {
+	recorder := newOTLPRecorder(t.Endpoint, t.serviceName)
+	t.exporter = recorder
+	return t.setupTracer(recorder)
+}
+
+func (t *trace) setupTracer(recorder zipkin.SpanRecorder) error {
+	tracer, err := zipkin.NewTracer(recorder, zipkin.ClientServerSameSpan(t.clientServer), zipkin.TraceID128Bit(true))
+	if err != nil {
+		return err
+	}
+	t.tracer = w3cTracer{tracer}
+	return nil
 }
 
 // Name implements the Handler interface.
@@ -67,18 +97,32 @@ func (t *trace) Name() string {
 
 // ServeDNS implements the plugin.Handle interface.
 func (t *trace) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
-	trace := false
-	if t.every > 0 {
-		queryNr := atomic.AddUint64(&t.count, 1)
+	if span := ot.SpanFromContext(ctx); span != nil {
+		return plugin.NextOrFailure(t.Name(), t.Next, ctx, w, r)
+	}
 
-		if queryNr%t.every == 0 {
-			trace = true
-		}
+	// A query that is part of the client's trace is always traced, the client decided on the
+	// sampling.
+	var opts []ot.StartSpanOption
+	if parent := pkgtrace.ParentFromContext(ctx); parent != nil {
+		opts = append(opts, ot.ChildOf(parent))
+	} else if !t.sampler.sample() {
+		return plugin.NextOrFailure(t.Name(), t.Next, ctx, w, r)
+	}
+
+	span := t.Tracer().StartSpan("servedns", append(opts, ext.SpanKindRPCServer)...)
+	defer span.Finish()
+	plugin.TagSpan(span, r)
+	ctx = ot.ContextWithSpan(ctx, span)
+
+	rec := dnsrecorder.New(w)
+	rc, err := plugin.NextOrFailure(t.Name(), t.Next, ctx, rec, r)
+	if rec.Msg != nil {
+		span.SetTag("rcode", dns.RcodeToString[rec.Rcode])
 	}
-	if span := ot.SpanFromContext(ctx); span == nil && trace {
-		span := t.Tracer().StartSpan("servedns")
-		defer span.Finish()
-		ctx = ot.ContextWithSpan(ctx, span)
+	// Tag the span with what the plugins found out about the query.
+	for name, value := range metadata.Values(ctx) {
+		span.SetTag(name, value)
 	}
-	return plugin.NextOrFailure(t.Name(), t.Next, ctx, w, r)
+	return rc, err
 }
@@ -3,7 +3,13 @@ package trace
 import (
 	"testing"
 
+	pkgtrace "github.com/coredns/coredns/plugin/pkg/trace"
+	"github.com/coredns/coredns/plugin/test"
+
 	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+	zipkin "github.com/openzipkin/zipkin-go-opentracing"
+	"golang.org/x/net/context"
 )
 
 // createTestTrace creates a trace plugin to be used in tests
@@ -31,3 +37,58 @@ func TestTrace(t *testing.T) {
 		t.Errorf("Error, no tracer created")
 	}
 }
+
+func TestServeDNS(t *testing.T) {
+	recorder := zipkin.NewInMemoryRecorder()
+	tr := &trace{sampler: &everySampler{every: 1}}
+	if err := tr.setupTracer(recorder); err != nil {
+		t.Fatal(err)
+	}
+	tr.Next = test.HandlerFunc(func(_ context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
+		m := new(dns.Msg)
+		m.SetRcode(r, dns.RcodeNameError)
+		w.WriteMsg(m)
+		return dns.RcodeNameError, nil
+	})
+
+	q := new(dns.Msg)
+	q.SetQuestion("example.org.", dns.TypeAAAA)
+	tr.ServeDNS(context.TODO(), &test.ResponseWriter{}, q)
+
+	spans := recorder.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	// The child finishes first.
+	child, root := spans[0], spans[1]
+	if root.Operation != "servedns" || child.Operation != "handlerfunc" {
+		t.Errorf("Expected spans servedns and handlerfunc, got %s and %s", root.Operation, child.Operation)
+	}
+	if *child.Context.ParentSpanID != root.Context.SpanID {
+		t.Errorf("Expected handlerfunc to be a child of servedns")
+	}
+	for _, s := range spans {
+		if s.Tags["qname"] != "example.org." || s.Tags["qtype"] != "AAAA" || s.Tags["rcode"] != "NXDOMAIN" {
+			t.Errorf("Expected %s to be tagged with example.org. AAAA NXDOMAIN, got %v", s.Operation, s.Tags)
+		}
+	}
+
+	// Not sampled.
+	recorder.Reset()
+	tr.sampler = &everySampler{every: 0}
+	tr.ServeDNS(context.TODO(), &test.ResponseWriter{}, q)
+	if spans := recorder.GetSpans(); len(spans) != 0 {
+		t.Errorf("Expected no spans, got %d", len(spans))
+	}
+
+	// But the queries of a client's trace are.
+	parent, _ := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	tr.ServeDNS(pkgtrace.ContextWithParent(context.TODO(), parent), &test.ResponseWriter{}, q)
+	spans = recorder.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	if root := spans[1]; root.Context.TraceID.Low != 0xa3ce929d0e0e4736 || *root.Context.ParentSpanID != 0x00f067aa0ba902b7 {
+		t.Errorf("Expected servedns to be part of the client's trace, got %+v", root.Context)
+	}
+}
@@ -0,0 +1,86 @@
+package view
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+)
+
+func setupView(c *caddy.Controller) error {
+	v, err := viewParse(c)
+	if err != nil {
+		return plugin.Error("view", err)
+	}
+
+	config := dnsserver.GetConfig(c)
+	if config.View != "" {
+		return plugin.Error("view", fmt.Errorf("server block is already part of view %q", config.View))
+	}
+	config.View = v.name
+	config.FilterFuncs = append(config.FilterFuncs, v.match)
+	for name, secret := range v.keys {
+		if config.TsigSecret == nil {
+			config.TsigSecret = make(map[string]string)
+		}
+		config.TsigSecret[name] = secret
+	}
+
+	return nil
+}
+
+func viewParse(c *caddy.Controller) (*view, error) {
+	v := &view{keys: make(map[string]string)}
+
+	for c.Next() {
+		args := c.RemainingArgs()
+		if len(args) != 1 {
+			return nil, c.ArgErr()
+		}
+		v.name = args[0]
+
+		for c.NextBlock() {
+			switch c.Val() {
+			case "source", "ecs":
+				what := c.Val()
+				args := c.RemainingArgs()
+				if len(args) == 0 {
+					return nil, c.ArgErr()
+				}
+				for _, a := range args {
+					n, err := cidr.Parse(a)
+					if err != nil {
+						return nil, err
+					}
+					if what == "source" {
+						v.source = append(v.source, n)
+					} else {
+						v.ecs = append(v.ecs, n)
+					}
+				}
+			case "key":
+				args := c.RemainingArgs()
+				if len(args) != 2 {
+					return nil, c.ArgErr()
+				}
+				if _, err := base64.StdEncoding.DecodeString(args[1]); err != nil {
+					return nil, fmt.Errorf("invalid secret for key %s: %s", args[0], err)
+				}
+				v.keys[dns.Fqdn(strings.ToLower(args[0]))] = args[1]
+			default:
+				return nil, c.Errf("unknown property '%s'", c.Val())
+			}
+		}
+	}
+
+	if len(v.source) == 0 && len(v.ecs) == 0 && len(v.keys) == 0 {
+		return nil, fmt.Errorf("view %q does not select any queries", v.name)
+	}
+	return v, nil
+}
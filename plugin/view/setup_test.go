@@ -0,0 +1,79 @@
+package view
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/core/dnsserver"
+
+	"github.com/mholt/caddy"
+)
+
+func TestSetupView(t *testing.T) {
+	c := caddy.NewTestController("dns", `view internal {
+		source 10.0.0.0/8 192.168.1.1
+		ecs 172.16.0.0/12
+		key Transfer.Example.org. c2VjcmV0
+	}`)
+	if err := setupView(c); err != nil {
+		t.Fatalf("Expected no errors, but got: %v", err)
+	}
+
+	cfg := dnsserver.GetConfig(c)
+	if got, want := cfg.View, "internal"; got != want {
+		t.Errorf("Expected the config's View to be %s, was %s", want, got)
+	}
+	if x := len(cfg.FilterFuncs); x != 1 {
+		t.Errorf("Expected 1 filter, got %d", x)
+	}
+	if _, ok := cfg.TsigSecret["transfer.example.org."]; !ok {
+		t.Errorf("Expected the TSIG key to be added to the config")
+	}
+}
+
+func TestViewParse(t *testing.T) {
+	tests := []struct {
+		input     string
+		shouldErr bool
+	}{
+		{`view internal {
+			source 10.0.0.0/8
+		}`, false},
+		{`view internal {
+			source 10.0.0.0/8 ::1
+			ecs 2001:db8::/32
+		}`, false},
+		{`view internal`, true},
+		{`view {
+			source 10.0.0.0/8
+		}`, true},
+		{`view internal external {
+			source 10.0.0.0/8
+		}`, true},
+		{`view internal {
+			source 10.0.0.bla
+		}`, true},
+		{`view internal {
+			source
+		}`, true},
+		{`view internal {
+			key transfer.example.org.
+		}`, true},
+		{`view internal {
+			key transfer.example.org. not-base64!
+		}`, true},
+		{`view internal {
+			client 10.0.0.0/8
+		}`, true},
+	}
+
+	for i, test := range tests {
+		c := caddy.NewTestController("dns", test.input)
+		_, err := viewParse(c)
+		if test.shouldErr && err == nil {
+			t.Errorf("Test %d: Expected error but got none for input %s", i, test.input)
+		}
+		if !test.shouldErr && err != nil {
+			t.Errorf("Test %d: Expected no error but got %v for input %s", i, err, test.input)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Package view allows multiple server blocks to serve the same zone to different clients.
+package view
+
+import (
+	"net"
+	"strings"
+
+	"github.com/coredns/coredns/request"
+
+	"github.com/mholt/caddy"
+	"github.com/miekg/dns"
+)
+
+func init() {
+	caddy.RegisterPlugin("view", caddy.Plugin{
+		ServerType: "dns",
+		Action:     setupView,
+	})
+}
+
+// view selects the queries a server block handles. A query is selected if it matches any of the
+// criteria.
+type view struct {
+	name   string
+	source []*net.IPNet      // networks of the clients
+	ecs    []*net.IPNet      // networks in the EDNS0 client subnet option
+	keys   map[string]string // TSIG keys (and their secrets) the query may be signed with
+}
+
+// match returns true if the query in state belongs to the view.
+func (v *view) match(state request.Request) bool {
+	if ip := net.ParseIP(state.IP()); ip != nil && contains(v.source, ip) {
+		return true
+	}
+
+	if len(v.ecs) > 0 {
+		if opt := state.Req.IsEdns0(); opt != nil {
+			for _, o := range opt.Option {
+				if e, ok := o.(*dns.EDNS0_SUBNET); ok && contains(v.ecs, e.Address) {
+					return true
+				}
+			}
+		}
+	}
+
+	if len(v.keys) > 0 {
+		// The server has verified the signature if the key is known.
+		if t := state.Req.IsTsig(); t != nil && state.W.TsigStatus() == nil {
+			if _, ok := v.keys[strings.ToLower(t.Hdr.Name)]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// contains returns true if ip is in one of the networks.
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package view
+
+import (
+	"net"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/cidr"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/coredns/coredns/request"
+
+	"github.com/miekg/dns"
+)
+
+func TestMatch(t *testing.T) {
+	source, _ := cidr.Parse("10.240.0.0/16")
+	ecsNet, _ := cidr.Parse("192.0.2.0/24")
+	v := &view{
+		name:   "internal",
+		source: []*net.IPNet{source},
+		ecs:    []*net.IPNet{ecsNet},
+		keys:   map[string]string{"transfer.example.org.": "c2VjcmV0"},
+	}
+
+	plain := new(dns.Msg)
+	plain.SetQuestion("example.org.", dns.TypeA)
+
+	ecs := new(dns.Msg)
+	ecs.SetQuestion("example.org.", dns.TypeA)
+	ecs.SetEdns0(4096, false)
+	ecs.IsEdns0().Option = append(ecs.IsEdns0().Option, &dns.EDNS0_SUBNET{Code: dns.EDNS0SUBNET, Family: 1, SourceNetmask: 24, Address: net.ParseIP("192.0.2.0")})
+
+	signed := new(dns.Msg)
+	signed.SetQuestion("example.org.", dns.TypeA)
+	signed.SetTsig("Transfer.Example.org.", dns.HmacSHA256, 300, 0)
+
+	tests := []struct {
+		w        dns.ResponseWriter
+		r        *dns.Msg
+		expected bool
+	}{
+		{&test.ResponseWriter{}, plain, true},   // 10.240.0.1
+		{&test.ResponseWriter6{}, plain, false}, // fe80::42:ff:feca:4c65
+		{&test.ResponseWriter6{}, ecs, true},
+		{&test.ResponseWriter6{}, signed, true},
+	}
+
+	for i, tc := range tests {
+		state := request.Request{W: tc.w, Req: tc.r}
+		if got := v.match(state); got != tc.expected {
+			t.Errorf("Test %d: expected match to be %t, got %t", i, tc.expected, got)
+		}
+	}
+}
@@ -154,9 +154,17 @@ func (r *Request) SizeAndDo(m *dns.Msg) bool {
 		if odo {
 			mo.SetDo()
 		}
+		mo.Option = withoutCookie(mo.Option)
 		return true
 	}
 
+	if len(o.Option) > 0 {
+		// Copy, so the request keeps its options.
+		oc := *o
+		oc.Option = withoutCookie(o.Option)
+		o = &oc
+	}
+
 	o.Hdr.Name = "."
 	o.Hdr.Rrtype = dns.TypeOPT
 	o.SetVersion(0)
@@ -170,6 +178,19 @@ func (r *Request) SizeAndDo(m *dns.Msg) bool {
 	return true
 }
 
+// withoutCookie returns options without the COOKIE option. A cookie from the request (or from an
+// upstream) must not be echoed back to the client, it is only added by the cookie plugin.
+func withoutCookie(options []dns.EDNS0) []dns.EDNS0 {
+	var opts []dns.EDNS0
+	for _, e := range options {
+		if e.Option() == dns.EDNS0COOKIE {
+			continue
+		}
+		opts = append(opts, e)
+	}
+	return opts
+}
+
 // Result is the result of Scrub.
 type Result int
 
@@ -31,6 +31,23 @@ func TestRequestRemote(t *testing.T) {
 	}
 }
 
+func TestRequestSizeAndDoCookie(t *testing.T) {
+	st := testRequest()
+	o := st.Req.IsEdns0()
+	o.Option = append(o.Option, &dns.EDNS0_COOKIE{Code: dns.EDNS0COOKIE, Cookie: "0102030405060708"})
+
+	m := new(dns.Msg)
+	m.SetReply(st.Req)
+	st.SizeAndDo(m)
+
+	if mo := m.IsEdns0(); mo == nil || len(mo.Option) != 0 {
+		t.Errorf("Expected OPT without options in reply, got %v", mo)
+	}
+	if len(o.Option) != 1 {
+		t.Errorf("Expected request to keep its COOKIE option")
+	}
+}
+
 func TestRequestMalformed(t *testing.T) {
 	m := new(dns.Msg)
 	st := Request{Req: m}
@@ -0,0 +1,112 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/admin"
+
+	"github.com/miekg/dns"
+)
+
+func TestAdmin(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	name, rm, err := TempFile(".", exampleOrg)
+	if err != nil {
+		t.Fatalf("Failed to create zone: %s", err)
+	}
+	defer rm()
+
+	corefile := `example.org:0 {
+	admin localhost:0
+	cache
+	file ` + name + `
+}
+`
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	url := "http://" + admin.ListenAddr
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	if _, err := dns.Exchange(m, udp); err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+
+	var servers []struct {
+		Plugins []string `json:"plugins"`
+	}
+	adminDo(t, "GET", url+"/servers", http.StatusOK, &servers)
+	if len(servers) != 1 || strings.Join(servers[0].Plugins, " ") != "cache file" {
+		t.Errorf("Expected one server with cache and file, got %v", servers)
+	}
+
+	var entries []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	adminDo(t, "GET", url+"/cache?name=example.org", http.StatusOK, &entries)
+	if len(entries) != 1 || entries[0].Name != "example.org." || entries[0].Type != "A" {
+		t.Errorf("Expected cache entry for example.org. A, got %v", entries)
+	}
+
+	var purged struct {
+		Purged int `json:"purged"`
+	}
+	adminDo(t, "DELETE", url+"/cache", http.StatusOK, &purged)
+	if purged.Purged != 1 {
+		t.Errorf("Expected 1 purged entry, got %d", purged.Purged)
+	}
+
+	var zones []struct {
+		Zone   string `json:"zone"`
+		Serial int64  `json:"serial"`
+	}
+	adminDo(t, "GET", url+"/zones", http.StatusOK, &zones)
+	if len(zones) != 1 || zones[0].Serial != 2015082541 {
+		t.Errorf("Expected example.org. with serial 2015082541, got %v", zones)
+	}
+
+	newZone := strings.Replace(exampleOrg, "2015082541", "2015082542", 1)
+	if err := ioutil.WriteFile(name, []byte(newZone), 0644); err != nil {
+		t.Fatalf("Failed to write zone: %s", err)
+	}
+	adminDo(t, "POST", url+"/zones/reload?zone=example.org", http.StatusOK, &zones)
+	if len(zones) != 1 || zones[0].Serial != 2015082542 {
+		t.Errorf("Expected example.org. with serial 2015082542, got %v", zones)
+	}
+
+	adminDo(t, "POST", url+"/zones/reload?zone=example.net", http.StatusNotFound, nil)
+}
+
+// adminDo sends a request to the admin API and decodes the JSON reply into v.
+func adminDo(t *testing.T, method, url string, code int, v interface{}) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %s", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to %s %s: %s", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != code {
+		t.Errorf("Expected status %d for %s %s, got %d", code, method, url, resp.StatusCode)
+	}
+	if v == nil {
+		return
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Errorf("Failed to decode reply of %s %s: %s", method, url, err)
+	}
+}
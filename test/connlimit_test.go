@@ -0,0 +1,65 @@
+package test
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestConnLimit(t *testing.T) {
+	corefile := `.:0 {
+	connlimit {
+		max-per-client 1
+		max-queries 2
+	}
+	whoami
+}
+`
+	log.SetOutput(ioutil.Discard)
+
+	i, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+
+	c1, err := dns.Dial("tcp", tcp)
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer c1.Close()
+	if err := exchange(c1, m); err != nil {
+		t.Fatalf("Expected reply on first connection: %s", err)
+	}
+
+	// A second connection from us is closed.
+	c2, err := dns.Dial("tcp", tcp)
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer c2.Close()
+	if err := exchange(c2, m); err == nil {
+		t.Errorf("Expected second connection to be closed")
+	}
+
+	// The first connection is closed after two queries.
+	if err := exchange(c1, m); err != nil {
+		t.Fatalf("Expected reply to second query: %s", err)
+	}
+	if err := exchange(c1, m); err == nil {
+		t.Errorf("Expected connection to be closed after two queries")
+	}
+}
+
+func exchange(c *dns.Conn, m *dns.Msg) error {
+	if err := c.WriteMsg(m); err != nil {
+		return err
+	}
+	_, err := c.ReadMsg()
+	return err
+}
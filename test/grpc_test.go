@@ -1,6 +1,8 @@
 package test
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"testing"
@@ -9,6 +11,7 @@ import (
 	"github.com/miekg/dns"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 
 	"github.com/coredns/coredns/pb"
 )
@@ -57,3 +60,208 @@ func TestGrpc(t *testing.T) {
 		t.Errorf("Expected 2 RRs in additional section, but got %d", len(d.Extra))
 	}
 }
+
+func TestGrpcQueryStream(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	corefile := `grpc://.:0 {
+		whoami
+}
+`
+	g, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer g.Stop()
+
+	conn, err := grpc.Dial(tcp, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+	defer conn.Close()
+
+	client := pb.NewDnsServiceClient(conn)
+
+	stream, err := client.QueryStream(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+
+	ids := map[uint16]bool{}
+	for i := 0; i < 10; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion("whoami.example.org.", dns.TypeA)
+		m.Id = uint16(i + 1)
+		msg, _ := m.Pack()
+		if err := stream.Send(&pb.DnsPacket{Msg: msg}); err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+		ids[m.Id] = true
+	}
+	stream.CloseSend()
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+		d := new(dns.Msg)
+		if err := d.Unpack(reply.Msg); err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+		if !ids[d.Id] {
+			t.Errorf("Unexpected or duplicate reply with ID %d", d.Id)
+		}
+		delete(ids, d.Id)
+		if len(d.Extra) != 2 {
+			t.Errorf("Expected 2 RRs in additional section, but got %d", len(d.Extra))
+		}
+	}
+	if len(ids) != 0 {
+		t.Errorf("Expected replies to all queries, missing %d", len(ids))
+	}
+}
+
+func TestGrpcQueryStreamLimits(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	corefile := `grpc://.:0 {
+		whoami
+		connlimit {
+			max-queries 3
+		}
+}
+`
+	g, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer g.Stop()
+
+	conn, err := grpc.Dial(tcp, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+	defer conn.Close()
+
+	client := pb.NewDnsServiceClient(conn)
+
+	stream, err := client.QueryStream(context.TODO())
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+
+	// A header that announces a question which isn't there.
+	bad := []byte{0, 42, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0}
+	if err := stream.Send(&pb.DnsPacket{Msg: bad}); err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+	// The fourth query exceeds max-queries.
+	for i := 0; i < 3; i++ {
+		m := new(dns.Msg)
+		m.SetQuestion("whoami.example.org.", dns.TypeA)
+		m.Id = uint16(i + 1)
+		msg, _ := m.Pack()
+		if err := stream.Send(&pb.DnsPacket{Msg: msg}); err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+	}
+
+	rcodes := map[uint16]int{}
+	for {
+		reply, err := stream.Recv()
+		if err != nil {
+			if grpc.Code(err) != codes.ResourceExhausted {
+				t.Errorf("Expected stream to end with %s, got %s", codes.ResourceExhausted, err)
+			}
+			break
+		}
+		// The FORMERR reply has no question, the header is unpacked anyway.
+		d := new(dns.Msg)
+		if err := d.Unpack(reply.Msg); err != nil && err != dns.ErrTruncated {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+		rcodes[d.Id] = d.Rcode
+	}
+
+	expected := map[uint16]int{42: dns.RcodeFormatError, 1: dns.RcodeSuccess, 2: dns.RcodeSuccess}
+	if len(rcodes) != len(expected) {
+		t.Errorf("Expected %d replies, got %d", len(expected), len(rcodes))
+	}
+	for id, rcode := range expected {
+		if got, ok := rcodes[id]; !ok || got != rcode {
+			t.Errorf("Expected rcode %d for ID %d, got %d", rcode, id, got)
+		}
+	}
+}
+
+func TestGrpcTransfer(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	zone := "example.org.	3600	IN	SOA	sns.dns.icann.org. noc.dns.icann.org. 2017042745 7200 3600 1209600 3600\n"
+	for i := 0; i < 100; i++ {
+		zone += fmt.Sprintf("host%d.example.org.	3600	IN	A	10.0.0.%d\n", i, i)
+	}
+	name, rm, err := TempFile(".", zone)
+	if err != nil {
+		t.Fatalf("Failed to create zone: %s", err)
+	}
+	defer rm()
+
+	corefile := `grpc://example.org:0 {
+		file ` + name + ` {
+			transfer to *
+		}
+}
+`
+	g, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer g.Stop()
+
+	conn, err := grpc.Dial(tcp, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+	defer conn.Close()
+
+	client := pb.NewDnsServiceClient(conn)
+
+	m := new(dns.Msg)
+	m.SetAxfr("example.org.")
+	msg, _ := m.Pack()
+
+	stream, err := client.Transfer(context.TODO(), &pb.DnsPacket{Msg: msg})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+
+	messages, records := 0, 0
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+		d := new(dns.Msg)
+		if err := d.Unpack(reply.Msg); err != nil {
+			t.Fatalf("Expected no error but got: %s", err)
+		}
+		messages++
+		records += len(d.Answer)
+	}
+
+	if messages < 2 {
+		t.Errorf("Expected transfer to span several messages, got %d", messages)
+	}
+	// SOA, 100 A records and the closing SOA.
+	if records != 102 {
+		t.Errorf("Expected 102 records, got %d", records)
+	}
+}
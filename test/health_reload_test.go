@@ -49,4 +49,14 @@ func TestHealthReload(t *testing.T) {
 		t.Fatalf("Expect OK, got %s", x)
 	}
 	resp.Body.Close()
+
+	// Without plugins that implement readiness, the server is ready.
+	resp, err = http.Get("http://localhost:35080/ready")
+	if err != nil {
+		t.Fatalf("Could not get ready: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expect status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	resp.Body.Close()
 }
@@ -1,8 +1,10 @@
 package test
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
 	"path"
 	"testing"
@@ -185,3 +187,62 @@ func TestMetricsAuto(t *testing.T) {
 		t.Errorf("Expected value %s for %s, but got %s", "1", metricName, got)
 	}
 }
+
+func TestMetricsTop(t *testing.T) {
+	corefile := `example.org:0 {
+	chaos CoreDNS-001 miek@miek.nl
+	prometheus localhost:0 {
+		top 10
+		top_window 1s
+	}
+}
+`
+	srv, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer srv.Stop()
+
+	m := new(dns.Msg)
+	m.SetQuestion("www.Example.org.", dns.TypeA)
+	for i := 0; i < 3; i++ {
+		if _, err = dns.Exchange(m, udp); err != nil {
+			t.Fatalf("Could not send message: %s", err)
+		}
+	}
+
+	time.Sleep(1100 * time.Millisecond) // wait for the window to be complete
+
+	data := mtest.Scrape(t, "http://"+metrics.ListenAddr+"/metrics")
+	got, _ := mtest.MetricValueLabel("coredns_dns_top_qname_requests", "www.example.org.", data)
+	if got != "3" {
+		t.Errorf("Expected value %s for %s, but got %s", "3", "www.example.org.", got)
+	}
+	// The client is either 127.0.0.1/32 or ::1/128.
+	got, labels := mtest.MetricValue("coredns_dns_top_client_requests", data)
+	if got != "3" {
+		t.Errorf("Expected value %s for %s, but got %s", "3", labels["client"], got)
+	}
+
+	resp, err := http.Get("http://" + metrics.ListenAddr + "/top")
+	if err != nil {
+		t.Fatalf("Could not get top: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var servers []struct {
+		Server string
+		Last   struct {
+			Qnames []struct {
+				Key   string
+				Count uint64
+			}
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&servers); err != nil {
+		t.Fatalf("Could not decode top: %s", err)
+	}
+	if len(servers) != 1 || len(servers[0].Last.Qnames) != 1 || servers[0].Last.Qnames[0].Count != 3 {
+		t.Errorf("Expected www.example.org. to be counted 3 times in the last window, got %+v", servers)
+	}
+}
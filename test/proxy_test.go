@@ -5,11 +5,13 @@ import (
 	"log"
 	"testing"
 
+	"github.com/coredns/coredns/plugin/pkg/metadata"
 	"github.com/coredns/coredns/plugin/proxy"
 	"github.com/coredns/coredns/plugin/test"
 	"github.com/coredns/coredns/request"
 
 	"github.com/miekg/dns"
+	"golang.org/x/net/context"
 )
 
 func TestLookupProxy(t *testing.T) {
@@ -127,3 +129,36 @@ func BenchmarkProxyLookup(b *testing.B) {
 		}
 	}
 }
+
+func TestProxyUpstreamMetadata(t *testing.T) {
+	t.Parallel()
+	name, rm, err := test.TempFile(".", exampleOrg)
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm()
+
+	corefile := `example.org:0 {
+       file ` + name + `
+}
+`
+
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	log.SetOutput(ioutil.Discard)
+
+	p := proxy.NewLookup([]string{udp})
+	ctx := metadata.NewContext(context.TODO())
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	if _, err := p.ServeDNS(ctx, &test.ResponseWriter{}, m); err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+	if v, _ := metadata.Get(ctx, proxy.UpstreamKey); v != udp {
+		t.Errorf("Expected upstream to be %s, got %q", udp, v)
+	}
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestProxyProtocol(t *testing.T) {
+	corefile := `.:0 {
+	proxyprotocol 127.0.0.1 ::1
+	whoami
+}
+`
+	log.SetOutput(ioutil.Discard)
+
+	i, udp, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	query, _ := m.Pack()
+
+	// UDP with a version 2 header from 192.0.2.1:4711.
+	hdr := []byte("\r\n\r\n\x00\r\nQUIT\n\x21\x12\x00\x0c" + "\xc0\x00\x02\x01" + "\x7f\x00\x00\x01" + "\x12\x67\x00\x35")
+	conn, err := net.Dial("udp", udp)
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer conn.Close()
+	conn.Write(append(hdr, query...))
+	c := &dns.Conn{Conn: conn}
+	r, err := c.ReadMsg()
+	if err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+	checkWhoami(t, r, "192.0.2.1", 4711)
+
+	// TCP with a version 1 header.
+	tconn, err := net.Dial("tcp", tcp)
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer tconn.Close()
+	tconn.Write([]byte("PROXY TCP6 2001:db8::1 ::1 4712 53\r\n"))
+	c = &dns.Conn{Conn: tconn}
+	if err := c.WriteMsg(m); err != nil {
+		t.Fatalf("Failed to write: %s", err)
+	}
+	r, err = c.ReadMsg()
+	if err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+	checkWhoami(t, r, "2001:db8::1", 4712)
+
+	// Without a header the address of the load balancer is used.
+	conn2, err := net.Dial("udp", udp)
+	if err != nil {
+		t.Fatalf("Failed to dial: %s", err)
+	}
+	defer conn2.Close()
+	conn2.Write(query)
+	c = &dns.Conn{Conn: conn2}
+	r, err = c.ReadMsg()
+	if err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+	local := conn2.LocalAddr().(*net.UDPAddr)
+	checkWhoami(t, r, local.IP.String(), local.Port)
+}
+
+// checkWhoami checks the whoami reply carries ip and port.
+func checkWhoami(t *testing.T, r *dns.Msg, ip string, port int) {
+	var addr net.IP
+	var p int
+	for _, rr := range r.Extra {
+		switch x := rr.(type) {
+		case *dns.A:
+			addr = x.A
+		case *dns.AAAA:
+			addr = x.AAAA
+		case *dns.SRV:
+			p = int(x.Port)
+		}
+	}
+	if !addr.Equal(net.ParseIP(ip)) {
+		t.Errorf("Expected client address %s, got %s", ip, addr)
+	}
+	if p != port {
+		t.Errorf("Expected client port %d, got %d", port, p)
+	}
+}
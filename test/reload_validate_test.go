@@ -0,0 +1,199 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/core/dnsserver"
+	"github.com/coredns/coredns/plugin/admin"
+	"github.com/coredns/coredns/plugin/metrics/vars"
+
+	"github.com/mholt/caddy"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestReloadValidate(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	corefile, err := ioutil.TempFile("", "Corefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(corefile.Name())
+
+	write := func(contents string) {
+		if err := ioutil.WriteFile(corefile.Name(), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`.:0 {
+	whoami
+	admin localhost:0
+}
+`)
+	contents, _ := ioutil.ReadFile(corefile.Name())
+	i, err := caddy.Start(caddy.CaddyfileInput{Contents: contents, Filepath: corefile.Name(), ServerTypeName: "dns"})
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	dnsserver.SetInstance(i)
+	defer func() {
+		dnsserver.Instance().Stop()
+		dnsserver.SetInstance(nil)
+	}()
+
+	// An invalid Corefile keeps the running servers.
+	invalid := reloads("invalid")
+	write(`.:0 {
+	whoami bla
+	admin localhost:0
+}
+`)
+	if _, err := dnsserver.Reload(); err == nil {
+		t.Fatalf("Expected reload of an invalid Corefile to fail")
+	}
+	if dnsserver.Instance() != i {
+		t.Fatalf("Expected the running instance to be kept")
+	}
+	if x := reloads("invalid"); x != invalid+1 {
+		t.Errorf("Expected %v invalid reloads, got %v", invalid+1, x)
+	}
+	udp, _ := CoreDNSServerPorts(i, 0)
+	send(t, udp)
+
+	// A valid Corefile replaces them, here through the admin API.
+	success := reloads("success")
+	write(`.:0 {
+	whoami
+	admin localhost:0
+	errors
+}
+`)
+	resp, err := http.Post("http://"+admin.ListenAddr+"/reload", "", nil)
+	if err != nil {
+		t.Fatalf("Could not reload: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	var reply struct {
+		Changes []string
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		t.Fatalf("Could not decode reply: %s", err)
+	}
+	if len(reply.Changes) != 1 || reply.Changes[0] != "+errors" {
+		t.Errorf("Expected changes [+errors], got %v", reply.Changes)
+	}
+
+	if dnsserver.Instance() == i {
+		t.Fatalf("Expected the running instance to be replaced")
+	}
+	if x := reloads("success"); x != success+1 {
+		t.Errorf("Expected %v successful reloads, got %v", success+1, x)
+	}
+	udp, _ = CoreDNSServerPorts(dnsserver.Instance(), 0)
+	send(t, udp)
+}
+
+func TestReloadMetricsAddress(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	corefile, err := ioutil.TempFile("", "Corefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(corefile.Name())
+
+	write := func(contents string) {
+		if err := ioutil.WriteFile(corefile.Name(), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`.:0 {
+	whoami
+}
+`)
+	contents, _ := ioutil.ReadFile(corefile.Name())
+	i, err := caddy.Start(caddy.CaddyfileInput{Contents: contents, Filepath: corefile.Name(), ServerTypeName: "dns"})
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	dnsserver.SetInstance(i)
+	defer func() {
+		dnsserver.Instance().Stop()
+		dnsserver.SetInstance(nil)
+	}()
+
+	// A dnstap receiver, validating must not connect to it.
+	tap, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tap.Close()
+	tapped := make(chan bool, 1)
+	go func() {
+		if c, err := tap.Accept(); err == nil {
+			c.Close()
+			tapped <- true
+		}
+	}()
+
+	addr := freeAddr(t)
+	write(`.:0 {
+	whoami
+	prometheus ` + addr + `
+	dnstap tcp://` + tap.Addr().String() + `
+}
+`)
+	contents, _ = ioutil.ReadFile(corefile.Name())
+	if err := dnsserver.Validate(caddy.CaddyfileInput{Contents: contents, Filepath: corefile.Name(), ServerTypeName: "dns"}); err != nil {
+		t.Fatalf("Expected Corefile to be valid, got %s", err)
+	}
+	if _, err := http.Get("http://" + addr + "/metrics"); err == nil {
+		t.Fatalf("Expected validating not to start the metrics listener")
+	}
+	select {
+	case <-tapped:
+		t.Fatalf("Expected validating not to connect to dnstap")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The metrics address added by the reload is listened on.
+	if _, err := dnsserver.Reload(); err != nil {
+		t.Fatalf("Could not reload: %s", err)
+	}
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Expected metrics on %s: %s", addr, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+// freeAddr returns a local address that nothing listens on.
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+func reloads(result string) float64 {
+	m := &dto.Metric{}
+	vars.ReloadCount.WithLabelValues(result).Write(m)
+	return m.GetCounter().GetValue()
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"io/ioutil"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestReusePort(t *testing.T) {
+	corefile := `.:0 {
+	reuseport 4
+	whoami
+}
+`
+	log.SetOutput(ioutil.Discard)
+
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	// Different source ports, so the queries are spread over the sockets.
+	for j := 0; j < 20; j++ {
+		if _, err := dns.Exchange(m, udp); err != nil {
+			t.Fatalf("Expected to receive reply, but didn't: %s", err)
+		}
+	}
+
+	i.Stop()
+
+	// All sockets must be closed, otherwise we can't bind without SO_REUSEPORT.
+	p, err := net.ListenPacket("udp", udp)
+	if err != nil {
+		t.Fatalf("Expected all sockets to be closed: %s", err)
+	}
+	p.Close()
+}
+
+func TestReusePortReload(t *testing.T) {
+	corefile := `.:0 {
+	whoami
+}
+`
+	log.SetOutput(ioutil.Discard)
+
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+
+	// The socket is inherited from the first server, which has no SO_REUSEPORT set, so the
+	// reloaded server serves that single socket.
+	corefile = `.:0 {
+	reuseport 4
+	whoami
+}
+`
+	i, err = i.Restart(NewInput(corefile))
+	if err != nil {
+		t.Fatalf("Could not restart CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	m := new(dns.Msg)
+	m.SetQuestion("example.org.", dns.TypeA)
+	if _, err := dns.Exchange(m, udp); err != nil {
+		t.Fatalf("Expected to receive reply, but didn't: %s", err)
+	}
+}
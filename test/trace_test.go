@@ -0,0 +1,95 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/coredns/coredns/pb"
+)
+
+func TestTraceGrpcParent(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+
+	type span struct {
+		TraceID      string `json:"traceId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+	}
+	spans := make(chan span, 10)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []span `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}{}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					spans <- s
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	// Nothing is sampled, unless the client traces the query.
+	corefile := `grpc://.:0 {
+		trace otlp ` + collector.URL + `/v1/traces {
+			every 0
+		}
+		whoami
+}
+`
+	g, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer g.Stop()
+
+	conn, err := grpc.Dial(tcp, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+	defer conn.Close()
+
+	m := new(dns.Msg)
+	m.SetQuestion("whoami.example.org.", dns.TypeA)
+	msg, _ := m.Pack()
+
+	ctx := metadata.NewOutgoingContext(context.TODO(), metadata.Pairs("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	if _, err := pb.NewDnsServiceClient(conn).Query(ctx, &pb.DnsPacket{Msg: msg}); err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+
+	found := map[string]span{}
+	timeout := time.After(5 * time.Second)
+	for len(found) < 2 {
+		select {
+		case s := <-spans:
+			found[s.Name] = s
+		case <-timeout:
+			t.Fatalf("Expected spans servedns and whoami, got %v", found)
+		}
+	}
+	for name, s := range found {
+		if s.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("Expected %s to be part of trace 4bf92f3577b34da6a3ce929d0e0e4736, got %s", name, s.TraceID)
+		}
+	}
+	if p := found["servedns"].ParentSpanID; p != "00f067aa0ba902b7" {
+		t.Errorf("Expected servedns to be a child of 00f067aa0ba902b7, got %s", p)
+	}
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/pb"
+	"github.com/coredns/coredns/plugin/test"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func viewZone(addr string) string {
+	return `$ORIGIN example.org.
+@	3600 IN	SOA sns.dns.icann.org. noc.dns.icann.org. 2017042745 7200 3600 1209600 3600
+	3600 IN NS a.iana-servers.net.
+www	3600 IN A ` + addr + `
+`
+}
+
+func TestViews(t *testing.T) {
+	t.Parallel()
+	local, rm1, err := test.TempFile(".", viewZone("10.0.0.1"))
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm1()
+	signed, rm2, err := test.TempFile(".", viewZone("10.0.0.2"))
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm2()
+	public, rm3, err := test.TempFile(".", viewZone("192.0.2.1"))
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm3()
+
+	corefile := `example.org:0 {
+	file ` + public + `
+}
+example.org:0 {
+	view signed {
+		key transfer.example.org. c2VjcmV0
+	}
+	file ` + signed + `
+}
+example.org:0 {
+	view local {
+		source 127.0.0.0/8 ::1
+	}
+	file ` + local + `
+}
+`
+
+	i, udp, _, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	log.SetOutput(ioutil.Discard)
+
+	m := new(dns.Msg)
+	m.SetQuestion("www.example.org.", dns.TypeA)
+	r, err := dns.Exchange(m, udp)
+	if err != nil {
+		t.Fatalf("Could not send message: %s", err)
+	}
+	if len(r.Answer) != 1 || r.Answer[0].(*dns.A).A.String() != "10.0.0.1" {
+		t.Errorf("Expected answer from the local view, got %v", r.Answer)
+	}
+
+	c := new(dns.Client)
+	c.TsigSecret = map[string]string{"transfer.example.org.": "c2VjcmV0"}
+	m = new(dns.Msg)
+	m.SetQuestion("www.example.org.", dns.TypeA)
+	m.SetTsig("transfer.example.org.", dns.HmacSHA256, 300, time.Now().Unix())
+	r, _, err = c.Exchange(m, udp)
+	if err != nil {
+		t.Fatalf("Could not send signed message: %s", err)
+	}
+	if len(r.Answer) != 1 || r.Answer[0].(*dns.A).A.String() != "10.0.0.2" {
+		t.Errorf("Expected answer from the signed view, got %v", r.Answer)
+	}
+}
+
+func TestViewsGrpcTsig(t *testing.T) {
+	t.Parallel()
+	signed, rm1, err := test.TempFile(".", viewZone("10.0.0.2"))
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm1()
+	public, rm2, err := test.TempFile(".", viewZone("192.0.2.1"))
+	if err != nil {
+		t.Fatalf("failed to create zone: %s", err)
+	}
+	defer rm2()
+
+	corefile := `grpc://example.org:0 {
+	file ` + public + `
+}
+grpc://example.org:0 {
+	view signed {
+		key transfer.example.org. c2VjcmV0
+	}
+	file ` + signed + `
+}
+`
+	i, _, tcp, err := CoreDNSServerAndPorts(corefile)
+	if err != nil {
+		t.Fatalf("Could not get CoreDNS serving instance: %s", err)
+	}
+	defer i.Stop()
+
+	log.SetOutput(ioutil.Discard)
+
+	conn, err := grpc.Dial(tcp, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error but got: %s", err)
+	}
+	defer conn.Close()
+	client := pb.NewDnsServiceClient(conn)
+
+	tests := []struct {
+		name   string
+		secret string // empty for a TSIG record without a MAC
+		answer string
+	}{
+		{"signed", "c2VjcmV0", "10.0.0.2"},
+		{"unsigned", "", "192.0.2.1"},
+		{"bad MAC", "d3Jvbmc=", "192.0.2.1"},
+	}
+	for _, tc := range tests {
+		m := new(dns.Msg)
+		m.SetQuestion("www.example.org.", dns.TypeA)
+		m.SetTsig("transfer.example.org.", dns.HmacSHA256, 300, time.Now().Unix())
+
+		var msg []byte
+		var mac string
+		if tc.secret == "" {
+			msg, err = m.Pack()
+		} else {
+			msg, mac, err = dns.TsigGenerate(m, tc.secret, "", false)
+		}
+		if err != nil {
+			t.Fatalf("Test %s: could not pack message: %s", tc.name, err)
+		}
+
+		reply, err := client.Query(context.TODO(), &pb.DnsPacket{Msg: msg})
+		if err != nil {
+			t.Fatalf("Test %s: expected no error but got: %s", tc.name, err)
+		}
+		if tc.secret == "c2VjcmV0" {
+			// The response to a signed query is signed.
+			if err := dns.TsigVerify(reply.Msg, tc.secret, mac, false); err != nil {
+				t.Errorf("Test %s: expected a signed response, got: %s", tc.name, err)
+			}
+		}
+		r := new(dns.Msg)
+		if err := r.Unpack(reply.Msg); err != nil {
+			t.Fatalf("Test %s: could not unpack reply: %s", tc.name, err)
+		}
+		if len(r.Answer) != 1 || r.Answer[0].(*dns.A).A.String() != tc.answer {
+			t.Errorf("Test %s: expected answer %s, got %v", tc.name, tc.answer, r.Answer)
+		}
+	}
+}